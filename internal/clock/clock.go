@@ -0,0 +1,62 @@
+package clock
+
+import (
+	"sync"
+	"time"
+)
+
+// Clock - источник текущего времени. Подменяется в тестах, чтобы
+// время блоков и проверки отметок времени были воспроизводимы.
+type Clock interface {
+	Now() time.Time
+}
+
+type systemClock struct{}
+
+func (systemClock) Now() time.Time {
+	return time.Now()
+}
+
+// System - системные часы
+var System Clock = systemClock{}
+
+// OrSystem возвращает c или System, если c не задан
+func OrSystem(c Clock) Clock {
+	if c == nil {
+		return System
+	}
+	return c
+}
+
+// Manual - часы, которые идут только по команде. Безопасны для
+// конкурентного использования.
+type Manual struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+// NewManual создаёт часы, показывающие t
+func NewManual(t time.Time) *Manual {
+	return &Manual{now: t}
+}
+
+func (m *Manual) Now() time.Time {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.now
+}
+
+// Set переводит часы на t
+func (m *Manual) Set(t time.Time) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.now = t
+}
+
+// Advance сдвигает часы на d и возвращает новое время
+func (m *Manual) Advance(d time.Duration) time.Time {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.now = m.now.Add(d)
+	return m.now
+}
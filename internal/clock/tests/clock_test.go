@@ -0,0 +1,53 @@
+package tests
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Alex1997377/weave/internal/clock"
+)
+
+func TestManual(t *testing.T) {
+	start := time.Unix(1735689600, 0)
+	c := clock.NewManual(start)
+
+	if !c.Now().Equal(start) {
+		t.Fatalf("Now = %v, want %v", c.Now(), start)
+	}
+	if got := c.Advance(90 * time.Second); !got.Equal(start.Add(90*time.Second)) || !c.Now().Equal(got) {
+		t.Errorf("Advance = %v, Now = %v", got, c.Now())
+	}
+
+	c.Set(start)
+	if !c.Now().Equal(start) {
+		t.Errorf("Set did not move clock back: %v", c.Now())
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			c.Advance(time.Second)
+			c.Now()
+		}()
+	}
+	wg.Wait()
+	if !c.Now().Equal(start.Add(8 * time.Second)) {
+		t.Errorf("concurrent Advance lost updates: %v", c.Now())
+	}
+}
+
+func TestOrSystem(t *testing.T) {
+	if clock.OrSystem(nil) != clock.System {
+		t.Error("OrSystem(nil) must return System")
+	}
+	manual := clock.NewManual(time.Unix(1, 0))
+	if clock.OrSystem(manual) != manual {
+		t.Error("OrSystem must keep explicit clock")
+	}
+	if d := time.Since(clock.System.Now()); d < 0 || d > time.Minute {
+		t.Errorf("System clock is off by %v", d)
+	}
+}
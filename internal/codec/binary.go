@@ -0,0 +1,46 @@
+package codec
+
+import (
+	"bytes"
+	"fmt"
+
+	"github.com/Alex1997377/weave/internal/core/block"
+	"github.com/Alex1997377/weave/internal/core/transaction"
+)
+
+// Binary - канонический бинарный формат: конверт блока и транзакции с тегом типа
+type Binary struct{}
+
+func (Binary) Name() string        { return NameBinary }
+func (Binary) ID() byte            { return 0x01 }
+func (Binary) ContentType() string { return "application/octet-stream" }
+
+func (Binary) EncodeBlock(b *block.Block) ([]byte, error) {
+	if b == nil {
+		return nil, ErrNilBlock
+	}
+	return b.Serialize()
+}
+
+func (Binary) DecodeBlock(data []byte) (*block.Block, error) {
+	return block.DeserializeBlock(data)
+}
+
+func (Binary) EncodeTransaction(tx transaction.Transaction) ([]byte, error) {
+	if tx == nil {
+		return nil, ErrNilTransaction
+	}
+	return transaction.EncodeTransaction(tx)
+}
+
+func (Binary) DecodeTransaction(data []byte) (transaction.Transaction, error) {
+	r := bytes.NewReader(data)
+	tx, err := transaction.DecodeTransaction(r)
+	if err != nil {
+		return nil, err
+	}
+	if r.Len() != 0 {
+		return nil, fmt.Errorf("transaction has %d trailing bytes", r.Len())
+	}
+	return tx, nil
+}
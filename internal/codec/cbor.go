@@ -0,0 +1,388 @@
+package codec
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+
+	"github.com/Alex1997377/weave/internal/core/block"
+	"github.com/Alex1997377/weave/internal/core/header"
+	"github.com/Alex1997377/weave/internal/core/transaction"
+)
+
+// CBOR - компактный бинарный формат на подмножестве RFC 8949 (целые, байтовые
+// строки и массивы фиксированной длины), без внешних зависимостей:
+//
+//	block       = [header, [tx, ...], hash: bstr, size: uint]
+//	header      = [version, index, timestamp: int, prev: bstr, merkle: bstr, difficulty, bits, nonce]
+//	tx          = [type: uint, body: bstr]   body = TransactionSerialize
+type CBOR struct{}
+
+func (CBOR) Name() string        { return NameCBOR }
+func (CBOR) ID() byte            { return 0x03 }
+func (CBOR) ContentType() string { return "application/cbor" }
+
+const (
+	cborHeaderFields = 8
+	cborBlockFields  = 4
+	cborTxFields     = 2
+)
+
+// Основные типы CBOR
+const (
+	cborUint  byte = 0
+	cborNeg   byte = 1
+	cborBytes byte = 2
+	cborArray byte = 4
+)
+
+var errCBORTrailing = errors.New("cbor: trailing data")
+
+func (CBOR) EncodeBlock(b *block.Block) ([]byte, error) {
+	if b == nil {
+		return nil, ErrNilBlock
+	}
+
+	var w cborWriter
+	w.head(cborArray, cborBlockFields)
+
+	h := &b.Header
+	w.head(cborArray, cborHeaderFields)
+	w.uint(uint64(h.Version))
+	w.int(int64(h.Index))
+	w.int(h.Timestamp)
+	w.bytes(h.PreviousHash)
+	w.bytes(h.MerkleRoot)
+	w.int(int64(h.Difficulty))
+	w.uint(uint64(h.Bits))
+	w.uint(h.Nonce)
+
+	w.head(cborArray, uint64(len(b.Transaction)))
+	for i, tx := range b.Transaction {
+		if err := w.transaction(tx); err != nil {
+			return nil, fmt.Errorf("failed to encode transaction %d: %w", i, err)
+		}
+	}
+
+	w.bytes(b.Hash)
+	w.uint(uint64(b.Size))
+
+	return w.buf.Bytes(), nil
+}
+
+func (CBOR) DecodeBlock(data []byte) (*block.Block, error) {
+	r := &cborReader{data: data}
+
+	if err := r.array(cborBlockFields, "block"); err != nil {
+		return nil, err
+	}
+
+	h, err := r.header()
+	if err != nil {
+		return nil, err
+	}
+
+	txCount, err := r.head(cborArray, "transactions")
+	if err != nil {
+		return nil, err
+	}
+	if txCount > block.MaxTransactions {
+		return nil, fmt.Errorf("transaction count too high: %d (max: %d)", txCount, block.MaxTransactions)
+	}
+
+	b := &block.Block{
+		Header:      *h,
+		Transaction: make([]transaction.Transaction, 0, txCount),
+	}
+	for i := uint64(0); i < txCount; i++ {
+		tx, err := r.transaction()
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode transaction %d: %w", i, err)
+		}
+		b.Transaction = append(b.Transaction, tx)
+	}
+
+	if b.Hash, err = r.bytes("hash"); err != nil {
+		return nil, err
+	}
+
+	size, err := r.uint("size", math.MaxUint32)
+	if err != nil {
+		return nil, err
+	}
+	b.Size = uint32(size)
+
+	if err := r.end(); err != nil {
+		return nil, err
+	}
+	return b, nil
+}
+
+func (CBOR) EncodeTransaction(tx transaction.Transaction) ([]byte, error) {
+	var w cborWriter
+	if err := w.transaction(tx); err != nil {
+		return nil, err
+	}
+	return w.buf.Bytes(), nil
+}
+
+func (CBOR) DecodeTransaction(data []byte) (transaction.Transaction, error) {
+	r := &cborReader{data: data}
+	tx, err := r.transaction()
+	if err != nil {
+		return nil, err
+	}
+	if err := r.end(); err != nil {
+		return nil, err
+	}
+	return tx, nil
+}
+
+type cborWriter struct {
+	buf bytes.Buffer
+}
+
+// head пишет начальный байт элемента и его аргумент в кратчайшей форме
+func (w *cborWriter) head(major byte, n uint64) {
+	var scratch [9]byte
+	switch {
+	case n < 24:
+		w.buf.WriteByte(major<<5 | byte(n))
+		return
+	case n <= math.MaxUint8:
+		scratch[0] = major<<5 | 24
+		scratch[1] = byte(n)
+		w.buf.Write(scratch[:2])
+	case n <= math.MaxUint16:
+		scratch[0] = major<<5 | 25
+		binary.BigEndian.PutUint16(scratch[1:], uint16(n))
+		w.buf.Write(scratch[:3])
+	case n <= math.MaxUint32:
+		scratch[0] = major<<5 | 26
+		binary.BigEndian.PutUint32(scratch[1:], uint32(n))
+		w.buf.Write(scratch[:5])
+	default:
+		scratch[0] = major<<5 | 27
+		binary.BigEndian.PutUint64(scratch[1:], n)
+		w.buf.Write(scratch[:9])
+	}
+}
+
+func (w *cborWriter) uint(n uint64) {
+	w.head(cborUint, n)
+}
+
+func (w *cborWriter) int(n int64) {
+	if n >= 0 {
+		w.head(cborUint, uint64(n))
+		return
+	}
+	w.head(cborNeg, uint64(-1-n))
+}
+
+func (w *cborWriter) bytes(b []byte) {
+	w.head(cborBytes, uint64(len(b)))
+	w.buf.Write(b)
+}
+
+func (w *cborWriter) transaction(tx transaction.Transaction) error {
+	if tx == nil {
+		return ErrNilTransaction
+	}
+
+	body, err := tx.TransactionSerialize()
+	if err != nil {
+		return err
+	}
+
+	w.head(cborArray, cborTxFields)
+	w.uint(uint64(transaction.TypeOf(tx)))
+	w.bytes(body)
+	return nil
+}
+
+type cborReader struct {
+	data []byte
+	off  int
+}
+
+// head читает элемент ожидаемого основного типа и возвращает его аргумент
+func (r *cborReader) head(major byte, field string) (uint64, error) {
+	if r.off >= len(r.data) {
+		return 0, fmt.Errorf("cbor: unexpected end of data reading %s", field)
+	}
+
+	initial := r.data[r.off]
+	if got := initial >> 5; got != major {
+		return 0, fmt.Errorf("cbor: %s has major type %d, want %d", field, got, major)
+	}
+	r.off++
+
+	info := initial & 0x1f
+	if info < 24 {
+		return uint64(info), nil
+	}
+
+	var width int
+	switch info {
+	case 24:
+		width = 1
+	case 25:
+		width = 2
+	case 26:
+		width = 4
+	case 27:
+		width = 8
+	default:
+		return 0, fmt.Errorf("cbor: unsupported additional info %d in %s", info, field)
+	}
+
+	if len(r.data)-r.off < width {
+		return 0, fmt.Errorf("cbor: unexpected end of data reading %s", field)
+	}
+	raw := r.data[r.off : r.off+width]
+	r.off += width
+
+	var n uint64
+	for _, b := range raw {
+		n = n<<8 | uint64(b)
+	}
+	return n, nil
+}
+
+func (r *cborReader) array(want uint64, field string) error {
+	n, err := r.head(cborArray, field)
+	if err != nil {
+		return err
+	}
+	if n != want {
+		return fmt.Errorf("cbor: %s has %d fields, want %d", field, n, want)
+	}
+	return nil
+}
+
+func (r *cborReader) uint(field string, max uint64) (uint64, error) {
+	n, err := r.head(cborUint, field)
+	if err != nil {
+		return 0, err
+	}
+	if n > max {
+		return 0, fmt.Errorf("cbor: %s value %d overflows", field, n)
+	}
+	return n, nil
+}
+
+func (r *cborReader) int(field string) (int64, error) {
+	if r.off < len(r.data) && r.data[r.off]>>5 == cborNeg {
+		n, err := r.head(cborNeg, field)
+		if err != nil {
+			return 0, err
+		}
+		if n > math.MaxInt64 {
+			return 0, fmt.Errorf("cbor: %s value overflows", field)
+		}
+		return -1 - int64(n), nil
+	}
+
+	n, err := r.uint(field, math.MaxInt64)
+	return int64(n), err
+}
+
+func (r *cborReader) bytes(field string) ([]byte, error) {
+	n, err := r.head(cborBytes, field)
+	if err != nil {
+		return nil, err
+	}
+	if n > uint64(len(r.data)-r.off) {
+		return nil, fmt.Errorf("cbor: %s length %d exceeds remaining data", field, n)
+	}
+
+	out := make([]byte, n)
+	copy(out, r.data[r.off:])
+	r.off += int(n)
+	return out, nil
+}
+
+func (r *cborReader) header() (*header.Header, error) {
+	if err := r.array(cborHeaderFields, "header"); err != nil {
+		return nil, err
+	}
+
+	version, err := r.uint("header version", math.MaxUint32)
+	if err != nil {
+		return nil, err
+	}
+	index, err := r.int("header index")
+	if err != nil {
+		return nil, err
+	}
+	timestamp, err := r.int("header timestamp")
+	if err != nil {
+		return nil, err
+	}
+	prev, err := r.bytes("header previous hash")
+	if err != nil {
+		return nil, err
+	}
+	merkle, err := r.bytes("header merkle root")
+	if err != nil {
+		return nil, err
+	}
+	difficulty, err := r.int("header difficulty")
+	if err != nil {
+		return nil, err
+	}
+	bits, err := r.uint("header bits", math.MaxUint32)
+	if err != nil {
+		return nil, err
+	}
+	nonce, err := r.uint("header nonce", math.MaxUint64)
+	if err != nil {
+		return nil, err
+	}
+
+	return &header.Header{
+		Version:      uint32(version),
+		Index:        int(index),
+		Timestamp:    timestamp,
+		PreviousHash: prev,
+		MerkleRoot:   merkle,
+		Difficulty:   int(difficulty),
+		Bits:         uint32(bits),
+		Nonce:        nonce,
+	}, nil
+}
+
+func (r *cborReader) transaction() (transaction.Transaction, error) {
+	if err := r.array(cborTxFields, "transaction"); err != nil {
+		return nil, err
+	}
+
+	typ, err := r.uint("transaction type", math.MaxUint8)
+	if err != nil {
+		return nil, err
+	}
+	body, err := r.bytes("transaction body")
+	if err != nil {
+		return nil, err
+	}
+
+	br := bytes.NewReader(body)
+	tx, err := transaction.DecodeTransactionBody(transaction.TxType(typ), br)
+	if err != nil {
+		return nil, err
+	}
+	if br.Len() != 0 {
+		return nil, fmt.Errorf("transaction has %d trailing bytes", br.Len())
+	}
+	return tx, nil
+}
+
+func (r *cborReader) end() error {
+	if r.off != len(r.data) {
+		return fmt.Errorf("%w: %d bytes", errCBORTrailing, len(r.data)-r.off)
+	}
+	return nil
+}
@@ -0,0 +1,32 @@
+package codec
+
+import (
+	"errors"
+
+	"github.com/Alex1997377/weave/internal/core/block"
+	"github.com/Alex1997377/weave/internal/core/transaction"
+)
+
+var (
+	ErrUnknownCodec   = errors.New("unknown codec")
+	ErrDuplicateCodec = errors.New("codec already registered")
+	ErrNilBlock       = errors.New("block is nil")
+	ErrNilTransaction = errors.New("transaction is nil")
+)
+
+// Codec - формат представления блоков и транзакций для хранилища и внешних API.
+// Консенсусный хеш от выбора кодека не зависит: он всегда считается по
+// каноническому заголовку (header.Header.Serialize).
+type Codec interface {
+	// Name - имя кодека в реестре ("binary", "json", "cbor")
+	Name() string
+	// ID - однобайтовый идентификатор, которым хранилище помечает значения
+	ID() byte
+	// ContentType - MIME-тип для внешних API
+	ContentType() string
+
+	EncodeBlock(b *block.Block) ([]byte, error)
+	DecodeBlock(data []byte) (*block.Block, error)
+	EncodeTransaction(tx transaction.Transaction) ([]byte, error)
+	DecodeTransaction(data []byte) (transaction.Transaction, error)
+}
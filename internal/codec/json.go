@@ -0,0 +1,41 @@
+package codec
+
+import (
+	"encoding/json"
+
+	"github.com/Alex1997377/weave/internal/core/block"
+	"github.com/Alex1997377/weave/internal/core/transaction"
+)
+
+// JSON - читаемый формат с hex-хешами; при декодировании блок перепроверяется
+type JSON struct{}
+
+func (JSON) Name() string        { return NameJSON }
+func (JSON) ID() byte            { return 0x02 }
+func (JSON) ContentType() string { return "application/json" }
+
+func (JSON) EncodeBlock(b *block.Block) ([]byte, error) {
+	if b == nil {
+		return nil, ErrNilBlock
+	}
+	return json.Marshal(b)
+}
+
+func (JSON) DecodeBlock(data []byte) (*block.Block, error) {
+	b := &block.Block{}
+	if err := json.Unmarshal(data, b); err != nil {
+		return nil, err
+	}
+	return b, nil
+}
+
+func (JSON) EncodeTransaction(tx transaction.Transaction) ([]byte, error) {
+	if tx == nil {
+		return nil, ErrNilTransaction
+	}
+	return transaction.MarshalTransactionJSON(tx)
+}
+
+func (JSON) DecodeTransaction(data []byte) (transaction.Transaction, error) {
+	return transaction.UnmarshalTransactionJSON(data)
+}
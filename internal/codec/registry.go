@@ -0,0 +1,109 @@
+package codec
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+)
+
+// Имена встроенных кодеков
+const (
+	NameBinary = "binary"
+	NameJSON   = "json"
+	NameCBOR   = "cbor"
+)
+
+var (
+	registryMu sync.RWMutex
+	byName     = map[string]Codec{}
+	byID       = map[byte]Codec{}
+)
+
+func init() {
+	MustRegister(Binary{})
+	MustRegister(JSON{})
+	MustRegister(CBOR{})
+}
+
+// Register добавляет кодек в реестр. Имя и ID должны быть уникальными.
+func Register(c Codec) error {
+	if c == nil {
+		return fmt.Errorf("codec is nil")
+	}
+
+	registryMu.Lock()
+	defer registryMu.Unlock()
+
+	if _, ok := byName[c.Name()]; ok {
+		return fmt.Errorf("%w: name %q", ErrDuplicateCodec, c.Name())
+	}
+	if _, ok := byID[c.ID()]; ok {
+		return fmt.Errorf("%w: id 0x%02x", ErrDuplicateCodec, c.ID())
+	}
+
+	byName[c.Name()] = c
+	byID[c.ID()] = c
+	return nil
+}
+
+// MustRegister - Register, паникующий при ошибке
+func MustRegister(c Codec) {
+	if err := Register(c); err != nil {
+		panic(err)
+	}
+}
+
+// Get возвращает кодек по имени
+func Get(name string) (Codec, error) {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+
+	c, ok := byName[name]
+	if !ok {
+		return nil, fmt.Errorf("%w: %q", ErrUnknownCodec, name)
+	}
+	return c, nil
+}
+
+// ByID возвращает кодек по однобайтовому идентификатору
+func ByID(id byte) (Codec, error) {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+
+	c, ok := byID[id]
+	if !ok {
+		return nil, fmt.Errorf("%w: id 0x%02x", ErrUnknownCodec, id)
+	}
+	return c, nil
+}
+
+// ByContentType возвращает кодек по MIME-типу (для выбора формата в API)
+func ByContentType(contentType string) (Codec, error) {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+
+	for _, c := range byName {
+		if c.ContentType() == contentType {
+			return c, nil
+		}
+	}
+	return nil, fmt.Errorf("%w: content type %q", ErrUnknownCodec, contentType)
+}
+
+// Names возвращает отсортированные имена зарегистрированных кодеков
+func Names() []string {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+
+	names := make([]string, 0, len(byName))
+	for name := range byName {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Default - кодек по умолчанию для хранилища
+func Default() Codec {
+	return Binary{}
+}
@@ -0,0 +1,154 @@
+package tests
+
+import (
+	"bytes"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/Alex1997377/weave/internal/codec"
+	"github.com/Alex1997377/weave/internal/core/block/tests/helpers"
+	"github.com/Alex1997377/weave/internal/store"
+	testhelpers "github.com/Alex1997377/weave/internal/tests/helpers"
+)
+
+func TestRegistry_Builtins(t *testing.T) {
+	want := []string{codec.NameBinary, codec.NameCBOR, codec.NameJSON}
+	if got := codec.Names(); !reflect.DeepEqual(got, want) {
+		t.Errorf("Names() = %v, want %v", got, want)
+	}
+
+	for _, name := range want {
+		c, err := codec.Get(name)
+		if err != nil {
+			t.Fatalf("Get(%q) error: %v", name, err)
+		}
+
+		byID, err := codec.ByID(c.ID())
+		if err != nil || byID.Name() != name {
+			t.Errorf("ByID(0x%02x) = %v, %v", c.ID(), byID, err)
+		}
+
+		byType, err := codec.ByContentType(c.ContentType())
+		if err != nil || byType.Name() != name {
+			t.Errorf("ByContentType(%q) = %v, %v", c.ContentType(), byType, err)
+		}
+	}
+
+	if _, err := codec.Get("xml"); !errors.Is(err, codec.ErrUnknownCodec) {
+		t.Errorf("expected ErrUnknownCodec, got %v", err)
+	}
+	if err := codec.Register(codec.JSON{}); !errors.Is(err, codec.ErrDuplicateCodec) {
+		t.Errorf("expected ErrDuplicateCodec, got %v", err)
+	}
+}
+
+func TestCodecs_BlockRoundTrip(t *testing.T) {
+	blk := helpers.CreateMinedBankBlock(t, 3)
+	want, err := blk.Serialize()
+	if err != nil {
+		t.Fatalf("Serialize error: %v", err)
+	}
+
+	for _, name := range codec.Names() {
+		t.Run(name, func(t *testing.T) {
+			c, _ := codec.Get(name)
+
+			data, err := c.EncodeBlock(blk)
+			if err != nil {
+				t.Fatalf("EncodeBlock error: %v", err)
+			}
+
+			decoded, err := c.DecodeBlock(data)
+			if err != nil {
+				t.Fatalf("DecodeBlock error: %v", err)
+			}
+
+			// канонические байты и хеш не зависят от кодека
+			got, err := decoded.Serialize()
+			if err != nil {
+				t.Fatalf("Serialize of decoded block error: %v", err)
+			}
+			if !bytes.Equal(want, got) {
+				t.Error("round trip changed the canonical encoding")
+			}
+			if err := decoded.Validate(); err != nil {
+				t.Errorf("decoded block is invalid: %v", err)
+			}
+		})
+	}
+}
+
+func TestCodecs_TransactionRoundTrip(t *testing.T) {
+	tx := helpers.CreateBankTransaction(7)
+
+	for _, name := range codec.Names() {
+		t.Run(name, func(t *testing.T) {
+			c, _ := codec.Get(name)
+
+			data, err := c.EncodeTransaction(tx)
+			if err != nil {
+				t.Fatalf("EncodeTransaction error: %v", err)
+			}
+
+			decoded, err := c.DecodeTransaction(data)
+			if err != nil {
+				t.Fatalf("DecodeTransaction error: %v", err)
+			}
+			if !bytes.Equal(decoded.TransactionGetID(), tx.TransactionGetID()) {
+				t.Error("transaction id changed")
+			}
+
+			if _, err := c.DecodeTransaction(append(data, 0x00)); err == nil {
+				t.Error("expected error for trailing data")
+			}
+		})
+	}
+}
+
+func TestCBOR_Compact(t *testing.T) {
+	blk := helpers.CreateMinedBankBlock(t, 3)
+
+	binaryData, _ := codec.Binary{}.EncodeBlock(blk)
+	cborData, err := codec.CBOR{}.EncodeBlock(blk)
+	if err != nil {
+		t.Fatalf("EncodeBlock error: %v", err)
+	}
+
+	if cborData[0] != 0x84 {
+		t.Errorf("block must start with a 4-element array, got 0x%02x", cborData[0])
+	}
+	if len(cborData) >= len(binaryData) {
+		t.Errorf("cbor encoding (%d bytes) is not smaller than binary (%d bytes)", len(cborData), len(binaryData))
+	}
+
+	if _, err := (codec.CBOR{}).DecodeBlock(cborData[:len(cborData)-1]); err == nil {
+		t.Error("expected error for truncated data")
+	}
+}
+
+func TestRepository_ReadsAnyCodec(t *testing.T) {
+	db := testhelpers.OpenTestDB(t)
+
+	first := helpers.CreateMinedBankBlock(t, 1)
+	second := helpers.CreateMinedBankBlock(t, 2)
+
+	// блоки, записанные разными кодеками, читаются любым экземпляром хранилища
+	if err := store.NewRepositoryWithCodec(db, codec.CBOR{}).SaveBlock(t.Context(), first); err != nil {
+		t.Fatalf("SaveBlock (cbor) error: %v", err)
+	}
+	if err := store.NewRepositoryWithCodec(db, codec.JSON{}).SaveBlock(t.Context(), second); err != nil {
+		t.Fatalf("SaveBlock (json) error: %v", err)
+	}
+
+	repo := store.NewRepository(db)
+	for _, hash := range [][]byte{first.Hash, second.Hash} {
+		got, err := repo.GetBlock(t.Context(), hash)
+		if err != nil {
+			t.Fatalf("GetBlock error: %v", err)
+		}
+		if !bytes.Equal(got.Hash, hash) {
+			t.Error("stored block hash mismatch")
+		}
+	}
+}
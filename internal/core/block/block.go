@@ -4,8 +4,11 @@ import (
 	"context"
 	"errors"
 	"fmt"
-	"time"
+	"math/big"
+	"slices"
 
+	"github.com/Alex1997377/weave/internal/clock"
+	"github.com/Alex1997377/weave/internal/core/consensus"
 	"github.com/Alex1997377/weave/internal/core/header"
 	"github.com/Alex1997377/weave/internal/core/transaction"
 	"github.com/Alex1997377/weave/internal/crypto/hash"
@@ -16,15 +19,101 @@ type Block struct {
 	Transaction []transaction.Transaction
 	Hash        hash.Hash
 	Size        uint32
+
+	// ChainWork - накопленная работа цепочки до этого блока включительно.
+	// Не входит в конверт и хеш: считается узлом и хранится рядом с блоком.
+	ChainWork *big.Int
 }
 
+// NewBlock создаёт и майнит блок; отмена ctx прерывает майнинг
 func NewBlock(
+	ctx context.Context,
 	transactions []transaction.Transaction,
 	PreviousHash []byte,
 	index int,
 	difficulty int) (*Block, error) {
 
-	if PreviousHash == nil {
+	if difficulty < 0 {
+		return nil, fmt.Errorf("difficulty cannot be negative: %d", difficulty)
+	}
+
+	work := consensus.WorkRequirement{
+		Version:    header.DefaultActivations.VersionAt(index),
+		Difficulty: difficulty,
+	}
+	return newBlock(ctx, nil, transactions, PreviousHash, index, work)
+}
+
+// NewBlockWithParams создаёт и майнит блок по правилам сети p с версией и целью
+// work (см. consensus.Params.NextWorkRequired); готовый блок проверяется
+// ValidateWithParams. Отмена ctx прерывает майнинг.
+func NewBlockWithParams(
+	ctx context.Context,
+	p *consensus.Params,
+	transactions []transaction.Transaction,
+	previousHash []byte,
+	index int,
+	work consensus.WorkRequirement) (*Block, error) {
+
+	if p == nil {
+		return nil, errors.New("consensus params cannot be nil")
+	}
+
+	return newBlock(ctx, p, transactions, previousHash, index, work)
+}
+
+func newBlock(
+	ctx context.Context,
+	p *consensus.Params,
+	transactions []transaction.Transaction,
+	PreviousHash []byte,
+	index int,
+	work consensus.WorkRequirement) (*Block, error) {
+
+	block, err := NewBlockTemplate(p, transactions, PreviousHash, index, work, clock.System)
+	if err != nil {
+		return nil, err
+	}
+
+	config := MineConfig{}
+	if p != nil {
+		algo, err := p.Algorithm()
+		if err != nil {
+			return nil, fmt.Errorf("invalid consensus params: %w", err)
+		}
+		config.Algorithm = algo
+	}
+
+	if err := block.Mine(ctx, config); err != nil {
+		return nil, fmt.Errorf("failed to mine block: %w", err)
+	}
+
+	size, err := block.CalculateSize()
+	if err != nil {
+		return nil, fmt.Errorf("failed to calculate block size: %w", err)
+	}
+	block.Size = size
+
+	if err := block.validateFor(p); err != nil {
+		return nil, fmt.Errorf("created block is invalid: %w", err)
+	}
+
+	return block, nil
+}
+
+// NewBlockTemplate собирает блок без доказательства работы: время заголовка
+// берётся из clk, MerkleRoot считается по transactions, nonce и хеш остаются
+// пустыми. p может быть nil. При одинаковых часах и входных данных шаблоны
+// совпадают побайтно, а Mine одним воркером находит у них один и тот же nonce.
+func NewBlockTemplate(
+	p *consensus.Params,
+	transactions []transaction.Transaction,
+	previousHash []byte,
+	index int,
+	work consensus.WorkRequirement,
+	clk clock.Clock) (*Block, error) {
+
+	if previousHash == nil {
 		return nil, errors.New("previous hash cannot be nil")
 	}
 
@@ -32,10 +121,6 @@ func NewBlock(
 		return nil, fmt.Errorf("block index cannot be negative: %d", index)
 	}
 
-	if difficulty < 0 {
-		return nil, fmt.Errorf("difficulty cannot be negative: %d", difficulty)
-	}
-
 	if index > 0 && len(transactions) == 0 {
 		return nil, errors.New("non-genesis block must have at least one transaction")
 	}
@@ -46,24 +131,49 @@ func NewBlock(
 		}
 	}
 
+	if p != nil {
+		if _, err := p.Algorithm(); err != nil {
+			return nil, fmt.Errorf("invalid consensus params: %w", err)
+		}
+	}
+
 	block := &Block{
 		Header: header.Header{
 			Index:        index,
-			Timestamp:    time.Now().Unix(),
-			PreviousHash: PreviousHash,
-			Difficulty:   difficulty,
+			Timestamp:    clock.OrSystem(clk).Now().Unix(),
+			PreviousHash: previousHash,
 			Nonce:        0,
 			MerkleRoot:   nil,
 		},
 		Transaction: transactions,
 	}
+	work.Apply(&block.Header)
 
 	if err := block.SetMerkleRoot(); err != nil {
 		return nil, fmt.Errorf("failed to set merkle root: %w", err)
 	}
+	return block, nil
+}
 
-	if err := block.Mine(context.Background(), MineConfig{}); err != nil {
-		return nil, fmt.Errorf("failed to mine block: %w", err)
+// NewGenesisBlock строит генезис-блок сети из p.Genesis. Блок не майнится:
+// nonce задан в параметрах, поэтому результат одинаков на всех узлах.
+func NewGenesisBlock(p *consensus.Params) (*Block, error) {
+	block, err := genesisTemplate(p)
+	if err != nil {
+		return nil, err
+	}
+
+	g := p.Genesis
+	block.Header.Nonce = g.Nonce
+
+	blockHash, err := block.CalculateHash()
+	if err != nil {
+		return nil, fmt.Errorf("failed to calculate genesis hash: %w", err)
+	}
+	block.Hash = blockHash
+
+	if g.Hash != "" && block.Hash.String() != g.Hash {
+		return nil, fmt.Errorf("genesis hash mismatch: expected %s, got %s", g.Hash, block.Hash.String())
 	}
 
 	size, err := block.CalculateSize()
@@ -72,9 +182,86 @@ func NewBlock(
 	}
 	block.Size = size
 
-	if err := block.Validate(); err != nil {
-		return nil, fmt.Errorf("created block is invalid: %w", err)
+	if err := block.ValidateWithParams(p); err != nil {
+		return nil, fmt.Errorf("genesis block is invalid: %w", err)
+	}
+
+	return block, nil
+}
+
+// MineGenesis подбирает nonce генезиса для новой сети: перебор идёт одним
+// воркером с нуля, поэтому находится наименьший подходящий nonce. Найденные
+// Header.Nonce и Hash записываются в p.Genesis, после чего NewGenesisBlock
+// строит тот же блок без майнинга.
+func MineGenesis(ctx context.Context, p *consensus.Params) (*Block, error) {
+	block, err := genesisTemplate(p)
+	if err != nil {
+		return nil, err
+	}
+
+	algo, err := p.Algorithm()
+	if err != nil {
+		return nil, fmt.Errorf("invalid consensus params: %w", err)
+	}
+
+	if err := block.Mine(ctx, MineConfig{NumWorkers: 1, Algorithm: algo}); err != nil {
+		return nil, fmt.Errorf("failed to mine genesis block: %w", err)
+	}
+	if block.Header.Timestamp != p.Genesis.Timestamp {
+		return nil, errors.New("genesis nonce space exhausted")
+	}
+
+	size, err := block.CalculateSize()
+	if err != nil {
+		return nil, fmt.Errorf("failed to calculate block size: %w", err)
 	}
+	block.Size = size
 
 	return block, nil
 }
+
+// genesisTemplate собирает генезис без nonce и хеша
+func genesisTemplate(p *consensus.Params) (*Block, error) {
+	if p == nil {
+		return nil, errors.New("consensus params cannot be nil")
+	}
+
+	work, err := p.NextWorkRequired(0, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to calculate genesis target: %w", err)
+	}
+
+	block := &Block{
+		Header: header.Header{
+			Index:        0,
+			Timestamp:    p.Genesis.Timestamp,
+			PreviousHash: make([]byte, HashSize),
+		},
+		Transaction: []transaction.Transaction{},
+	}
+	work.Apply(&block.Header)
+
+	if err := block.SetMerkleRoot(); err != nil {
+		return nil, fmt.Errorf("failed to set merkle root: %w", err)
+	}
+	return block, nil
+}
+
+// Clone возвращает копию блока: заголовок, хеш, накопленная работа и список
+// транзакций копируются, сами транзакции остаются общими (они не меняются
+// после создания).
+func (b *Block) Clone() *Block {
+	if b == nil {
+		return nil
+	}
+
+	c := *b
+	c.Header.PreviousHash = slices.Clone(b.Header.PreviousHash)
+	c.Header.MerkleRoot = slices.Clone(b.Header.MerkleRoot)
+	c.Hash = slices.Clone(b.Hash)
+	c.Transaction = slices.Clone(b.Transaction)
+	if b.ChainWork != nil {
+		c.ChainWork = new(big.Int).Set(b.ChainWork)
+	}
+	return &c
+}
@@ -5,6 +5,7 @@ import (
 	"encoding/binary"
 	"errors"
 	"fmt"
+	"hash/crc32"
 	"runtime"
 	"sync"
 
@@ -15,12 +16,14 @@ import (
 )
 
 // These constants are defining the maximum number of transactions allowed in a block
-// (`MaxTransactions`), the size of a hash in bytes (`HashSize`), and the minimum block size in bytes
+// (`MaxTransactions`), the maximum size of one encoded transaction (`MaxTransactionSize`),
+// the size of a hash in bytes (`HashSize`), and the minimum envelope size in bytes
 // (`minBlockSize`).
 const (
-	MaxTransactions = 10000
-	HashSize        = 32
-	minBlockSize    = 32 + 4 + 32 + 4
+	MaxTransactions    = 10000
+	MaxTransactionSize = 1 << 16
+	HashSize           = 32
+	minBlockSize       = envelopePrefixSize + 4 + envelopeFooterSize
 )
 
 // The `DeserializeOptions` type in Go contains fields for header and transaction deserializers.
@@ -30,45 +33,71 @@ const (
 // @property Tx - The `Tx` property in the `DeserializeOptions` struct is of type
 // `interfaces.TransactionDeserializer`. This property is likely used for deserializing transactions
 // during the deserialization process.
+// @property Limits - The `Limits` property bounds the block size, transaction count and transaction
+// size; zero fields take their value from `DefaultLimits`.
 type DeserializeOptions struct {
 	Header interfaces.HeaderDeserializer
 	Tx     interfaces.TransactionDeserializer
+	Limits Limits
 }
 
-// The function `DeserializeBlockWithparallelPooled` deserializes a block from byte data in parallel
-// using a worker pool.
+// The function `DeserializeBlockWithparallelPooled` deserializes a block envelope from byte data,
+// decoding transactions in parallel using a worker pool.
 func DeserializeBlockWithparallelPooled(data []byte, opts DeserializeOptions) (*Block, error) {
 	if len(data) < minBlockSize {
 		return nil, fmt.Errorf("data too short for block")
 	}
 
-	buf := bytes.NewReader(data)
+	limits := opts.Limits.withDefaults()
+	if len(data) > int(limits.MaxBlockSize) {
+		return nil, fmt.Errorf("%w: %d bytes (max: %d)", ErrBlockTooLarge, len(data), limits.MaxBlockSize)
+	}
+
+	if err := checkEnvelope(data); err != nil {
+		return nil, err
+	}
+
+	footerStart := len(data) - envelopeFooterSize
+	offset := len(EnvelopeMagic) + 1
 	block := &Block{}
 
-	header, err := opts.Header.DeserializeHeader(buf)
+	headerLen := int(binary.LittleEndian.Uint32(data[offset:]))
+	offset += 4
+	if headerLen > footerStart-offset-4 {
+		return nil, fmt.Errorf("header length %d exceeds block data", headerLen)
+	}
+
+	headerBuf := bytes.NewReader(data[offset : offset+headerLen])
+	header, err := opts.Header.DeserializeHeader(headerBuf)
 	if err != nil {
 		return nil, fmt.Errorf("failed to deserialize header: %w", err)
-
+	}
+	if headerBuf.Len() != 0 {
+		return nil, fmt.Errorf("header has %d trailing bytes", headerBuf.Len())
 	}
 	block.Header = *header
+	offset += headerLen
 
-	var txCount uint32
-	if err := binary.Read(buf, binary.LittleEndian, &txCount); err != nil {
-		return nil, fmt.Errorf("failed to read transaction count: %w", err)
-	}
+	txCount := binary.LittleEndian.Uint32(data[offset:])
+	offset += 4
 
-	if txCount > MaxTransactions {
-		return nil, fmt.Errorf("transaction count too high: %d (max: %d)", txCount, MaxTransactions)
+	if txCount > limits.MaxTransactions {
+		return nil, fmt.Errorf("transaction count too high: %d (max: %d)", txCount, limits.MaxTransactions)
 	}
 
-	txDataStart := 32 + 4
-	txBoundaries, err := findTransactionBoundaries(data[txDataStart:], txCount)
+	txData := data[offset:footerStart]
+	txBoundaries, err := findTransactionBoundaries(txData, txCount, limits.MaxTransactionSize)
 	if err != nil {
 		return nil, fmt.Errorf("failed to find tx boundaries: %w", err)
 	}
 
+	if txBoundaries[txCount] != len(txData) {
+		return nil, fmt.Errorf("extra data after block transactions: %d bytes remaining", len(txData)-txBoundaries[txCount])
+	}
+
 	block.Transaction = make([]transaction.Transaction, txCount)
 	if txCount > 0 {
+		txErrs := make([]error, txCount)
 		numWorkers := utils.Min(int(txCount), runtime.NumCPU())
 		wp := blockdeserialize.NewWorkerPool(numWorkers, opts.Tx)
 
@@ -77,7 +106,11 @@ func DeserializeBlockWithparallelPooled(data []byte, opts DeserializeOptions) (*
 		go func() {
 			defer resultsWg.Done()
 			for result := range wp.Results {
-				if result.Err == nil && result.Tx != nil {
+				if result.Err != nil {
+					txErrs[result.Index] = result.Err
+					continue
+				}
+				if result.Tx != nil {
 					block.Transaction[result.Index] = result.Tx
 				}
 			}
@@ -86,7 +119,7 @@ func DeserializeBlockWithparallelPooled(data []byte, opts DeserializeOptions) (*
 		for i := uint32(0); i < txCount; i++ {
 			task := blockdeserialize.TaskPool.Get().(*blockdeserialize.TxTask)
 			task.Index = i
-			task.Data = data[txDataStart+txBoundaries[i] : txDataStart+txBoundaries[i+1]]
+			task.Data = txData[txBoundaries[i]+4 : txBoundaries[i+1]]
 			task.Result = wp.Results
 			wp.Tasks <- task
 		}
@@ -97,65 +130,96 @@ func DeserializeBlockWithparallelPooled(data []byte, opts DeserializeOptions) (*
 		resultsWg.Wait()
 
 		for i, tx := range block.Transaction {
+			if txErrs[i] != nil {
+				return nil, fmt.Errorf("tx %d missing: %w", i, txErrs[i])
+			}
 			if tx == nil {
 				return nil, fmt.Errorf("tx %d missing", i)
 			}
 		}
 	}
 
-	footerStart := txDataStart + txBoundaries[txCount]
-	if len(data) < footerStart+HashSize+4 {
-		return nil, errors.New("insufficient data for footer")
-	}
-
 	block.Hash = make([]byte, HashSize)
 	copy(block.Hash, data[footerStart:footerStart+HashSize])
 	block.Size = binary.LittleEndian.Uint32(data[footerStart+HashSize:])
 
-	if block.Size == 0 {
-		return nil, errors.New("invalid block size")
+	return block, nil
+}
+
+// ErrBlockSizeMismatch - размер данных не совпадает с размером из конверта:
+// конверт обрезан или за ним идут лишние байты
+var ErrBlockSizeMismatch = errors.New("block size mismatch")
+
+// checkEnvelope проверяет magic, версию, заявленный размер и контрольную сумму конверта
+func checkEnvelope(data []byte) error {
+	if !bytes.Equal(data[:len(EnvelopeMagic)], EnvelopeMagic[:]) {
+		return fmt.Errorf("invalid block magic: %x", data[:len(EnvelopeMagic)])
 	}
 
-	if len(data) > footerStart+HashSize+4 {
-		return nil, fmt.Errorf("extra data after block deserialization: %d bytes remaining", len(data)-(footerStart+HashSize+4))
+	if version := data[len(EnvelopeMagic)]; version != EnvelopeVersion {
+		return fmt.Errorf("unsupported block envelope version: %d", version)
 	}
 
-	return block, nil
+	footerStart := len(data) - envelopeFooterSize
+	size := binary.LittleEndian.Uint32(data[footerStart+HashSize:])
+	if size == 0 {
+		return errors.New("invalid block size")
+	}
+	if int(size) != len(data) {
+		return fmt.Errorf("%w: envelope declares %d bytes, got %d", ErrBlockSizeMismatch, size, len(data))
+	}
+
+	checksum := binary.LittleEndian.Uint32(data[len(data)-4:])
+	if actual := crc32.ChecksumIEEE(data[:len(data)-4]); actual != checksum {
+		return fmt.Errorf("block checksum mismatch: expected %08x, got %08x", checksum, actual)
+	}
+
+	return nil
 }
 
 // The function DeserializeBlock deserializes a block using parallel pooling with specified
 // deserializers.
 func DeserializeBlock(data []byte) (*Block, error) {
+	return DeserializeBlockWithLimits(data, DefaultLimits)
+}
+
+// The function DeserializeBlockWithLimits deserializes a block like `DeserializeBlock`, rejecting
+// it as soon as it exceeds the given limits (see `LimitsFor`).
+func DeserializeBlockWithLimits(data []byte, limits Limits) (*Block, error) {
 	return DeserializeBlockWithparallelPooled(data, DeserializeOptions{
 		Header: interfaces.RealHeaderDeserializer{},
 		Tx:     interfaces.RealTransactionDeserializer{},
+		Limits: limits,
 	})
 }
 
-// The function DeserializeTransaction takes a bytes.Reader as input and returns a deserialized
-// transaction along with any error encountered.
+// The function DeserializeTransaction takes a bytes.Reader holding a type-tagged transaction
+// and returns the deserialized transaction along with any error encountered.
 func DeserializeTransaction(buf *bytes.Reader) (transaction.Transaction, error) {
-	return transaction.DeserializeTransactionFromReader(buf)
+	return transaction.DecodeTransaction(buf)
 }
 
-// The `findTransactionBoundaries` function parses a byte slice to determine transaction boundaries
-// based on a given transaction count and checks for errors related to transaction header bounds and
-// signature size.
-func findTransactionBoundaries(data []byte, txCount uint32) ([]int, error) {
+// The `findTransactionBoundaries` function walks the length-prefixed transaction records and
+// returns the offset of every record, checking each declared length against `maxTxSize`
+// and the available data.
+func findTransactionBoundaries(data []byte, txCount uint32, maxTxSize uint32) ([]int, error) {
 	boundaries := make([]int, txCount+1)
 	offset := 0
 
 	for i := uint32(0); i < txCount; i++ {
-		if offset+108 > len(data) {
-			return nil, fmt.Errorf("tx %d header out of bounds", i)
+		if offset+4 > len(data) {
+			return nil, fmt.Errorf("tx %d length out of bounds", i)
 		}
 
-		sigLen := binary.LittleEndian.Uint32(data[offset+32+32+32+8:])
-		if sigLen > 1024 {
-			return nil, fmt.Errorf("signature too large at tx %d: %d", i, sigLen)
+		txLen := binary.LittleEndian.Uint32(data[offset:])
+		if txLen == 0 {
+			return nil, fmt.Errorf("tx %d is empty", i)
+		}
+		if txLen > maxTxSize {
+			return nil, fmt.Errorf("transaction too large at tx %d: %d", i, txLen)
 		}
 
-		txSize := 108 + int(sigLen)
+		txSize := 4 + int(txLen)
 		if offset+txSize > len(data) {
 			return nil, fmt.Errorf("tx %d size mismatch: need %d, have %d", i, txSize, len(data)-offset)
 		}
@@ -4,7 +4,9 @@ package block
 import (
 	"errors"
 
+	"github.com/Alex1997377/weave/internal/core/block/interfaces"
 	"github.com/Alex1997377/weave/internal/crypto/hash"
+	"github.com/Alex1997377/weave/internal/crypto/powalgo"
 )
 
 func (b *Block) HashString() (string, error) {
@@ -27,3 +29,20 @@ func (b *Block) FormatHash(prefix string) (string, error) {
 	}
 	return hash.FormatHashWithPrefix(prefix, b.Hash)
 }
+
+// PowHash возвращает хеш заголовка, который сравнивается с целью. Для SHA-256
+// это сам Block.Hash, для остальных алгоритмов хеш считается заново.
+func (b *Block) PowHash(algo powalgo.Algorithm) (interfaces.Hash, error) {
+	if b == nil {
+		return nil, errors.New("block is nil")
+	}
+	if powalgo.IsIdentity(algo) {
+		return b.Hash, nil
+	}
+
+	data, err := b.Header.Serialize()
+	if err != nil {
+		return nil, err
+	}
+	return algo.Hash(data), nil
+}
@@ -0,0 +1,110 @@
+package block
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/Alex1997377/weave/internal/core/header"
+	"github.com/Alex1997377/weave/internal/core/transaction"
+	"github.com/Alex1997377/weave/internal/crypto/hash"
+	"github.com/Alex1997377/weave/pkg/utils"
+)
+
+// blockJSON - JSON-представление блока: hex-хеши, транзакции с полем "type"
+type blockJSON struct {
+	Header       header.Header     `json:"header"`
+	Transactions []json.RawMessage `json:"transactions"`
+	Hash         hash.Hash         `json:"hash"`
+	Size         uint32            `json:"size"`
+}
+
+func (b Block) MarshalJSON() ([]byte, error) {
+	txs := make([]json.RawMessage, len(b.Transaction))
+	for i, tx := range b.Transaction {
+		data, err := transaction.MarshalTransactionJSON(tx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal transaction %d: %w", i, err)
+		}
+		txs[i] = data
+	}
+
+	return json.Marshal(blockJSON{
+		Header:       b.Header,
+		Transactions: txs,
+		Hash:         b.Hash,
+		Size:         b.Size,
+	})
+}
+
+// UnmarshalJSON строго декодирует блок и заново проверяет Merkle root,
+// хеш заголовка и размер, поэтому подменённые фикстуры не принимаются
+func (b *Block) UnmarshalJSON(data []byte) error {
+	if b == nil {
+		return errors.New("block is nil")
+	}
+
+	var raw blockJSON
+	if err := utils.DecodeJSONStrict(data, &raw); err != nil {
+		return fmt.Errorf("invalid block JSON: %w", err)
+	}
+
+	decoded := Block{
+		Header:      raw.Header,
+		Transaction: make([]transaction.Transaction, len(raw.Transactions)),
+		Hash:        raw.Hash,
+		Size:        raw.Size,
+	}
+
+	for i, txData := range raw.Transactions {
+		tx, err := transaction.UnmarshalTransactionJSON(txData)
+		if err != nil {
+			return fmt.Errorf("invalid transaction %d: %w", i, err)
+		}
+		decoded.Transaction[i] = tx
+	}
+
+	if err := decoded.verifyDecoded(); err != nil {
+		return err
+	}
+
+	*b = decoded
+	return nil
+}
+
+// PrettyJSON возвращает блок в виде JSON с отступами для чтения человеком
+func (b *Block) PrettyJSON() ([]byte, error) {
+	if b == nil {
+		return nil, errors.New("block is nil")
+	}
+	return json.MarshalIndent(b, "", "  ")
+}
+
+func (b *Block) verifyDecoded() error {
+	merkleRoot, err := b.CalculateMerkleRootWithError()
+	if err != nil {
+		return fmt.Errorf("failed to calculate merkle root: %w", err)
+	}
+	if !bytes.Equal(merkleRoot, b.Header.MerkleRoot) {
+		return errors.New("merkle root doesn`t match transactions")
+	}
+
+	calculatedHash, err := b.CalculateHash()
+	if err != nil {
+		return fmt.Errorf("failed to calculate hash: %w", err)
+	}
+	if !bytes.Equal(calculatedHash, b.Hash) {
+		return errors.New("block hash doesn`t match content")
+	}
+
+	size, err := b.CalculateSize()
+	if err != nil {
+		return fmt.Errorf("failed to calculate size: %w", err)
+	}
+	if size != b.Size {
+		return fmt.Errorf("block size mismatch: declared %d, calculated %d", b.Size, size)
+	}
+
+	return nil
+}
@@ -2,22 +2,52 @@ package block
 
 import (
 	"context"
+	"encoding/binary"
 	"errors"
 	"fmt"
+	"math"
+	"math/bits"
 	"runtime"
 	"sync"
 	"sync/atomic"
 	"time"
 
+	"github.com/Alex1997377/weave/internal/clock"
 	"github.com/Alex1997377/weave/internal/core/block/interfaces"
+	"github.com/Alex1997377/weave/internal/core/transaction"
 	"github.com/Alex1997377/weave/internal/crypto/hash"
+	"github.com/Alex1997377/weave/internal/crypto/pow"
+	"github.com/Alex1997377/weave/internal/crypto/powalgo"
 )
 
+// MineConfig - настройки майнинга.
+//
+// Перебор идёт раундами: в каждом раунде диапазон nonce [0, NonceRange)
+// (0 - все 2^64 значений) делится между воркерами на непересекающиеся
+// отрезки. Если раунд исчерпан, Mine увеличивает ExtraNonce coinbase-транзакции
+// (меняя MerkleRoot) или, если coinbase нет, сдвигает время заголовка, и
+// начинает новый раунд. Поэтому Mine завершается только при находке,
+// отмене контекста или таймауте.
+//
+// Algorithm задаёт функцию доказательства работы (по умолчанию SHA-256).
+// Hasher, если задан, заменяет её при переборе - используется в тестах;
+// найденный хеш тогда считается идентификатором блока.
+//
+// Clock задаёт время, на которое сдвигается заголовок при смене раунда
+// (по умолчанию системные часы).
+//
+// OnProgress, если задан, получает отчёты раз в ProgressInterval
+// (по умолчанию DefaultProgressInterval) и итоговый отчёт с Done=true.
 type MineConfig struct {
-	NumWorkers int
-	Verbose    bool
-	Timeout    time.Duration
-	Hasher     interfaces.HashCalculator
+	NumWorkers       int
+	Verbose          bool
+	Timeout          time.Duration
+	Hasher           interfaces.HashCalculator
+	Algorithm        powalgo.Algorithm
+	NonceRange       uint64
+	OnProgress       ProgressFunc
+	ProgressInterval time.Duration
+	Clock            clock.Clock
 }
 
 // Для переиспользования байтовых беферов
@@ -37,17 +67,31 @@ func (b *Block) Mine(ctx context.Context, config MineConfig) error {
 		return errors.New("block difficulty cannot be negative")
 	}
 
+	target, err := b.Header.Target()
+	if err != nil {
+		return fmt.Errorf("invalid block target: %w", err)
+	}
+
+	// идентификатор блока приходится пересчитывать, если хеш перебора с ним не совпадает
+	identity := config.Hasher != nil || powalgo.IsIdentity(config.Algorithm)
+	if config.Hasher == nil {
+		config.Hasher = config.Algorithm
+	}
 	if config.Hasher == nil {
 		config.Hasher = &hash.HashCalculatorImpl{}
 	}
 
-	if b.Header.Difficulty == 0 {
+	// Любой хеш удовлетворяет максимальной цели - перебор не нужен
+	if target == pow.MaxTarget {
 		hash, err := b.CalculateHash()
 		if err != nil {
 			return err
 		}
 
 		b.Hash = hash
+		if config.OnProgress != nil {
+			newMineTelemetry(b.Header.Index, 0, target, config.OnProgress).finish(true, b.Header.Nonce, hash, nil)
+		}
 		return nil
 	}
 
@@ -56,16 +100,90 @@ func (b *Block) Mine(ctx context.Context, config MineConfig) error {
 	}
 
 	if config.Verbose {
-		fmt.Printf("Mining block %d, difficulty %d, workers %d\n",
-			b.Header.Index, b.Header.Difficulty, config.NumWorkers)
+		fmt.Printf("Mining block %d, target %s, workers %d\n",
+			b.Header.Index, target, config.NumWorkers)
+	}
+
+	if config.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, config.Timeout)
+		defer cancel()
+	}
+
+	lastNonce := uint64(math.MaxUint64)
+	if config.NonceRange > 0 {
+		lastNonce = config.NonceRange - 1
 	}
+	ranges := splitNonceRange(0, lastNonce, config.NumWorkers)
 
+	telemetry := newMineTelemetry(b.Header.Index, len(ranges), target, config.OnProgress)
+	telemetry.run(config.ProgressInterval)
+
+	for round := 0; ; round++ {
+		telemetry.round.Store(int64(round))
+
+		nonce, hashResult, found, err := b.mineRound(ctx, config, target, ranges, telemetry)
+		if err != nil {
+			telemetry.finish(false, 0, nil, err)
+			return err
+		}
+
+		if found {
+			b.Header.Nonce = nonce
+			b.Hash = hashResult
+			if !identity {
+				if b.Hash, err = b.CalculateHash(); err != nil {
+					telemetry.finish(false, 0, nil, err)
+					return err
+				}
+			}
+			result := telemetry.finish(true, nonce, b.Hash, nil)
+			if config.Verbose {
+				fmt.Printf("Mined! Nonce=%d, hash=%x, rounds=%d, hashes=%d, hashrate=%.0f H/s, time=%v\n",
+					b.Header.Nonce, b.Hash, result.Round, result.Hashes, result.Hashrate, result.Elapsed)
+			}
+			return nil
+		}
+
+		if err := ctx.Err(); err != nil {
+			telemetry.finish(false, 0, nil, err)
+			return err
+		}
+
+		if err := b.rollSearchSpace(config.Clock); err != nil {
+			err = fmt.Errorf("failed to roll search space: %w", err)
+			telemetry.finish(false, 0, nil, err)
+			return err
+		}
+		if config.Verbose {
+			fmt.Printf("Nonce range exhausted, round %d: timestamp=%d, merkle=%x\n",
+				round+1, b.Header.Timestamp, b.Header.MerkleRoot)
+		}
+	}
+}
+
+// mineRound перебирает отрезки ranges для текущего заголовка
+func (b *Block) mineRound(ctx context.Context, config MineConfig, target pow.Target, ranges []nonceRange, telemetry *mineTelemetry) (uint64, []byte, bool, error) {
 	baseHeader, nonceOffset, err := b.Header.SerializeWithoutNonce()
 	if err != nil {
-		return fmt.Errorf("failed to serialize header without nonce: %w", err)
+		return 0, nil, false, fmt.Errorf("failed to serialize header without nonce: %w", err)
 	}
 
-	startTime := time.Now()
+	nonce, hashResult, found := searchRanges(ctx, baseHeader, nonceOffset, target, config.Hasher, ranges, telemetry)
+	return nonce, hashResult, found, nil
+}
+
+// searchRanges параллельно перебирает отрезки ranges заголовка baseHeader
+// (по воркеру на отрезок) до первой находки или исчерпания всех отрезков
+func searchRanges(
+	ctx context.Context,
+	baseHeader []byte,
+	nonceOffset int,
+	target pow.Target,
+	hasher interfaces.HashCalculator,
+	ranges []nonceRange,
+	telemetry *mineTelemetry) (uint64, []byte, bool) {
+
 	var (
 		found       atomic.Bool
 		winnerNonce atomic.Uint64
@@ -74,31 +192,20 @@ func (b *Block) Mine(ctx context.Context, config MineConfig) error {
 		stopCh      = make(chan struct{})
 	)
 
-	if config.Timeout > 0 {
-		var cancel context.CancelFunc
-		ctx, cancel = context.WithTimeout(ctx, config.Timeout)
-		defer cancel()
-	}
-
-	if config.Hasher == nil {
-		return errors.New("hasher is required")
-	}
-
-	step := uint64(1 << 20)
-	for i := 0; i < config.NumWorkers; i++ {
+	for i, r := range ranges {
 		wg.Add(1)
 		args := &workerArgs{
-			b:           b,
 			baseHeader:  baseHeader,
 			nonceOffset: nonceOffset,
-			startNonce:  uint64(i) * step,
-			step:        step,
-			config:      config,
+			target:      target,
+			nonces:      r,
+			hasher:      hasher,
 			found:       &found,
 			winnerNonce: &winnerNonce,
 			hashResult:  &hashResult,
 			stopCh:      stopCh,
 			ctx:         ctx,
+			hashes:      telemetry.counter(i),
 		}
 		go mineWorker(args, &wg)
 	}
@@ -106,56 +213,166 @@ func (b *Block) Mine(ctx context.Context, config MineConfig) error {
 	wg.Wait()
 
 	if !found.Load() {
-		if err := ctx.Err(); err != nil {
-			return err
-		}
-		return errors.New("mining failed to find valid nonce")
+		return 0, nil, false
 	}
+	return winnerNonce.Load(), hashResult, true
+}
 
-	b.Hash = hashResult
-	b.Header.Nonce = winnerNonce.Load()
-	if config.Verbose {
-		fmt.Printf("Mined! Nonce=%d, hash=%x, time=%v\n",
-			b.Header.Nonce, hashResult, time.Since(startTime))
+// rollSearchSpace меняет заголовок после исчерпания диапазона nonce:
+// заменяет coinbase копией с увеличенным ExtraNonce, а если её нет (или
+// ExtraNonce переполнился) - сдвигает время заголовка вперёд. Сама
+// транзакция не меняется: её могут делить копии блока (см. Clone).
+func (b *Block) rollSearchSpace(clk clock.Clock) error {
+	if cb := b.coinbase(); cb != nil {
+		rolled := *cb
+		rolled.ExtraNonce++
+		if rolled.ExtraNonce != 0 {
+			b.Transaction[0] = &rolled
+			return b.SetMerkleRoot()
+		}
 	}
 
+	now := clock.OrSystem(clk).Now().Unix()
+	if now <= b.Header.Timestamp {
+		now = b.Header.Timestamp + 1
+	}
+	b.Header.Timestamp = now
 	return nil
 }
 
+// coinbase возвращает coinbase-транзакцию блока, если она первая в списке
+func (b *Block) coinbase() *transaction.CoinbaseTransaction {
+	if len(b.Transaction) == 0 {
+		return nil
+	}
+	cb, _ := b.Transaction[0].(*transaction.CoinbaseTransaction)
+	return cb
+}
+
 // -----------------------
 // ВСПОМОГАТЕЛЬНЫЕ ФУНКЦИИ
 // -----------------------
 
+// nonceRange - отрезок nonce [first, last] одного воркера
+type nonceRange struct {
+	first uint64
+	last  uint64
+}
+
+// splitNonceRange делит [first, last] на n непересекающихся отрезков почти равной длины
+func splitNonceRange(first, last uint64, n int) []nonceRange {
+	if n <= 1 || first > last {
+		return []nonceRange{{first: first, last: last}}
+	}
+
+	// число значений last-first+1 может не поместиться в uint64
+	var hi uint64
+	lo := last - first + 1
+	if lo == 0 {
+		hi = 1
+	}
+	q, r := bits.Div64(hi, lo, uint64(n))
+
+	ranges := make([]nonceRange, 0, n)
+	start := first
+	for i := 0; i < n; i++ {
+		size := q
+		if uint64(i) < r {
+			size++
+		}
+		if size == 0 {
+			break
+		}
+		ranges = append(ranges, nonceRange{first: start, last: start + size - 1})
+		start += size
+	}
+	return ranges
+}
+
 type workerArgs struct {
-	b           *Block
 	baseHeader  []byte
 	nonceOffset int
-	startNonce  uint64
-	step        uint64
-	config      MineConfig
+	target      pow.Target
+	nonces      nonceRange
+	hasher      interfaces.HashCalculator
 	found       *atomic.Bool
 	winnerNonce *atomic.Uint64
 	hashResult  *[]byte
 	stopCh      chan struct{}
 	ctx         context.Context
+	hashes      *atomic.Uint64
 }
 
 func mineWorker(args *workerArgs, wg *sync.WaitGroup) {
 	defer wg.Done()
 
-	nonce := args.startNonce
+	// счётчик обновляется пачками, чтобы не трогать общую память на каждом хеше
+	var pending uint64
+	defer func() { args.hashes.Add(pending) }()
+
+	hashNonce := args.nonceHashFunc()
+
+	nonce := args.nonces.first
 	for {
-		select {
-		case <-args.stopCh:
+		// каналы проверяются раз в пачку: select на каждом nonce заметно дороже хеша
+		if pending == 0 && args.stopped() {
 			return
-		case <-args.ctx.Done():
-			return
-		default:
 		}
 		if args.found.Load() {
 			return
 		}
 
+		hash := hashNonce(nonce)
+		pending++
+		if pending == hashFlushInterval {
+			args.hashes.Add(pending)
+			pending = 0
+		}
+
+		if hash.MeetsTarget(args.target) {
+			if args.found.CompareAndSwap(false, true) {
+				args.winnerNonce.Store(nonce)
+				*args.hashResult = hash.Bytes()
+				close(args.stopCh)
+			}
+			return
+		}
+
+		// отрезок воркера исчерпан - раунд продолжат остальные
+		if nonce == args.nonces.last {
+			return
+		}
+		nonce++
+	}
+}
+
+// stopped сообщает, что раунд остановлен находкой другого воркера или контекстом
+func (args *workerArgs) stopped() bool {
+	select {
+	case <-args.stopCh:
+		return true
+	case <-args.ctx.Done():
+		return true
+	default:
+		return false
+	}
+}
+
+// nonceHashFunc возвращает функцию хеширования заголовка с заданным nonce.
+// Если хешер поддерживает midstate, префикс до nonce сжимается один раз,
+// иначе заголовок собирается в буфере из пула для каждого nonce
+func (args *workerArgs) nonceHashFunc() func(nonce uint64) interfaces.Hash {
+	if mc, ok := args.hasher.(interfaces.MidstateHashCalculator); ok {
+		if ms, err := mc.Midstate(args.baseHeader[:args.nonceOffset]); err == nil {
+			var suffix [8]byte
+			return func(nonce uint64) interfaces.Hash {
+				binary.LittleEndian.PutUint64(suffix[:], nonce)
+				return ms.HashSuffix(suffix[:])
+			}
+		}
+	}
+
+	return func(nonce uint64) interfaces.Hash {
 		headerBufPtr := headerBufferPool.Get().(*[]byte)
 		headerBuf := *headerBufPtr
 		headerBuf = append(headerBuf[:0], args.baseHeader...)
@@ -169,31 +386,12 @@ func mineWorker(args *workerArgs, wg *sync.WaitGroup) {
 			headerBuf = headerBuf[:args.nonceOffset+8]
 		}
 
-		headerBuf[args.nonceOffset] = byte(nonce)
-		headerBuf[args.nonceOffset+1] = byte(nonce >> 8)
-		headerBuf[args.nonceOffset+2] = byte(nonce >> 16)
-		headerBuf[args.nonceOffset+3] = byte(nonce >> 24)
-		headerBuf[args.nonceOffset+4] = byte(nonce >> 32)
-		headerBuf[args.nonceOffset+5] = byte(nonce >> 40)
-		headerBuf[args.nonceOffset+6] = byte(nonce >> 48)
-		headerBuf[args.nonceOffset+7] = byte(nonce >> 56)
+		binary.LittleEndian.PutUint64(headerBuf[args.nonceOffset:], nonce)
 
-		hash := args.config.Hasher.Hash(headerBuf)
+		hash := args.hasher.Hash(headerBuf)
 
 		*headerBufPtr = headerBuf[:0]
 		headerBufferPool.Put(headerBufPtr)
-
-		if hash.IsValidForDifficulty(args.b.Header.Difficulty) {
-			if args.found.CompareAndSwap(false, true) {
-				args.winnerNonce.Store(nonce)
-				*args.hashResult = hash.Bytes()
-				close(args.stopCh)
-			}
-			return
-		}
-		nonce++
-		if nonce == 0 {
-			return
-		}
+		return hash
 	}
 }
@@ -0,0 +1,150 @@
+package block
+
+import (
+	"math/big"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/Alex1997377/weave/internal/crypto/pow"
+)
+
+// DefaultProgressInterval - период отчётов о прогрессе, если он не задан в MineConfig
+const DefaultProgressInterval = time.Second
+
+// hashFlushInterval - через сколько хешей воркер переносит локальный счётчик в общий
+const hashFlushInterval = 1024
+
+// MineProgress - снимок состояния майнинга.
+//
+// Промежуточные отчёты приходят раз в ProgressInterval, последний отчёт
+// имеет Done=true и содержит итог: найденный nonce и хеш либо ошибку.
+type MineProgress struct {
+	Index        int
+	Round        int
+	Workers      int
+	WorkerHashes []uint64
+	Hashes       uint64
+	// Hashrate - средняя скорость перебора с начала майнинга, хешей в секунду
+	Hashrate float64
+	Elapsed  time.Duration
+	// ExpectedHashes - матожидание числа хешей до решения для цели блока
+	ExpectedHashes float64
+	// ETA - ожидаемое время до решения при текущем хешрейте, 0 если хешрейт ещё неизвестен
+	ETA time.Duration
+
+	Done  bool
+	Found bool
+	Nonce uint64
+	Hash  []byte
+	Err   error
+}
+
+// ProgressFunc получает отчёты о майнинге. Вызовы никогда не пересекаются во времени.
+type ProgressFunc func(MineProgress)
+
+// hashCounter выровнен по кеш-линии, чтобы счётчики соседних воркеров не мешали друг другу
+type hashCounter struct {
+	n atomic.Uint64
+	_ [56]byte
+}
+
+// mineTelemetry собирает счётчики воркеров и рассылает отчёты
+type mineTelemetry struct {
+	index          int
+	start          time.Time
+	counters       []hashCounter
+	round          atomic.Int64
+	expectedHashes float64
+	onProgress     ProgressFunc
+
+	stopCh chan struct{}
+	wg     sync.WaitGroup
+}
+
+func newMineTelemetry(index, workers int, target pow.Target, onProgress ProgressFunc) *mineTelemetry {
+	expected, _ := new(big.Float).SetInt(target.Work()).Float64()
+	return &mineTelemetry{
+		index:          index,
+		start:          time.Now(),
+		counters:       make([]hashCounter, workers),
+		expectedHashes: expected,
+		onProgress:     onProgress,
+		stopCh:         make(chan struct{}),
+	}
+}
+
+// counter возвращает счётчик воркера i
+func (t *mineTelemetry) counter(i int) *atomic.Uint64 {
+	return &t.counters[i].n
+}
+
+// run периодически отправляет отчёты до вызова finish
+func (t *mineTelemetry) run(interval time.Duration) {
+	if t.onProgress == nil {
+		return
+	}
+	if interval <= 0 {
+		interval = DefaultProgressInterval
+	}
+
+	t.wg.Add(1)
+	go func() {
+		defer t.wg.Done()
+
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-t.stopCh:
+				return
+			case <-ticker.C:
+				t.onProgress(t.snapshot())
+			}
+		}
+	}()
+}
+
+// finish останавливает периодические отчёты и возвращает итоговый
+func (t *mineTelemetry) finish(found bool, nonce uint64, hash []byte, err error) MineProgress {
+	close(t.stopCh)
+	t.wg.Wait()
+
+	p := t.snapshot()
+	p.Done = true
+	p.Found = found
+	p.Nonce = nonce
+	p.Hash = hash
+	p.Err = err
+
+	if t.onProgress != nil {
+		t.onProgress(p)
+	}
+	return p
+}
+
+func (t *mineTelemetry) snapshot() MineProgress {
+	p := MineProgress{
+		Index:          t.index,
+		Round:          int(t.round.Load()) + 1,
+		Workers:        len(t.counters),
+		WorkerHashes:   make([]uint64, len(t.counters)),
+		Elapsed:        time.Since(t.start),
+		ExpectedHashes: t.expectedHashes,
+	}
+
+	for i := range t.counters {
+		p.WorkerHashes[i] = t.counters[i].n.Load()
+		p.Hashes += p.WorkerHashes[i]
+	}
+
+	if seconds := p.Elapsed.Seconds(); seconds > 0 {
+		p.Hashrate = float64(p.Hashes) / seconds
+	}
+	// перебор без памяти: ожидаемое время до решения не зависит от уже сделанного
+	if p.Hashrate > 0 {
+		p.ETA = time.Duration(p.ExpectedHashes / p.Hashrate * float64(time.Second))
+	}
+	return p
+}
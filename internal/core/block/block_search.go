@@ -0,0 +1,56 @@
+package block
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"runtime"
+
+	"github.com/Alex1997377/weave/internal/core/block/interfaces"
+	"github.com/Alex1997377/weave/internal/crypto/hash"
+	"github.com/Alex1997377/weave/internal/crypto/pow"
+)
+
+// NonceSearch - задание перебора для внешнего майнера, у которого есть
+// только сериализованный заголовок (см. header.Header.SerializeWithoutNonce)
+type NonceSearch struct {
+	Header      []byte
+	NonceOffset int
+	// First и Last - границы отрезка nonce включительно
+	First      uint64
+	Last       uint64
+	Target     pow.Target
+	Hasher     interfaces.HashCalculator
+	NumWorkers int
+	OnProgress ProgressFunc
+}
+
+// SearchNonce перебирает nonce из [First, Last] теми же воркерами, что и
+// Block.Mine, но без смены времени и ExtraNonce: при исчерпании отрезка
+// возвращается found=false. Хеш возвращается копией.
+func SearchNonce(ctx context.Context, s NonceSearch) (nonce uint64, powHash []byte, found bool, err error) {
+	if s.NonceOffset < 0 || len(s.Header) < s.NonceOffset+8 {
+		return 0, nil, false, fmt.Errorf("nonce offset %d outside of %d-byte header", s.NonceOffset, len(s.Header))
+	}
+	if s.First > s.Last {
+		return 0, nil, false, errors.New("empty nonce range")
+	}
+	if s.Hasher == nil {
+		s.Hasher = &hash.HashCalculatorImpl{}
+	}
+	if s.NumWorkers <= 0 {
+		s.NumWorkers = runtime.NumCPU()
+	}
+
+	ranges := splitNonceRange(s.First, s.Last, s.NumWorkers)
+
+	telemetry := newMineTelemetry(0, len(ranges), s.Target, s.OnProgress)
+	telemetry.run(0)
+
+	nonce, powHash, found = searchRanges(ctx, s.Header, s.NonceOffset, s.Target, s.Hasher, ranges, telemetry)
+	if !found {
+		err = ctx.Err()
+	}
+	telemetry.finish(found, nonce, powHash, err)
+	return nonce, powHash, found, err
+}
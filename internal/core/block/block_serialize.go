@@ -2,87 +2,187 @@ package block
 
 import (
 	"bytes"
+	"encoding/binary"
 	"errors"
 	"fmt"
+	"hash/crc32"
+	"io"
 
+	"github.com/Alex1997377/weave/internal/core/transaction"
 	"github.com/Alex1997377/weave/internal/crypto/hash"
 )
 
+// Конверт блока - единый формат, которым пользуются Serialize, CalculateSize,
+// DeserializeBlock и хранилище. Все числа записываются в little-endian:
+//
+//	magic      [4]byte   "WVBK"
+//	version    uint8     EnvelopeVersion
+//	headerLen  uint32
+//	header     [headerLen]byte
+//	txCount    uint32
+//	txCount x { txLen uint32, tx [txLen]byte }   tx = тег типа + TransactionSerialize
+//	hash       [32]byte
+//	size       uint32    полный размер конверта в байтах
+//	checksum   uint32    CRC-32 (IEEE) всех предыдущих байтов
+const (
+	EnvelopeVersion uint8 = 1
+
+	envelopePrefixSize = 4 + 1 + 4
+	envelopeFooterSize = HashSize + 4 + 4
+)
+
+var EnvelopeMagic = [4]byte{'W', 'V', 'B', 'K'}
+
 func (b *Block) Serialize() ([]byte, error) {
 	if b == nil {
 		return nil, errors.New("block is nil")
 	}
 
-	buf := new(bytes.Buffer)
+	parts, err := b.envelopeParts()
+	if err != nil {
+		return nil, err
+	}
+
+	buf := bytes.NewBuffer(make([]byte, 0, parts.size))
+	if err := parts.writeTo(buf); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+func (b *Block) CalculateHash() ([]byte, error) {
+	if b == nil {
+		return nil, errors.New("block is nil")
+	}
+	data, err := b.Header.Serialize()
+	if err != nil {
+		return nil, fmt.Errorf("failed to serialize header: %w", err)
+	}
+	return hash.HashBytes(data).Bytes(), nil
+}
+
+// CalculateSize возвращает размер конверта блока, ровно len(Serialize())
+func (b *Block) CalculateSize() (uint32, error) {
+	if b == nil {
+		return 0, errors.New("block is nil")
+	}
 
 	headerBytes, err := b.Header.Serialize()
 	if err != nil {
-		return nil, fmt.Errorf("failed to serialize headerL %w", err)
+		return 0, fmt.Errorf("failed to serialize header for size calculation: %w", err)
 	}
 
-	_, err = buf.Write(headerBytes)
+	txs, err := b.encodeTransactions()
 	if err != nil {
-		return nil, fmt.Errorf("failed to write header to buffer: %w", err)
+		return 0, fmt.Errorf("failed to serialize transactions for size calculation: %w", err)
 	}
 
-	for i, tx := range b.Transaction {
-		if tx == nil {
-			return nil, fmt.Errorf("transaction at index %d is nil", i)
-		}
+	return envelopeSize(len(headerBytes), txs), nil
+}
 
-		txBytes, err := tx.TransactionSerialize()
-		if err != nil {
-			return nil, fmt.Errorf("failed to serialize transaction %d:", err)
-		}
+// envelope - уже закодированные части конверта блока
+type envelope struct {
+	header []byte
+	txs    [][]byte
+	hash   []byte
+	size   uint32
+}
 
-		_, err = buf.Write(txBytes)
-		if err != nil {
-			return nil, fmt.Errorf("failed to write transaction %d to buffer: %w", i, err)
-		}
+func (b *Block) envelopeParts() (*envelope, error) {
+	headerBytes, err := b.Header.Serialize()
+	if err != nil {
+		return nil, fmt.Errorf("failed to serialize header: %w", err)
 	}
 
-	_, err = buf.Write(b.Hash)
+	txs, err := b.encodeTransactions()
 	if err != nil {
-		return nil, fmt.Errorf("failed to write block hash: %w", err)
+		return nil, err
 	}
 
-	return buf.Bytes(), nil
-}
+	if len(b.Hash) != HashSize {
+		return nil, fmt.Errorf("invalid block hash length: expected %d, got %d", HashSize, len(b.Hash))
+	}
 
-func (b *Block) CalculateHash() ([]byte, error) {
-    if b == nil {
-        return nil, errors.New("block is nil")
-    }
-    data, err := b.Header.Serialize()
-    if err != nil {
-        return nil, fmt.Errorf("failed to serialize header: %w", err)
-    }
-    return hash.HashBytes(data).Bytes(), nil
+	return &envelope{
+		header: headerBytes,
+		txs:    txs,
+		hash:   b.Hash,
+		size:   envelopeSize(len(headerBytes), txs),
+	}, nil
 }
 
-func (b *Block) CalculateSize() (uint32, error) {
-	if b == nil {
-		return 0, errors.New("block is nil")
+// writeTo пишет конверт в поток, считая контрольную сумму на лету
+func (e *envelope) writeTo(w io.Writer) error {
+	checksum := crc32.NewIEEE()
+	out := io.MultiWriter(w, checksum)
+
+	var scratch [4]byte
+	writeUint32 := func(v uint32) error {
+		binary.LittleEndian.PutUint32(scratch[:], v)
+		_, err := out.Write(scratch[:])
+		return err
 	}
 
-	headerBytes, err := b.Header.Serialize()
-	if err != nil {
-		return 0, fmt.Errorf("failed to serialize header for size calculation: %w", err)
+	if _, err := out.Write(EnvelopeMagic[:]); err != nil {
+		return fmt.Errorf("failed to write block magic: %w", err)
+	}
+	if _, err := out.Write([]byte{EnvelopeVersion}); err != nil {
+		return fmt.Errorf("failed to write envelope version: %w", err)
+	}
+	if err := writeUint32(uint32(len(e.header))); err != nil {
+		return fmt.Errorf("failed to write header length: %w", err)
 	}
-	headerSize := uint32(len(headerBytes))
+	if _, err := out.Write(e.header); err != nil {
+		return fmt.Errorf("failed to write header: %w", err)
+	}
+
+	if err := writeUint32(uint32(len(e.txs))); err != nil {
+		return fmt.Errorf("failed to write transaction count: %w", err)
+	}
+	for i, txBytes := range e.txs {
+		if err := writeUint32(uint32(len(txBytes))); err != nil {
+			return fmt.Errorf("failed to write transaction %d length: %w", i, err)
+		}
+		if _, err := out.Write(txBytes); err != nil {
+			return fmt.Errorf("failed to write transaction %d: %w", i, err)
+		}
+	}
+
+	if _, err := out.Write(e.hash); err != nil {
+		return fmt.Errorf("failed to write block hash: %w", err)
+	}
+	if err := writeUint32(e.size); err != nil {
+		return fmt.Errorf("failed to write block size: %w", err)
+	}
+
+	binary.LittleEndian.PutUint32(scratch[:], checksum.Sum32())
+	if _, err := w.Write(scratch[:]); err != nil {
+		return fmt.Errorf("failed to write block checksum: %w", err)
+	}
+	return nil
+}
 
-	var transactionsSize uint32 = 0
+func (b *Block) encodeTransactions() ([][]byte, error) {
+	txs := make([][]byte, len(b.Transaction))
 	for i, tx := range b.Transaction {
 		if tx == nil {
-			return 0, fmt.Errorf("transaction at index %d is nil during size calculation", i)
+			return nil, fmt.Errorf("transaction at index %d is nil", i)
 		}
-		txBytes, err := tx.TransactionSerialize()
+
+		txBytes, err := transaction.EncodeTransaction(tx)
 		if err != nil {
-			return 0, fmt.Errorf("failed to serialize transaction %d for size calculation: %w", i, err)
+			return nil, fmt.Errorf("failed to serialize transaction %d: %w", i, err)
 		}
-		transactionsSize += uint32(len(txBytes))
+		txs[i] = txBytes
 	}
+	return txs, nil
+}
 
-	hashSize := uint32(len(b.Hash)) // <-- добавляем хеш
-	return headerSize + transactionsSize + hashSize, nil
+func envelopeSize(headerLen int, txs [][]byte) uint32 {
+	size := envelopePrefixSize + headerLen + 4 + envelopeFooterSize
+	for _, txBytes := range txs {
+		size += 4 + len(txBytes)
+	}
+	return uint32(size)
 }
@@ -0,0 +1,35 @@
+package block
+
+import (
+	"context"
+	"errors"
+)
+
+// ErrBlockNotFound возвращается BlockStore, когда блока или записи индекса
+// высот нет; остальные ошибки хранилища означают сбой чтения или записи
+var ErrBlockNotFound = errors.New("block not found")
+
+// BlockStore описывает хранилище блоков, которым пользуется цепочка.
+// Формат значения выбирает реализация; store.Repository пишет байт
+// codec.Codec.ID, за которым идёт тело блока в этом кодеке (binary - конверт
+// Serialize, json или cbor), и читает каждый блок кодеком из его первого байта.
+//
+// SaveBlock сохраняет блок и делает его последним, PutBlock сохраняет блок
+// боковой ветки без смены последнего хеша, SetLastHash переносит вершину
+// на уже сохранённый блок. Хранилище ведёт индекс высот основной цепочки:
+// GetHashByHeight возвращает хеш блока на высоте от генезиса до вершины.
+// GetHeader возвращает заголовок блока без тела: по нему цепочка строит
+// дерево заголовков, а тела читает только по запросу.
+//
+// Отменённый или просроченный ctx прерывает операцию с ошибкой ctx.Err();
+// прерванная запись не оставляет изменений.
+type BlockStore interface {
+	SaveBlock(ctx context.Context, block *Block) error
+	PutBlock(ctx context.Context, block *Block) error
+	GetBlock(ctx context.Context, hash []byte) (*Block, error)
+	GetHeader(ctx context.Context, hash []byte) (*StoredHeader, error)
+	GetLastHash(ctx context.Context) ([]byte, error)
+	SetLastHash(ctx context.Context, hash []byte) error
+	GetHashByHeight(ctx context.Context, height int) ([]byte, error)
+	Close() error
+}
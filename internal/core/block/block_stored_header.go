@@ -0,0 +1,39 @@
+package block
+
+import (
+	"math/big"
+
+	"github.com/Alex1997377/weave/internal/core/header"
+)
+
+// StoredHeader - запись хранилища о блоке без тела: заголовок, хеш и
+// накопленная работа (nil, если блок сохранён без неё). Из таких записей
+// цепочка строит дерево заголовков, не читая транзакций.
+type StoredHeader struct {
+	Hash      []byte
+	Header    header.Header
+	ChainWork *big.Int
+}
+
+// StoredHeader возвращает запись о заголовке блока
+func (b *Block) StoredHeader() *StoredHeader {
+	return &StoredHeader{
+		Hash:      b.Hash,
+		Header:    b.Header,
+		ChainWork: b.ChainWork,
+	}
+}
+
+// SetChainWork вычисляет накопленную работу при накопленной работе
+// родителя parentWork (nil для генезиса)
+func (h *StoredHeader) SetChainWork(parentWork *big.Int) error {
+	work, err := h.Header.Work()
+	if err != nil {
+		return err
+	}
+	if parentWork != nil {
+		work.Add(work, parentWork)
+	}
+	h.ChainWork = work
+	return nil
+}
@@ -0,0 +1,234 @@
+package block
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash"
+	"hash/crc32"
+	"io"
+
+	"github.com/Alex1997377/weave/internal/core/consensus"
+	headerpkg "github.com/Alex1997377/weave/internal/core/header"
+	"github.com/Alex1997377/weave/internal/core/transaction"
+)
+
+// maxHeaderSize ограничивает заявленную длину заголовка в конверте
+const maxHeaderSize = 1024
+
+// Limits - ограничения, которые DecodeBlockWithLimits проверяет по мере чтения потока,
+// не дожидаясь конца блока
+type Limits struct {
+	MaxBlockSize       uint32
+	MaxTransactions    uint32
+	MaxTransactionSize uint32
+}
+
+// DefaultLimits - ограничения потокового декодера по умолчанию
+var DefaultLimits = Limits{
+	MaxBlockSize:       32 << 20,
+	MaxTransactions:    MaxTransactions,
+	MaxTransactionSize: MaxTransactionSize,
+}
+
+// withDefaults заменяет нулевые поля значениями DefaultLimits, так что
+// можно задать только нужные ограничения
+func (l Limits) withDefaults() Limits {
+	if l.MaxBlockSize == 0 {
+		l.MaxBlockSize = DefaultLimits.MaxBlockSize
+	}
+	if l.MaxTransactions == 0 {
+		l.MaxTransactions = DefaultLimits.MaxTransactions
+	}
+	if l.MaxTransactionSize == 0 {
+		l.MaxTransactionSize = DefaultLimits.MaxTransactionSize
+	}
+	return l
+}
+
+// LimitsFor возвращает ограничения декодера, заданные параметрами консенсуса
+func LimitsFor(p *consensus.Params) Limits {
+	if p == nil {
+		return DefaultLimits
+	}
+	return Limits{
+		MaxBlockSize:       p.MaxBlockSize,
+		MaxTransactions:    p.MaxTransactions,
+		MaxTransactionSize: p.MaxTransactionSize,
+	}
+}
+
+// ErrBlockTooLarge возвращается, когда поток превышает Limits.MaxBlockSize
+var ErrBlockTooLarge = errors.New("block exceeds size limit")
+
+// EncodeBlock пишет конверт блока в поток. Результат совпадает с Serialize.
+func EncodeBlock(w io.Writer, b *Block) error {
+	if b == nil {
+		return errors.New("block is nil")
+	}
+	if w == nil {
+		return errors.New("writer is nil")
+	}
+
+	parts, err := b.envelopeParts()
+	if err != nil {
+		return err
+	}
+
+	return parts.writeTo(w)
+}
+
+// DecodeBlock читает ровно один конверт блока из потока с ограничениями DefaultLimits
+func DecodeBlock(r io.Reader) (*Block, error) {
+	return DecodeBlockWithLimits(r, DefaultLimits)
+}
+
+// DecodeBlockWithLimits читает ровно один конверт блока из потока. Байты после
+// контрольной суммы не читаются, поэтому из одного потока можно читать блоки подряд.
+// Нулевые поля limits берутся из DefaultLimits.
+func DecodeBlockWithLimits(r io.Reader, limits Limits) (*Block, error) {
+	if r == nil {
+		return nil, errors.New("reader is nil")
+	}
+	limits = limits.withDefaults()
+
+	er := &envelopeReader{r: r, checksum: crc32.NewIEEE(), limit: limits.MaxBlockSize}
+
+	var magic [4]byte
+	if err := er.readFull(magic[:], "block magic"); err != nil {
+		return nil, err
+	}
+	if magic != EnvelopeMagic {
+		return nil, fmt.Errorf("invalid block magic: %x", magic[:])
+	}
+
+	var version [1]byte
+	if err := er.readFull(version[:], "envelope version"); err != nil {
+		return nil, err
+	}
+	if version[0] != EnvelopeVersion {
+		return nil, fmt.Errorf("unsupported block envelope version: %d", version[0])
+	}
+
+	headerLen, err := er.readUint32("header length")
+	if err != nil {
+		return nil, err
+	}
+	if headerLen > maxHeaderSize {
+		return nil, fmt.Errorf("header length %d exceeds limit %d", headerLen, maxHeaderSize)
+	}
+
+	headerReader := &io.LimitedReader{R: er, N: int64(headerLen)}
+	header, err := headerpkg.DecodeHeader(headerReader)
+	if err != nil {
+		return nil, fmt.Errorf("failed to deserialize header: %w", err)
+	}
+	if headerReader.N != 0 {
+		return nil, fmt.Errorf("header has %d trailing bytes", headerReader.N)
+	}
+
+	txCount, err := er.readUint32("transaction count")
+	if err != nil {
+		return nil, err
+	}
+	if txCount > limits.MaxTransactions {
+		return nil, fmt.Errorf("transaction count too high: %d (max: %d)", txCount, limits.MaxTransactions)
+	}
+
+	block := &Block{
+		Header:      *header,
+		Transaction: make([]transaction.Transaction, 0, txCount),
+	}
+
+	for i := uint32(0); i < txCount; i++ {
+		txLen, err := er.readUint32(fmt.Sprintf("tx %d length", i))
+		if err != nil {
+			return nil, err
+		}
+		if txLen == 0 {
+			return nil, fmt.Errorf("tx %d is empty", i)
+		}
+		if txLen > limits.MaxTransactionSize {
+			return nil, fmt.Errorf("transaction too large at tx %d: %d", i, txLen)
+		}
+
+		txReader := &io.LimitedReader{R: er, N: int64(txLen)}
+		tx, err := transaction.DecodeTransaction(txReader)
+		if err != nil {
+			return nil, fmt.Errorf("failed to deserialize tx %d: %w", i, err)
+		}
+		if txReader.N != 0 {
+			return nil, fmt.Errorf("transaction %d has %d trailing bytes", i, txReader.N)
+		}
+		block.Transaction = append(block.Transaction, tx)
+	}
+
+	block.Hash = make([]byte, HashSize)
+	if err := er.readFull(block.Hash, "block hash"); err != nil {
+		return nil, err
+	}
+
+	size, err := er.readUint32("block size")
+	if err != nil {
+		return nil, err
+	}
+	if size == 0 {
+		return nil, errors.New("invalid block size")
+	}
+	if int64(size) != er.n+4 {
+		return nil, fmt.Errorf("%w: envelope declares %d bytes, got %d", ErrBlockSizeMismatch, size, er.n+4)
+	}
+	block.Size = size
+
+	computed := er.checksum.Sum32()
+	var checksum [4]byte
+	if err := er.readFull(checksum[:], "block checksum"); err != nil {
+		return nil, err
+	}
+	if stored := binary.LittleEndian.Uint32(checksum[:]); stored != computed {
+		return nil, fmt.Errorf("block checksum mismatch: expected %08x, got %08x", stored, computed)
+	}
+
+	return block, nil
+}
+
+// envelopeReader считает прочитанные байты и контрольную сумму и обрывает чтение
+// при превышении лимита размера блока
+type envelopeReader struct {
+	r        io.Reader
+	checksum hash.Hash32
+	n        int64
+	limit    uint32
+}
+
+func (er *envelopeReader) Read(p []byte) (int, error) {
+	if er.limit > 0 {
+		remaining := int64(er.limit) - er.n
+		if remaining <= 0 {
+			return 0, ErrBlockTooLarge
+		}
+		if int64(len(p)) > remaining {
+			p = p[:remaining]
+		}
+	}
+
+	n, err := er.r.Read(p)
+	er.n += int64(n)
+	er.checksum.Write(p[:n])
+	return n, err
+}
+
+func (er *envelopeReader) readFull(p []byte, field string) error {
+	if _, err := io.ReadFull(er, p); err != nil {
+		return fmt.Errorf("failed to read %s: %w", field, err)
+	}
+	return nil
+}
+
+func (er *envelopeReader) readUint32(field string) (uint32, error) {
+	var buf [4]byte
+	if err := er.readFull(buf[:], field); err != nil {
+		return 0, err
+	}
+	return binary.LittleEndian.Uint32(buf[:]), nil
+}
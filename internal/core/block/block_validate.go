@@ -4,9 +4,168 @@ import (
 	"bytes"
 	"errors"
 	"fmt"
+
+	"github.com/Alex1997377/weave/internal/core/consensus"
+	"github.com/Alex1997377/weave/internal/core/header"
+	"github.com/Alex1997377/weave/internal/core/header/errors/constants"
+	"github.com/Alex1997377/weave/internal/core/transaction"
+	"github.com/Alex1997377/weave/internal/crypto/powalgo"
+)
+
+// Ошибки правил транзакций, см. CheckTransactions
+var (
+	ErrInvalidTransaction = errors.New("invalid transaction")
+	ErrInvalidCoinbase    = errors.New("invalid coinbase")
 )
 
+// ruleError - нарушение правила транзакций: текст берётся из err, а
+// errors.Is находит и err, и вид нарушения kind
+type ruleError struct {
+	kind error
+	err  error
+}
+
+func (e *ruleError) Error() string   { return e.err.Error() }
+func (e *ruleError) Unwrap() []error { return []error{e.kind, e.err} }
+
+func txError(format string, args ...any) error {
+	return &ruleError{kind: ErrInvalidTransaction, err: fmt.Errorf(format, args...)}
+}
+
+func coinbaseError(format string, args ...any) error {
+	return &ruleError{kind: ErrInvalidCoinbase, err: fmt.Errorf(format, args...)}
+}
+
+// Validate проверяет блок без привязки к сети: расписание версий по умолчанию,
+// сложность и лимиты не ограничиваются
 func (b *Block) Validate() error {
+	if err := b.validate(header.DefaultActivations, powalgo.Default()); err != nil {
+		return err
+	}
+	return b.CheckTransactions(nil)
+}
+
+// ValidateWithParams проверяет блок по правилам сети p: расписание версий,
+// границы цели, размер блока и правила транзакций (см. CheckTransactions)
+func (b *Block) ValidateWithParams(p *consensus.Params) error {
+	if p == nil {
+		return errors.New("consensus params cannot be nil")
+	}
+
+	algo, err := p.Algorithm()
+	if err != nil {
+		return fmt.Errorf("invalid consensus params: %w", err)
+	}
+
+	if err := b.validate(p.Activations, algo); err != nil {
+		return err
+	}
+
+	if err := p.CheckTarget(&b.Header); err != nil {
+		return fmt.Errorf("invalid block difficulty: %w", err)
+	}
+
+	size, err := b.CalculateSize()
+	if err != nil {
+		return fmt.Errorf("failed to calculate block size: %w", err)
+	}
+	if size > p.MaxBlockSize {
+		return fmt.Errorf("block size %d exceeds limit of %d bytes", size, p.MaxBlockSize)
+	}
+
+	return b.CheckTransactions(p)
+}
+
+// CheckTransactions проверяет транзакции блока: формат каждой транзакции,
+// место и высоту coinbase, а по правилам сети p ещё наличие транзакций в
+// блоке после генезиса, их число, размер, длину подписей и сумму coinbase.
+// При p == nil лимиты сети не проверяются. Подписи по открытому ключу не
+// проверяются: транзакция его не несёт. Нарушения правил coinbase
+// опознаются как ErrInvalidCoinbase, остальные - как ErrInvalidTransaction.
+func (b *Block) CheckTransactions(p *consensus.Params) error {
+	if b == nil {
+		return errors.New("block is nil")
+	}
+
+	if p != nil {
+		if b.Header.Index > 0 && len(b.Transaction) == 0 {
+			return txError("non-genesis block must have at least one transaction")
+		}
+		if uint32(len(b.Transaction)) > p.MaxTransactions {
+			return txError("transaction count too high: %d (max: %d)", len(b.Transaction), p.MaxTransactions)
+		}
+	}
+
+	for i, tx := range b.Transaction {
+		if tx == nil {
+			return txError("transaction at index %d is nil", i)
+		}
+
+		// coinbase допускается только первой транзакцией и только для своей высоты
+		if transaction.IsCoinbase(tx) {
+			if err := b.checkCoinbase(tx, i, p); err != nil {
+				return err
+			}
+			continue
+		}
+
+		if err := tx.TransactionValidate(); err != nil {
+			return txError("invalid transaction at index %d: %w", i, err)
+		}
+		if p == nil {
+			continue
+		}
+
+		data, err := tx.TransactionSerialize()
+		if err != nil {
+			return txError("failed to serialize transaction at index %d: %w", i, err)
+		}
+		if uint32(len(data)) > p.MaxTransactionSize {
+			return txError("transaction %d too large: %d (max: %d)", i, len(data), p.MaxTransactionSize)
+		}
+		if signed, ok := tx.(transaction.SignedTransaction); ok {
+			if n := len(signed.TransactionGetSignature()); n > p.MaxSignatureSize {
+				return txError("transaction %d signature too large: %d (max: %d)", i, n, p.MaxSignatureSize)
+			}
+		}
+	}
+
+	return nil
+}
+
+// checkCoinbase проверяет место, формат, высоту и (при p != nil) сумму coinbase
+func (b *Block) checkCoinbase(tx transaction.Transaction, position int, p *consensus.Params) error {
+	if position != 0 {
+		return coinbaseError("coinbase transaction at index %d, must be first", position)
+	}
+	if err := tx.TransactionValidate(); err != nil {
+		return coinbaseError("invalid transaction at index %d: %w", position, err)
+	}
+
+	cb, ok := tx.(*transaction.CoinbaseTransaction)
+	if !ok {
+		return nil
+	}
+	if cb.Height != b.Header.Index {
+		return coinbaseError("coinbase height %d doesn`t match block index %d", cb.Height, b.Header.Index)
+	}
+	if p != nil {
+		if reward := p.BlockReward(b.Header.Index); cb.Amount > reward {
+			return coinbaseError("coinbase amount %v exceeds block reward %v", cb.Amount, reward)
+		}
+	}
+	return nil
+}
+
+// validateFor выбирает Validate или ValidateWithParams
+func (b *Block) validateFor(p *consensus.Params) error {
+	if p == nil {
+		return b.Validate()
+	}
+	return b.ValidateWithParams(p)
+}
+
+func (b *Block) validate(activations header.ActivationSchedule, algo powalgo.Algorithm) error {
 	if b == nil {
 		return errors.New("block is nil")
 	}
@@ -19,10 +178,28 @@ func (b *Block) Validate() error {
 		return errors.New("block difficulty cannot be negative")
 	}
 
-	if !b.Hash.IsValidForDifficulty(b.Header.Difficulty) {
+	target, err := b.Header.Target()
+	if err != nil {
+		return fmt.Errorf("invalid block target: %w", err)
+	}
+
+	powHash, err := b.PowHash(algo)
+	if err != nil {
+		return fmt.Errorf("failed to calculate proof-of-work hash: %w", err)
+	}
+
+	if !powHash.MeetsTarget(target) {
 		return errors.New("invalid proof of work")
 	}
 
+	if err := b.Header.Validate(constants.OpValidate); err != nil {
+		return fmt.Errorf("invalid header: %w", err)
+	}
+
+	if err := activations.Check(&b.Header); err != nil {
+		return fmt.Errorf("invalid header version: %w", err)
+	}
+
 	calculatedHash, err := b.CalculateHash()
 	if err != nil {
 		return fmt.Errorf("failed to calculate hash for validation: %w", err)
@@ -32,15 +209,5 @@ func (b *Block) Validate() error {
 		return errors.New("block hash doesn`t match content")
 	}
 
-	for i, tx := range b.Transaction {
-		if tx == nil {
-			return fmt.Errorf("transaction at index %d is nil", i)
-		}
-
-		if err := tx.TransactionValidate(); err != nil {
-			return fmt.Errorf("invalid transaction at index %d: %w", i, err)
-		}
-	}
-
 	return nil
 }
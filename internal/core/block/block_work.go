@@ -0,0 +1,37 @@
+package block
+
+import (
+	"errors"
+	"math/big"
+)
+
+// Work возвращает работу, которую доказывает блок: 2^256 / (target + 1)
+func (b *Block) Work() (*big.Int, error) {
+	if b == nil {
+		return nil, errors.New("block is nil")
+	}
+	return b.Header.Work()
+}
+
+// CalculateChainWork возвращает накопленную работу блока при накопленной
+// работе родителя parentWork (nil для генезиса)
+func (b *Block) CalculateChainWork(parentWork *big.Int) (*big.Int, error) {
+	work, err := b.Work()
+	if err != nil {
+		return nil, err
+	}
+	if parentWork != nil {
+		work.Add(work, parentWork)
+	}
+	return work, nil
+}
+
+// SetChainWork вычисляет и сохраняет в блоке накопленную работу
+func (b *Block) SetChainWork(parentWork *big.Int) error {
+	chainWork, err := b.CalculateChainWork(parentWork)
+	if err != nil {
+		return err
+	}
+	b.ChainWork = chainWork
+	return nil
+}
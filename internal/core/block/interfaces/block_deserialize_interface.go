@@ -1,7 +1,7 @@
 package interfaces
 
 import (
-	"bytes"
+	"io"
 
 	"github.com/Alex1997377/weave/internal/core/header"
 	"github.com/Alex1997377/weave/internal/core/transaction"
@@ -9,25 +9,25 @@ import (
 
 // HeaderDeserializer определяет контракт для десериализации заголовка блока
 type HeaderDeserializer interface {
-	DeserializeHeader(r *bytes.Reader) (*header.Header, error)
+	DeserializeHeader(r io.Reader) (*header.Header, error)
 }
 
 // TransactionDeserializer определяет контракт для десериализации транзакции
 type TransactionDeserializer interface {
-	DeserializeTransaction(r *bytes.Reader) (transaction.Transaction, error)
+	DeserializeTransaction(r io.Reader) (transaction.Transaction, error)
 }
 
-// RealHeaderDeserializer - реальная реализация, использующая header.DeserializeHeader
+// RealHeaderDeserializer - реальная реализация, использующая header.DecodeHeader
 type RealHeaderDeserializer struct{}
 
-func (RealHeaderDeserializer) DeserializeHeader(r *bytes.Reader) (*header.Header, error) {
-	return header.DeserializeHeader(r)
+func (RealHeaderDeserializer) DeserializeHeader(r io.Reader) (*header.Header, error) {
+	return header.DecodeHeader(r)
 }
 
 // RealTransactionDeserializer - реальная реализация,
-// использующая transaction.DeserializeTransactionFromReader
+// использующая transaction.DecodeTransaction
 type RealTransactionDeserializer struct{}
 
-func (RealTransactionDeserializer) DeserializeTransaction(r *bytes.Reader) (transaction.Transaction, error) {
-	return transaction.DeserializeTransactionFromReader(r)
+func (RealTransactionDeserializer) DeserializeTransaction(r io.Reader) (transaction.Transaction, error) {
+	return transaction.DecodeTransaction(r)
 }
@@ -1,10 +1,28 @@
 package interfaces
 
+import "github.com/Alex1997377/weave/internal/crypto/pow"
+
 type Hash interface {
 	IsValidForDifficulty(difficulty int) bool
+	MeetsTarget(target pow.Target) bool
 	Bytes() []byte
 }
 
 type HashCalculator interface {
 	Hash(data []byte) Hash
 }
+
+// MidstateHasher хеширует данные с общим префиксом, состояние SHA-256
+// которого посчитано заранее. Не безопасен для конкурентного использования.
+type MidstateHasher interface {
+	// HashSuffix возвращает хеш prefix||suffix
+	HashSuffix(suffix []byte) Hash
+}
+
+// MidstateHashCalculator - необязательное расширение HashCalculator для
+// майнинга: nonce - последнее поле заголовка, поэтому сжатие неизменного
+// префикса выполняется один раз на раунд, а не на каждый nonce
+type MidstateHashCalculator interface {
+	HashCalculator
+	Midstate(prefix []byte) (MidstateHasher, error)
+}
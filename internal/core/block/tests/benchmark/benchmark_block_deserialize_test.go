@@ -2,8 +2,8 @@ package benchmark
 
 import (
 	"bytes"
-	"encoding/binary"
 	"fmt"
+	"io"
 	"testing"
 
 	"github.com/Alex1997377/weave/internal/core/block"
@@ -14,22 +14,16 @@ import (
 )
 
 func createBenchmarkBlockData(txCount uint32, transactions []transaction.Transaction) *bytes.Buffer {
-	buf := &bytes.Buffer{}
-
-	buf.Write(bytes.Repeat([]byte{0xAA}, 32))
-	binary.Write(buf, binary.LittleEndian, txCount)
-
+	records := make([][]byte, 0, len(transactions))
 	for _, tx := range transactions {
-		txData, err := tx.TransactionSerialize()
+		txData, err := transaction.EncodeTransaction(tx)
 		if err != nil {
 			panic(err)
 		}
-		buf.Write(txData)
+		records = append(records, txData)
 	}
-	buf.Write(bytes.Repeat([]byte{0xCC}, 32))
-	binary.Write(buf, binary.LittleEndian, uint32(12345))
 
-	return buf
+	return bytes.NewBuffer(helpers.BuildEnvelope(bytes.Repeat([]byte{0xAA}, 32), txCount, records, bytes.Repeat([]byte{0xCC}, 32)))
 }
 
 func BenchmarkDeserializeBlock(b *testing.B) {
@@ -45,21 +39,15 @@ func BenchmarkDeserializeBlock(b *testing.B) {
 			dataBytes := data.Bytes()
 
 			headerMock := &mocks.MockHeaderDeserializer{
-				MockFunc: func(r *bytes.Reader) (*header.Header, error) {
+				MockFunc: func(r io.Reader) (*header.Header, error) {
 					r.Read(make([]byte, 32))
 					return &header.Header{Index: 1}, nil
 				},
 			}
 
 			txMock := &mocks.MockTransactionDeserializer{
-				MockFunc: func(r *bytes.Reader) (transaction.Transaction, error) {
-					// Читаем ровно 172 байта – размер одной тестовой транзакции
-					buf := make([]byte, 172)
-					if _, err := r.Read(buf); err != nil {
-						return nil, err
-					}
-					// Возвращаем тестовую транзакцию (данные не важны, главное – сдвиг указателя)
-					return helpers.CreateTestTransaction(1), nil
+				MockFunc: func(r io.Reader) (transaction.Transaction, error) {
+					return helpers.ReadTestTransaction(r)
 				},
 			}
 
@@ -89,21 +77,15 @@ func BenchmarkDeserializedBlockParallel(b *testing.B) {
 	dataBytes := data.Bytes()
 
 	headerMock := &mocks.MockHeaderDeserializer{
-		MockFunc: func(r *bytes.Reader) (*header.Header, error) {
+		MockFunc: func(r io.Reader) (*header.Header, error) {
 			r.Read(make([]byte, 32))
 			return &header.Header{Index: 1}, nil
 		},
 	}
 
 	txMock := &mocks.MockTransactionDeserializer{
-		MockFunc: func(r *bytes.Reader) (transaction.Transaction, error) {
-			// Читаем ровно 172 байта – размер одной тестовой транзакции
-			buf := make([]byte, 172)
-			if _, err := r.Read(buf); err != nil {
-				return nil, err
-			}
-			// Возвращаем тестовую транзакцию (можно любую)
-			return helpers.CreateTestTransaction(1), nil
+		MockFunc: func(r io.Reader) (transaction.Transaction, error) {
+			return helpers.ReadTestTransaction(r)
 		},
 	}
 
@@ -122,3 +104,17 @@ func BenchmarkDeserializedBlockParallel(b *testing.B) {
 		}
 	})
 }
+
+func BenchmarkDeserializeBlockRoundTrip(b *testing.B) {
+	data, err := helpers.CreateMinedBankBlock(b, 100).Serialize()
+	if err != nil {
+		b.Fatalf("serialize failed: %v", err)
+	}
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := block.DeserializeBlock(data); err != nil {
+			b.Fatalf("deserialize failed: %v", err)
+		}
+	}
+}
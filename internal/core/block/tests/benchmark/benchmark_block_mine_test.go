@@ -2,12 +2,14 @@ package benchmark
 
 import (
 	"context"
+	"encoding/binary"
 	"fmt"
 	"runtime"
 	"testing"
 	"time"
 
 	"github.com/Alex1997377/weave/internal/core/block"
+	"github.com/Alex1997377/weave/internal/core/block/interfaces"
 	"github.com/Alex1997377/weave/internal/core/header"
 	"github.com/Alex1997377/weave/internal/crypto/hash"
 )
@@ -16,6 +18,7 @@ import (
 func createTestBlock(index, difficulty int) *block.Block {
 	return &block.Block{
 		Header: header.Header{
+			Version:      header.Version1,
 			Index:        index,
 			Difficulty:   difficulty,
 			PreviousHash: make([]byte, 32),
@@ -29,17 +32,27 @@ func createTestBlock(index, difficulty int) *block.Block {
 	}
 }
 
-// BenchmarkMine измеряет время майнинга с разным количеством воркеров.
+// BenchmarkMine измеряет время майнинга с разным количеством воркеров
+// и реальный хешрейт по итоговым отчётам Mine.
 func BenchmarkMine(b *testing.B) {
 	difficulty := 10 // умеренная сложность, чтобы каждая итерация была измеримой
 
 	for _, workers := range []int{1, runtime.NumCPU(), 2 * runtime.NumCPU()} {
 		b.Run(fmt.Sprintf("workers=%d", workers), func(b *testing.B) {
 			hasher := &hash.HashCalculatorImpl{}
+
+			var hashes uint64
+			var elapsed time.Duration
 			config := block.MineConfig{
 				NumWorkers: workers,
 				Verbose:    false,
 				Hasher:     hasher,
+				OnProgress: func(p block.MineProgress) {
+					if p.Done {
+						hashes += p.Hashes
+						elapsed += p.Elapsed
+					}
+				},
 			}
 
 			b.ResetTimer()
@@ -50,6 +63,93 @@ func BenchmarkMine(b *testing.B) {
 					b.Fatalf("mine failed: %v", err)
 				}
 			}
+
+			b.StopTimer()
+			if elapsed > 0 {
+				b.ReportMetric(float64(hashes)/elapsed.Seconds(), "hashes/s")
+			}
+			b.ReportMetric(float64(hashes)/float64(b.N), "hashes/op")
+		})
+	}
+}
+
+// plainHasher скрывает midstate-расширение, чтобы сравнить полный перебор
+type plainHasher struct {
+	inner hash.HashCalculatorImpl
+}
+
+func (p plainHasher) Hash(data []byte) interfaces.Hash {
+	return p.inner.Hash(data)
+}
+
+// BenchmarkHeaderHash сравнивает хеширование заголовка целиком и от midstate
+func BenchmarkHeaderHash(b *testing.B) {
+	blk := createTestBlock(1, 16)
+	base, nonceOffset, err := blk.Header.SerializeWithoutNonce()
+	if err != nil {
+		b.Fatalf("serialize failed: %v", err)
+	}
+	target, _ := blk.Header.Target()
+
+	b.Run("full", func(b *testing.B) {
+		hasher := hash.HashCalculatorImpl{}
+		data := append([]byte(nil), base...)
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			binary.LittleEndian.PutUint64(data[nonceOffset:], uint64(i))
+			hasher.Hash(data).MeetsTarget(target)
+		}
+	})
+
+	b.Run("midstate", func(b *testing.B) {
+		ms, err := hash.HashCalculatorImpl{}.Midstate(base[:nonceOffset])
+		if err != nil {
+			b.Fatalf("midstate failed: %v", err)
+		}
+		var suffix [8]byte
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			binary.LittleEndian.PutUint64(suffix[:], uint64(i))
+			ms.HashSuffix(suffix[:]).MeetsTarget(target)
+		}
+	})
+}
+
+// BenchmarkMineMidstate сравнивает хешрейт майнинга с midstate и без него
+func BenchmarkMineMidstate(b *testing.B) {
+	hashers := map[string]interfaces.HashCalculator{
+		"full":     plainHasher{},
+		"midstate": hash.HashCalculatorImpl{},
+	}
+
+	for _, name := range []string{"full", "midstate"} {
+		b.Run(name, func(b *testing.B) {
+			var hashes uint64
+			var elapsed time.Duration
+			config := block.MineConfig{
+				NumWorkers: 1,
+				Hasher:     hashers[name],
+				OnProgress: func(p block.MineProgress) {
+					if p.Done {
+						hashes += p.Hashes
+						elapsed += p.Elapsed
+					}
+				},
+			}
+
+			for i := 0; i < b.N; i++ {
+				// одинаковые заголовки в обоих вариантах - одинаковое число хешей
+				blk := createTestBlock(1, 12)
+				blk.Header.Timestamp = 1735689600 + int64(i)
+				if err := blk.Mine(context.Background(), config); err != nil {
+					b.Fatalf("mine failed: %v", err)
+				}
+			}
+
+			b.StopTimer()
+			if hashes > 0 {
+				b.ReportMetric(float64(elapsed.Nanoseconds())/float64(hashes), "ns/hash")
+			}
 		})
 	}
 }
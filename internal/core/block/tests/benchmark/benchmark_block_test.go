@@ -12,7 +12,7 @@ func BenchmarkNewBlock(b *testing.B) {
 	tx := helpers.CreateTestTransaction(1)
 	prevHash := make([]byte, 32)
 	for i := 0; i < b.N; i++ {
-		_, err := block.NewBlock([]transaction.Transaction{tx}, prevHash, 1, 0)
+		_, err := block.NewBlock(b.Context(), []transaction.Transaction{tx}, prevHash, 1, 0)
 		if err != nil {
 			b.Fatal(err)
 		}
@@ -0,0 +1,306 @@
+package tests
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Alex1997377/weave/internal/core/block"
+	"github.com/Alex1997377/weave/internal/core/block/interfaces"
+	"github.com/Alex1997377/weave/internal/core/block/tests/helpers"
+	"github.com/Alex1997377/weave/internal/core/block/tests/mocks"
+	"github.com/Alex1997377/weave/internal/core/consensus"
+	"github.com/Alex1997377/weave/internal/core/transaction"
+)
+
+// headerChangedHasher считает хеш валидным только после изменения заголовка
+// за пределами nonce (время или MerkleRoot)
+type headerChangedHasher struct {
+	initial []byte
+}
+
+func (h *headerChangedHasher) Hash(data []byte) interfaces.Hash {
+	valid := !bytes.Equal(data[:len(data)-8], h.initial)
+	return mocks.MockHash{Valid: valid, BytesHash: bytes.Repeat([]byte{0x01}, 32)}
+}
+
+// recordingHasher запоминает перебранные nonce первого раунда и никогда не находит блок
+type recordingHasher struct {
+	mu      sync.Mutex
+	initial []byte
+	seen    map[uint64]int
+}
+
+func (h *recordingHasher) Hash(data []byte) interfaces.Hash {
+	if bytes.Equal(data[:len(data)-8], h.initial) {
+		h.mu.Lock()
+		h.seen[binary.LittleEndian.Uint64(data[len(data)-8:])]++
+		h.mu.Unlock()
+	}
+	return mocks.MockHash{Valid: false}
+}
+
+func TestBlock_Mine_RollsTimestamp(t *testing.T) {
+	blk := helpers.CreateTestBlock(1, 8)
+	initial, _, _ := blk.Header.SerializeWithoutNonce()
+	timestamp := blk.Header.Timestamp
+
+	err := blk.Mine(context.Background(), block.MineConfig{
+		NumWorkers: 2,
+		NonceRange: 16,
+		Hasher:     &headerChangedHasher{initial: initial[:len(initial)-8]},
+	})
+	if err != nil {
+		t.Fatalf("Mine failed: %v", err)
+	}
+
+	if blk.Header.Timestamp <= timestamp {
+		t.Errorf("timestamp was not rolled: %d", blk.Header.Timestamp)
+	}
+	if blk.Header.Nonce >= 16 {
+		t.Errorf("nonce %d outside of configured range", blk.Header.Nonce)
+	}
+}
+
+func TestBlock_Mine_RollsExtraNonce(t *testing.T) {
+	cb := transaction.NewCoinbase(1, bytes.Repeat([]byte{0x11}, 32), 50)
+	blk := helpers.CreateTestBlock(1, 8)
+	blk.Transaction = []transaction.Transaction{cb}
+	if err := blk.SetMerkleRoot(); err != nil {
+		t.Fatalf("SetMerkleRoot error: %v", err)
+	}
+
+	initial, _, _ := blk.Header.SerializeWithoutNonce()
+	merkleRoot := append([]byte(nil), blk.Header.MerkleRoot...)
+	timestamp := blk.Header.Timestamp
+
+	err := blk.Mine(context.Background(), block.MineConfig{
+		NumWorkers: 3,
+		NonceRange: 16,
+		Hasher:     &headerChangedHasher{initial: initial[:len(initial)-8]},
+	})
+	if err != nil {
+		t.Fatalf("Mine failed: %v", err)
+	}
+
+	if rolled := blk.Transaction[0].(*transaction.CoinbaseTransaction); rolled.ExtraNonce != 1 {
+		t.Errorf("got extra nonce %d, want 1", rolled.ExtraNonce)
+	}
+	if cb.ExtraNonce != 0 {
+		t.Error("roll changed the original coinbase transaction")
+	}
+	if bytes.Equal(blk.Header.MerkleRoot, merkleRoot) {
+		t.Error("merkle root was not updated after extra nonce roll")
+	}
+	if blk.Header.Timestamp != timestamp {
+		t.Error("timestamp must not change while extra nonce can be rolled")
+	}
+}
+
+func TestBlock_Mine_CloneKeepsTemplateIntact(t *testing.T) {
+	tpl := helpers.CreateTestBlock(1, 8)
+	tpl.Transaction = []transaction.Transaction{transaction.NewCoinbase(1, bytes.Repeat([]byte{0x11}, 32), 50)}
+	if err := tpl.SetMerkleRoot(); err != nil {
+		t.Fatalf("SetMerkleRoot error: %v", err)
+	}
+	merkleRoot := append([]byte(nil), tpl.Header.MerkleRoot...)
+
+	initial, _, _ := tpl.Header.SerializeWithoutNonce()
+	clone := tpl.Clone()
+	err := clone.Mine(context.Background(), block.MineConfig{
+		NumWorkers: 1,
+		NonceRange: 16,
+		Hasher:     &headerChangedHasher{initial: initial[:len(initial)-8]},
+	})
+	if err != nil {
+		t.Fatalf("Mine failed: %v", err)
+	}
+
+	// шаблон и его транзакции не меняются, MerkleRoot шаблона остаётся верным
+	if cb := tpl.Transaction[0].(*transaction.CoinbaseTransaction); cb.ExtraNonce != 0 {
+		t.Errorf("template extra nonce %d, want 0", cb.ExtraNonce)
+	}
+	if !bytes.Equal(tpl.Header.MerkleRoot, merkleRoot) || !bytes.Equal(tpl.CalculateMerkleRoot(), merkleRoot) {
+		t.Error("template merkle root went stale after mining a clone")
+	}
+}
+
+func TestBlock_Mine_DisjointWorkerRanges(t *testing.T) {
+	const nonceRange = 1000
+
+	blk := helpers.CreateTestBlock(1, 8)
+	initial, _, _ := blk.Header.SerializeWithoutNonce()
+	hasher := &recordingHasher{initial: initial[:len(initial)-8], seen: map[uint64]int{}}
+
+	err := blk.Mine(context.Background(), block.MineConfig{
+		NumWorkers: 7,
+		NonceRange: nonceRange,
+		Timeout:    50 * time.Millisecond,
+		Hasher:     hasher,
+	})
+	if err == nil {
+		t.Fatal("expected timeout, got nil")
+	}
+
+	hasher.mu.Lock()
+	defer hasher.mu.Unlock()
+
+	if len(hasher.seen) != nonceRange {
+		t.Errorf("first round covered %d nonces, want %d", len(hasher.seen), nonceRange)
+	}
+	for nonce, count := range hasher.seen {
+		if count != 1 {
+			t.Errorf("nonce %d hashed %d times", nonce, count)
+		}
+		if nonce >= nonceRange {
+			t.Errorf("nonce %d outside of configured range", nonce)
+		}
+	}
+}
+
+func TestCoinbase_RoundTrip(t *testing.T) {
+	cb := transaction.NewCoinbase(7, bytes.Repeat([]byte{0x22}, 32), 25)
+	cb.Data = []byte("weave")
+
+	id := cb.TransactionGetID()
+	cb.ExtraNonce++
+	if bytes.Equal(id, cb.TransactionGetID()) {
+		t.Error("extra nonce must change transaction id")
+	}
+
+	encoded, err := transaction.EncodeTransaction(cb)
+	if err != nil {
+		t.Fatalf("EncodeTransaction error: %v", err)
+	}
+	decoded, err := transaction.DecodeTransaction(bytes.NewReader(encoded))
+	if err != nil {
+		t.Fatalf("DecodeTransaction error: %v", err)
+	}
+	if !bytes.Equal(decoded.TransactionGetID(), cb.TransactionGetID()) {
+		t.Error("binary round trip changed coinbase")
+	}
+
+	data, err := json.Marshal(cb)
+	if err != nil {
+		t.Fatalf("Marshal error: %v", err)
+	}
+	fromJSON, err := transaction.UnmarshalTransactionJSON(data)
+	if err != nil {
+		t.Fatalf("UnmarshalTransactionJSON error: %v", err)
+	}
+	if !bytes.Equal(fromJSON.TransactionGetID(), cb.TransactionGetID()) {
+		t.Error("JSON round trip changed coinbase")
+	}
+
+	tampered := strings.Replace(string(data), `"amount":25`, `"amount":50`, 1)
+	if _, err := transaction.UnmarshalTransactionJSON([]byte(tampered)); err == nil {
+		t.Error("expected id mismatch for tampered coinbase")
+	}
+}
+
+func TestValidateWithParams_Coinbase(t *testing.T) {
+	p := consensus.Regtest()
+	recipient := bytes.Repeat([]byte{0x33}, 32)
+
+	tests := []struct {
+		name   string
+		txs    func() []transaction.Transaction
+		errMsg string
+	}{
+		{
+			name: "valid reward",
+			txs: func() []transaction.Transaction {
+				return []transaction.Transaction{transaction.NewCoinbase(1, recipient, p.BlockReward(1))}
+			},
+		},
+		{
+			name: "reward too high",
+			txs: func() []transaction.Transaction {
+				return []transaction.Transaction{transaction.NewCoinbase(1, recipient, p.BlockReward(1)+1)}
+			},
+			errMsg: "exceeds block reward",
+		},
+		{
+			name: "coinbase not first",
+			txs: func() []transaction.Transaction {
+				return []transaction.Transaction{helpers.CreateBankTransaction(1), transaction.NewCoinbase(1, recipient, 1)}
+			},
+			errMsg: "must be first",
+		},
+		{
+			name: "wrong height",
+			txs: func() []transaction.Transaction {
+				return []transaction.Transaction{transaction.NewCoinbase(2, recipient, 1)}
+			},
+			errMsg: "doesn`t match block index",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			blk := helpers.CreateTestBlock(1, 0)
+			blk.Transaction = tt.txs()
+			if err := blk.SetMerkleRoot(); err != nil {
+				t.Fatalf("SetMerkleRoot error: %v", err)
+			}
+			if err := blk.Mine(context.Background(), block.MineConfig{}); err != nil {
+				t.Fatalf("Mine error: %v", err)
+			}
+
+			// сложность 0 за пределами границ regtest не проверяем - только coinbase
+			p := consensus.Regtest()
+			p.Activations = nil
+			err := blk.ValidateWithParams(p)
+			if tt.errMsg == "" {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.errMsg) {
+				t.Errorf("error = %v, want contain %q", err, tt.errMsg)
+			}
+		})
+	}
+}
+
+func TestCheckTransactions_ErrorKinds(t *testing.T) {
+	p := consensus.Regtest()
+	recipient := bytes.Repeat([]byte{0x33}, 32)
+	invalid := helpers.CreateBankTransaction(1)
+	invalid.Recipient = nil
+
+	tests := []struct {
+		name string
+		txs  []transaction.Transaction
+		want error
+	}{
+		{name: "reward too high", txs: []transaction.Transaction{transaction.NewCoinbase(1, recipient, p.BlockReward(1)+1)}, want: block.ErrInvalidCoinbase},
+		{name: "coinbase not first", txs: []transaction.Transaction{helpers.CreateBankTransaction(1), transaction.NewCoinbase(1, recipient, 1)}, want: block.ErrInvalidCoinbase},
+		{name: "no transactions", want: block.ErrInvalidTransaction},
+		{name: "invalid transaction", txs: []transaction.Transaction{transaction.NewCoinbase(1, recipient, 1), invalid}, want: block.ErrInvalidTransaction},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			blk := helpers.CreateTestBlock(1, 0)
+			blk.Transaction = tt.txs
+			if err := blk.CheckTransactions(p); !errors.Is(err, tt.want) {
+				t.Fatalf("CheckTransactions error = %v, want %v", err, tt.want)
+			}
+		})
+	}
+
+	// без параметров сети лимиты не проверяются
+	blk := helpers.CreateTestBlock(1, 0)
+	blk.Transaction = []transaction.Transaction{transaction.NewCoinbase(1, recipient, p.BlockReward(1)+1)}
+	if err := blk.CheckTransactions(nil); err != nil {
+		t.Fatalf("CheckTransactions(nil) error: %v", err)
+	}
+}
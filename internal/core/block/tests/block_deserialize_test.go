@@ -2,8 +2,9 @@ package tests
 
 import (
 	"bytes"
-	"encoding/binary"
 	"errors"
+	"io"
+	"strings"
 	"sync"
 	"testing"
 
@@ -14,6 +15,23 @@ import (
 	"github.com/Alex1997377/weave/internal/core/transaction"
 )
 
+func encodeRecords(t *testing.T, transactions []transaction.Transaction) [][]byte {
+	t.Helper()
+	records := make([][]byte, len(transactions))
+	for i, tx := range transactions {
+		data, err := transaction.EncodeTransaction(tx)
+		if err != nil {
+			t.Fatalf("failed to encode transaction %d: %v", i, err)
+		}
+		records[i] = data
+	}
+	return records
+}
+
+func readTestTransaction(r io.Reader) (transaction.Transaction, error) {
+	return helpers.ReadTestTransaction(r)
+}
+
 func TestDeserializeBlockWithDeps_Success(t *testing.T) {
 	txCount := uint32(3)
 	transactions := []transaction.Transaction{
@@ -28,18 +46,16 @@ func TestDeserializeBlockWithDeps_Success(t *testing.T) {
 	var callCount int
 
 	txMock := &mocks.MockTransactionDeserializer{
-		MockFunc: func(r *bytes.Reader) (transaction.Transaction, error) {
+		MockFunc: func(r io.Reader) (transaction.Transaction, error) {
 			mu.Lock()
-			idx := callCount
 			callCount++
 			mu.Unlock()
-			t.Logf("Mock called for tx %d", idx)
-			return helpers.CreateTestTransaction(byte(idx + 1)), nil
+			return readTestTransaction(r)
 		},
 	}
 
 	headerMock := &mocks.MockHeaderDeserializer{
-		MockFunc: func(r *bytes.Reader) (*header.Header, error) {
+		MockFunc: func(r io.Reader) (*header.Header, error) {
 			r.Read(make([]byte, 32))
 			return &header.Header{Index: 42}, nil
 		},
@@ -59,8 +75,18 @@ func TestDeserializeBlockWithDeps_Success(t *testing.T) {
 		t.Errorf("got %d transactions, want %d", len(blk.Transaction), txCount)
 	}
 
-	if blk.Size != 12345 {
-		t.Errorf("got size %d, want 12345", blk.Size)
+	for i, tx := range blk.Transaction {
+		if !bytes.Equal(tx.TransactionGetID(), transactions[i].TransactionGetID()) {
+			t.Errorf("transaction %d out of order", i)
+		}
+	}
+
+	if blk.Header.Index != 42 {
+		t.Errorf("got header index %d, want 42", blk.Header.Index)
+	}
+
+	if blk.Size != uint32(len(dataBytes)) {
+		t.Errorf("got size %d, want %d", blk.Size, len(dataBytes))
 	}
 
 	if !bytes.Equal(blk.Hash, bytes.Repeat([]byte{0xCC}, 32)) {
@@ -94,19 +120,14 @@ func TestDeserializeBlockWithDeps_ZeroTransactions(t *testing.T) {
 }
 
 func TestDeserializeBlockWithDeps_TransactionCountTooHigh(t *testing.T) {
-	buf := &bytes.Buffer{}
-
-	buf.Write(bytes.Repeat([]byte{0xAA}, 32))
-	binary.Write(buf, binary.LittleEndian, uint32(10001))
-	buf.Write(bytes.Repeat([]byte{0xCC}, 32))
-	binary.Write(buf, binary.LittleEndian, uint32(12345))
+	data := helpers.BuildEnvelope(bytes.Repeat([]byte{0xAA}, 32), 10001, nil, bytes.Repeat([]byte{0xCC}, 32))
 
 	opts := block.DeserializeOptions{
 		Header: &mocks.MockHeaderDeserializer{},
 		Tx:     &mocks.MockTransactionDeserializer{},
 	}
 
-	_, err := block.DeserializeBlockWithparallelPooled(buf.Bytes(), opts)
+	_, err := block.DeserializeBlockWithparallelPooled(data, opts)
 	if err == nil {
 		t.Fatal("expected error, got nil")
 	}
@@ -118,55 +139,22 @@ func TestDeserializeBlockWithDeps_TransactionCountTooHigh(t *testing.T) {
 }
 
 func TestDeserializeBlockWithDeps_TransactionError(t *testing.T) {
-	buf := &bytes.Buffer{}
-
-	buf.Write(bytes.Repeat([]byte{0xAA}, 32))
-	binary.Write(buf, binary.LittleEndian, uint32(2))
-
-	tx1 := helpers.CreateTestTransaction(1)
-
-	tx1Data, _ := tx1.TransactionSerialize()
-	buf.Write(tx1Data)
-
-	tx2 := helpers.CreateTestTransaction(2)
-
-	tx2Data, _ := tx2.TransactionSerialize()
-	buf.Write(tx2Data)
-
-	buf.Write(bytes.Repeat([]byte{0xCC}, 32))
-	binary.Write(buf, binary.LittleEndian, uint32(12345))
+	transactions := []transaction.Transaction{
+		helpers.CreateTestTransaction(1),
+		helpers.CreateTestTransaction(2),
+	}
+	data := helpers.CreateValidBlockData(t, 2, transactions)
 
 	txMock := &mocks.MockTransactionDeserializer{
-		MockFunc: func(r *bytes.Reader) (transaction.Transaction, error) {
-			sender := make([]byte, 32)
-			r.Read(sender)
-
-			recipient := make([]byte, 32)
-			r.Read(recipient)
-
-			id := make([]byte, 32)
-			r.Read(id)
-
-			var amount float64
-			binary.Read(r, binary.LittleEndian, &amount)
-
-			var sigLen uint32
-			binary.Read(r, binary.LittleEndian, &sigLen)
-
-			signature := make([]byte, sigLen)
-			r.Read(signature)
-
-			if id[0] == 2 {
+		MockFunc: func(r io.Reader) (transaction.Transaction, error) {
+			tx, err := helpers.ReadTestTransaction(r)
+			if err != nil {
+				return nil, err
+			}
+			if tx.Id[0] == 2 {
 				return nil, errors.New("simulated error")
 			}
-
-			return &helpers.TestTransaction{
-				Id:        id,
-				Sender:    sender,
-				Recipient: recipient,
-				Amount:    amount,
-				Signature: signature,
-			}, nil
+			return tx, nil
 		},
 	}
 	opts := block.DeserializeOptions{
@@ -174,13 +162,13 @@ func TestDeserializeBlockWithDeps_TransactionError(t *testing.T) {
 		Tx:     txMock,
 	}
 
-	_, err := block.DeserializeBlockWithparallelPooled(buf.Bytes(), opts)
+	_, err := block.DeserializeBlockWithparallelPooled(data.Bytes(), opts)
 	if err == nil {
 		t.Fatal("expected error, got nil")
 	}
 
-	if err.Error() != "tx 1 missing" {
-		t.Errorf("expected 'tx 1 missing', got %q", err.Error())
+	if err.Error() != "tx 1 missing: simulated error" {
+		t.Errorf("expected 'tx 1 missing: simulated error', got %q", err.Error())
 	}
 
 	if txMock.GetCallCount() != 2 {
@@ -189,29 +177,22 @@ func TestDeserializeBlockWithDeps_TransactionError(t *testing.T) {
 }
 
 func TestDeserializeBlockWithDeps_ExtraData(t *testing.T) {
-	buf := &bytes.Buffer{}
-
-	buf.Write(bytes.Repeat([]byte{0xAA}, 32))
-	binary.Write(buf, binary.LittleEndian, uint32(0))
-	buf.Write(bytes.Repeat([]byte{0xCC}, 32))
-	binary.Write(buf, binary.LittleEndian, uint32(100))
-	buf.WriteByte(0xFF) // extra
+	data := helpers.CreateValidBlockData(t, 0, nil).Bytes()
+	data = append(data, 0xFF) // extra
 
 	opts := block.DeserializeOptions{
 		Header: &mocks.MockHeaderDeserializer{},
 		Tx:     &mocks.MockTransactionDeserializer{},
 	}
 
-	blk, err := block.DeserializeBlockWithparallelPooled(buf.Bytes(), opts)
+	blk, err := block.DeserializeBlockWithparallelPooled(data, opts)
 
 	if err == nil {
 		t.Fatal("expected error, got nil")
 	}
 
-	expected := "extra data after block deserialization: 1 bytes remaining"
-
-	if err.Error() != expected {
-		t.Errorf("expected %q, got %q", expected, err.Error())
+	if !errors.Is(err, block.ErrBlockSizeMismatch) {
+		t.Errorf("expected %v, got %v", block.ErrBlockSizeMismatch, err)
 	}
 
 	if blk != nil {
@@ -219,20 +200,37 @@ func TestDeserializeBlockWithDeps_ExtraData(t *testing.T) {
 	}
 }
 
-func TestDeserializeBlockWithDeps_InvalidBlockSize(t *testing.T) {
-	buf := &bytes.Buffer{}
+func TestDeserializeBlockWithDeps_ExtraTransactionData(t *testing.T) {
+	records := encodeRecords(t, []transaction.Transaction{helpers.CreateTestTransaction(1)})
+	// запись есть, а счётчик транзакций равен нулю
+	data := helpers.BuildEnvelope(bytes.Repeat([]byte{0xAA}, 32), 0, records, bytes.Repeat([]byte{0xCC}, 32))
 
-	buf.Write(bytes.Repeat([]byte{0xAA}, 32))
-	binary.Write(buf, binary.LittleEndian, uint32(0))
-	buf.Write(bytes.Repeat([]byte{0xCC}, 32))
-	binary.Write(buf, binary.LittleEndian, uint32(0)) // size 0
+	opts := block.DeserializeOptions{
+		Header: &mocks.MockHeaderDeserializer{},
+		Tx:     &mocks.MockTransactionDeserializer{},
+	}
+
+	_, err := block.DeserializeBlockWithparallelPooled(data, opts)
+	if err == nil {
+		t.Fatal("expected error, got nil")
+	}
+
+	if !strings.HasPrefix(err.Error(), "extra data after block transactions") {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestDeserializeBlockWithDeps_InvalidBlockSize(t *testing.T) {
+	data := helpers.CreateValidBlockData(t, 0, nil).Bytes()
+	sizeOffset := len(data) - 8
+	copy(data[sizeOffset:], []byte{0, 0, 0, 0}) // size 0
 
 	opts := block.DeserializeOptions{
 		Header: &mocks.MockHeaderDeserializer{},
 		Tx:     &mocks.MockTransactionDeserializer{},
 	}
 
-	blk, err := block.DeserializeBlockWithparallelPooled(buf.Bytes(), opts)
+	blk, err := block.DeserializeBlockWithparallelPooled(data, opts)
 
 	if err == nil {
 		t.Fatal("expected error, got nil")
@@ -248,52 +246,54 @@ func TestDeserializeBlockWithDeps_InvalidBlockSize(t *testing.T) {
 	}
 }
 
-func TestDeserializeBlockWithDeps_MaxTransactions(t *testing.T) {
-	txCount := uint32(10000)
-	buf := &bytes.Buffer{}
-
-	buf.Write(bytes.Repeat([]byte{0xAA}, 32))
-	binary.Write(buf, binary.LittleEndian, txCount)
+func TestDeserializeBlockWithDeps_ChecksumMismatch(t *testing.T) {
+	data := helpers.CreateValidBlockData(t, 1, []transaction.Transaction{helpers.CreateTestTransaction(1)}).Bytes()
+	data[20] ^= 0xFF
 
-	for i := uint32(0); i < txCount; i++ {
-		tx := helpers.CreateTestTransaction(byte(i % 256))
-
-		txData, _ := tx.TransactionSerialize()
-
-		buf.Write(txData)
+	opts := block.DeserializeOptions{
+		Header: &mocks.MockHeaderDeserializer{},
+		Tx:     &mocks.MockTransactionDeserializer{},
 	}
 
-	buf.Write(bytes.Repeat([]byte{0xCC}, 32))
-	binary.Write(buf, binary.LittleEndian, uint32(12345))
+	_, err := block.DeserializeBlockWithparallelPooled(data, opts)
+	if err == nil {
+		t.Fatal("expected error, got nil")
+	}
 
-	txMock := &mocks.MockTransactionDeserializer{
-		MockFunc: func(r *bytes.Reader) (transaction.Transaction, error) {
-			sender := make([]byte, 32)
-			r.Read(sender)
+	if !strings.HasPrefix(err.Error(), "block checksum mismatch") {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
 
-			recipient := make([]byte, 32)
-			r.Read(recipient)
+func TestDeserializeBlockWithDeps_InvalidMagic(t *testing.T) {
+	data := helpers.CreateValidBlockData(t, 0, nil).Bytes()
+	data[0] = 'X'
 
-			id := make([]byte, 32)
-			r.Read(id)
+	opts := block.DeserializeOptions{
+		Header: &mocks.MockHeaderDeserializer{},
+		Tx:     &mocks.MockTransactionDeserializer{},
+	}
 
-			var amount float64
-			binary.Read(r, binary.LittleEndian, &amount)
+	_, err := block.DeserializeBlockWithparallelPooled(data, opts)
+	if err == nil {
+		t.Fatal("expected error, got nil")
+	}
 
-			var sigLen uint32
-			binary.Read(r, binary.LittleEndian, &sigLen)
+	if !strings.HasPrefix(err.Error(), "invalid block magic") {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
 
-			signature := make([]byte, sigLen)
-			r.Read(signature)
+func TestDeserializeBlockWithDeps_MaxTransactions(t *testing.T) {
+	txCount := uint32(10000)
+	transactions := make([]transaction.Transaction, txCount)
+	for i := uint32(0); i < txCount; i++ {
+		transactions[i] = helpers.CreateTestTransaction(byte(i % 256))
+	}
+	data := helpers.CreateValidBlockData(t, txCount, transactions)
 
-			return &helpers.TestTransaction{
-				Id:        id,
-				Sender:    sender,
-				Recipient: recipient,
-				Amount:    amount,
-				Signature: signature,
-			}, nil
-		},
+	txMock := &mocks.MockTransactionDeserializer{
+		MockFunc: readTestTransaction,
 	}
 
 	opts := block.DeserializeOptions{
@@ -301,7 +301,7 @@ func TestDeserializeBlockWithDeps_MaxTransactions(t *testing.T) {
 		Tx:     txMock,
 	}
 
-	blk, err := block.DeserializeBlockWithparallelPooled(buf.Bytes(), opts)
+	blk, err := block.DeserializeBlockWithparallelPooled(data.Bytes(), opts)
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -314,3 +314,34 @@ func TestDeserializeBlockWithDeps_MaxTransactions(t *testing.T) {
 		t.Errorf("expected %d calls, got %d", txCount, txMock.GetCallCount())
 	}
 }
+
+func TestDeserializeBlock_RoundTrip(t *testing.T) {
+	blk := helpers.CreateMinedBankBlock(t, 3)
+
+	data, err := blk.Serialize()
+	if err != nil {
+		t.Fatalf("Serialize error: %v", err)
+	}
+
+	decoded, err := block.DeserializeBlock(data)
+	if err != nil {
+		t.Fatalf("DeserializeBlock error: %v", err)
+	}
+
+	again, err := decoded.Serialize()
+	if err != nil {
+		t.Fatalf("Serialize of decoded block error: %v", err)
+	}
+
+	if !bytes.Equal(data, again) {
+		t.Fatal("round trip is not byte-exact")
+	}
+
+	if decoded.Size != blk.Size {
+		t.Errorf("got size %d, want %d", decoded.Size, blk.Size)
+	}
+
+	if err := decoded.Validate(); err != nil {
+		t.Errorf("decoded block is invalid: %v", err)
+	}
+}
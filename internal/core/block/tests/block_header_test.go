@@ -0,0 +1,176 @@
+package tests
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/Alex1997377/weave/internal/core/header"
+	"github.com/Alex1997377/weave/internal/crypto/serialize"
+)
+
+func createTestHeader() header.Header {
+	return header.Header{
+		Version:      header.Version1,
+		Index:        7,
+		Timestamp:    1234567890,
+		PreviousHash: bytes.Repeat([]byte{0xAA}, 32),
+		MerkleRoot:   bytes.Repeat([]byte{0xBB}, 32),
+		Nonce:        0x0102030405060708,
+		Difficulty:   12,
+	}
+}
+
+func TestHeader_SerializeFixedWidth(t *testing.T) {
+	h := createTestHeader()
+
+	data, err := h.Serialize()
+	if err != nil {
+		t.Fatalf("Serialize error: %v", err)
+	}
+	if len(data) != header.HeaderSizeV1 {
+		t.Errorf("got %d bytes, want %d", len(data), header.HeaderSizeV1)
+	}
+
+	base, nonceOffset, err := h.SerializeWithoutNonce()
+	if err != nil {
+		t.Fatalf("SerializeWithoutNonce error: %v", err)
+	}
+	if nonceOffset != len(base)-8 {
+		t.Errorf("nonce offset %d is not at the end of %d bytes", nonceOffset, len(base))
+	}
+	if !bytes.Equal(base[:nonceOffset], data[:nonceOffset]) {
+		t.Error("prefix before nonce differs between Serialize and SerializeWithoutNonce")
+	}
+}
+
+func TestHeader_RoundTrip(t *testing.T) {
+	h := createTestHeader()
+
+	data, err := h.Serialize()
+	if err != nil {
+		t.Fatalf("Serialize error: %v", err)
+	}
+
+	decoded, err := header.DeserializeHeader(bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("DeserializeHeader error: %v", err)
+	}
+
+	if decoded.Version != h.Version || decoded.Index != h.Index || decoded.Timestamp != h.Timestamp ||
+		decoded.Nonce != h.Nonce || decoded.Difficulty != h.Difficulty {
+		t.Errorf("decoded header %+v differs from %+v", decoded, h)
+	}
+
+	again, err := decoded.Serialize()
+	if err != nil {
+		t.Fatalf("Serialize of decoded header error: %v", err)
+	}
+	if !bytes.Equal(data, again) {
+		t.Error("header round trip is not byte-exact")
+	}
+}
+
+func TestHeader_UnknownVersion(t *testing.T) {
+	h := createTestHeader()
+	h.Version = 99
+
+	if _, err := h.Serialize(); err == nil {
+		t.Error("expected error for unknown version")
+	}
+
+	valid := createTestHeader()
+	data, err := valid.Serialize()
+	if err != nil {
+		t.Fatalf("Serialize error: %v", err)
+	}
+	data[0] = 99
+	if _, err := header.DeserializeHeader(bytes.NewReader(data)); err == nil {
+		t.Error("expected error when decoding unknown version")
+	}
+}
+
+func TestHeader_SerializeHeaderMatchesCanonical(t *testing.T) {
+	h := createTestHeader()
+
+	canonical, err := h.Serialize()
+	if err != nil {
+		t.Fatalf("Serialize error: %v", err)
+	}
+
+	legacy, err := serialize.SerializeHeader(h.Index, h.Timestamp, h.PreviousHash, h.MerkleRoot, int(h.Nonce), h.Difficulty)
+	if err != nil {
+		t.Fatalf("SerializeHeader error: %v", err)
+	}
+
+	if !bytes.Equal(canonical, legacy) {
+		t.Error("crypto/serialize produces a different layout than header.Serialize")
+	}
+}
+
+func TestActivationSchedule(t *testing.T) {
+	schedule := header.ActivationSchedule{
+		{Version: header.Version1, Height: 0},
+		{Version: 2, Height: 100},
+	}
+
+	if got := schedule.VersionAt(99); got != header.Version1 {
+		t.Errorf("VersionAt(99) = %d, want %d", got, header.Version1)
+	}
+	if got := schedule.VersionAt(100); got != 2 {
+		t.Errorf("VersionAt(100) = %d, want 2", got)
+	}
+
+	h := createTestHeader()
+	h.Index = 150
+	if err := schedule.Check(&h); err == nil {
+		t.Error("expected error for version 1 header after version 2 activation")
+	}
+
+	h.Index = 50
+	if err := schedule.Check(&h); err != nil {
+		t.Errorf("unexpected error before activation: %v", err)
+	}
+}
+
+func TestHeader_Version2Bits(t *testing.T) {
+	h := createTestHeader()
+	h.Version = header.Version2
+	h.Difficulty = 0
+	h.Bits = 0x1f00ffff
+
+	data, err := h.Serialize()
+	if err != nil {
+		t.Fatalf("Serialize error: %v", err)
+	}
+	if len(data) != header.HeaderSizeV2 {
+		t.Errorf("got %d bytes, want %d", len(data), header.HeaderSizeV2)
+	}
+
+	decoded, err := header.DeserializeHeader(bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("DeserializeHeader error: %v", err)
+	}
+	if decoded.Bits != h.Bits || decoded.Difficulty != 0 {
+		t.Errorf("got bits %08x difficulty %d", decoded.Bits, decoded.Difficulty)
+	}
+
+	target, err := decoded.Target()
+	if err != nil {
+		t.Fatalf("Target error: %v", err)
+	}
+	if target.Compact() != h.Bits {
+		t.Errorf("got target bits %08x, want %08x", target.Compact(), h.Bits)
+	}
+
+	// в заголовке версии 2 сложность в битах не попадает в хеш и должна быть нулевой
+	h.Difficulty = 4
+	if err := h.Validate("TEST"); err == nil {
+		t.Error("expected error for difficulty in version 2 header")
+	}
+
+	h.Difficulty = 0
+	h.Bits = 0x04923456 // отрицательная цель
+	if err := h.Validate("TEST"); err == nil {
+		t.Error("expected error for negative target")
+	}
+}
@@ -0,0 +1,160 @@
+package tests
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/Alex1997377/weave/internal/core/block"
+	"github.com/Alex1997377/weave/internal/core/block/tests/helpers"
+	"github.com/Alex1997377/weave/internal/core/transaction"
+	"github.com/Alex1997377/weave/internal/crypto/hash"
+)
+
+func TestBlock_JSONRoundTrip(t *testing.T) {
+	blk := helpers.CreateMinedBankBlock(t, 2)
+
+	data, err := json.Marshal(blk)
+	if err != nil {
+		t.Fatalf("Marshal error: %v", err)
+	}
+
+	if !strings.Contains(string(data), `"hash":"`+blk.Hash.String()+`"`) {
+		t.Errorf("hash is not encoded as hex: %s", data)
+	}
+	if !strings.Contains(string(data), `"type":"bank"`) {
+		t.Errorf("transaction type is missing: %s", data)
+	}
+
+	var decoded block.Block
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatalf("Unmarshal error: %v", err)
+	}
+
+	want, err := blk.Serialize()
+	if err != nil {
+		t.Fatalf("Serialize error: %v", err)
+	}
+	got, err := decoded.Serialize()
+	if err != nil {
+		t.Fatalf("Serialize of decoded block error: %v", err)
+	}
+	if !bytes.Equal(want, got) {
+		t.Error("JSON round trip changed the block")
+	}
+}
+
+func TestBlock_JSONRejectsTampering(t *testing.T) {
+	blk := helpers.CreateMinedBankBlock(t, 2)
+
+	tests := []struct {
+		name   string
+		mutate func(m map[string]interface{})
+		errMsg string
+	}{
+		{
+			name: "tampered transaction id",
+			mutate: func(m map[string]interface{}) {
+				tx := m["transactions"].([]interface{})[0].(map[string]interface{})
+				tx["id"] = strings.Repeat("ff", 32)
+			},
+			errMsg: "merkle root doesn`t match transactions",
+		},
+		{
+			name: "tampered nonce",
+			mutate: func(m map[string]interface{}) {
+				m["header"].(map[string]interface{})["nonce"] = 12345
+			},
+			errMsg: "block hash doesn`t match content",
+		},
+		{
+			name: "tampered size",
+			mutate: func(m map[string]interface{}) {
+				m["size"] = 1
+			},
+			errMsg: "block size mismatch",
+		},
+		{
+			name: "unknown field",
+			mutate: func(m map[string]interface{}) {
+				m["extra"] = true
+			},
+			errMsg: "unknown field",
+		},
+		{
+			name: "short hash",
+			mutate: func(m map[string]interface{}) {
+				m["hash"] = "abcd"
+			},
+			errMsg: "invalid hash length",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, err := json.Marshal(blk)
+			if err != nil {
+				t.Fatalf("Marshal error: %v", err)
+			}
+
+			var m map[string]interface{}
+			if err := json.Unmarshal(data, &m); err != nil {
+				t.Fatalf("Unmarshal into map error: %v", err)
+			}
+			tt.mutate(m)
+			data, _ = json.Marshal(m)
+
+			var decoded block.Block
+			err = json.Unmarshal(data, &decoded)
+			if err == nil {
+				t.Fatal("expected error, got nil")
+			}
+			if !strings.Contains(err.Error(), tt.errMsg) {
+				t.Errorf("error = %v, want contain %q", err, tt.errMsg)
+			}
+		})
+	}
+}
+
+func TestTransaction_JSON(t *testing.T) {
+	tx := helpers.CreateBankTransaction(5)
+
+	data, err := transaction.MarshalTransactionJSON(tx)
+	if err != nil {
+		t.Fatalf("MarshalTransactionJSON error: %v", err)
+	}
+
+	decoded, err := transaction.UnmarshalTransactionJSON(data)
+	if err != nil {
+		t.Fatalf("UnmarshalTransactionJSON error: %v", err)
+	}
+
+	if !bytes.Equal(decoded.TransactionGetSender(), tx.Sender) || decoded.TransactionGetAmount() != tx.Amount {
+		t.Errorf("decoded transaction %+v differs from %+v", decoded, tx)
+	}
+
+	if _, err := transaction.UnmarshalTransactionJSON([]byte(`{"type":"unknown"}`)); err == nil {
+		t.Error("expected error for unknown transaction type")
+	}
+}
+
+func TestHash_JSON(t *testing.T) {
+	h := hash.HashBytes([]byte("weave"))
+
+	data, err := json.Marshal(h)
+	if err != nil {
+		t.Fatalf("Marshal error: %v", err)
+	}
+	if string(data) != `"`+h.String()+`"` {
+		t.Errorf("got %s, want hex string", data)
+	}
+
+	var decoded hash.Hash
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatalf("Unmarshal error: %v", err)
+	}
+	if !hash.HashEquals(h, decoded) {
+		t.Error("hash JSON round trip failed")
+	}
+}
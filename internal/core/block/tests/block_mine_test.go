@@ -1,7 +1,11 @@
 package tests
 
 import (
+	"bytes"
 	"context"
+	"encoding/binary"
+	"errors"
+	"sync"
 	"testing"
 	"time"
 
@@ -9,6 +13,7 @@ import (
 	"github.com/Alex1997377/weave/internal/core/block/interfaces"
 	"github.com/Alex1997377/weave/internal/core/block/tests/helpers"
 	"github.com/Alex1997377/weave/internal/core/block/tests/mocks"
+	"github.com/Alex1997377/weave/internal/crypto/hash"
 )
 
 // Тест на ошибки (без изменений)
@@ -156,3 +161,151 @@ func (c *conditionalMockHasher) Hash(data []byte) interfaces.Hash {
 	valid := (nonce == c.targetNonce)
 	return mocks.MockHash{Valid: valid, BytesHash: make([]byte, 32)}
 }
+
+func TestBlock_Mine_Progress(t *testing.T) {
+	blk := helpers.CreateTestBlock(1, 10)
+
+	var (
+		mu     sync.Mutex
+		events []block.MineProgress
+	)
+	config := block.MineConfig{
+		NumWorkers:       3,
+		Timeout:          100 * time.Millisecond,
+		Hasher:           &mocks.MockHashCalculator{Valid: false},
+		ProgressInterval: 10 * time.Millisecond,
+		OnProgress: func(p block.MineProgress) {
+			mu.Lock()
+			events = append(events, p)
+			mu.Unlock()
+		},
+	}
+
+	err := blk.Mine(context.Background(), config)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected deadline exceeded, got %v", err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	if len(events) < 2 {
+		t.Fatalf("got %d progress events, want periodic reports and a final one", len(events))
+	}
+	for _, p := range events[:len(events)-1] {
+		if p.Done {
+			t.Error("intermediate report marked as done")
+		}
+	}
+
+	final := events[len(events)-1]
+	if !final.Done || final.Found || !errors.Is(final.Err, context.DeadlineExceeded) {
+		t.Errorf("unexpected final report: %+v", final)
+	}
+	if len(final.WorkerHashes) != 3 {
+		t.Fatalf("got %d worker counters, want 3", len(final.WorkerHashes))
+	}
+
+	var sum uint64
+	for i, n := range final.WorkerHashes {
+		if n == 0 {
+			t.Errorf("worker %d reported no hashes", i)
+		}
+		sum += n
+	}
+	if sum != final.Hashes {
+		t.Errorf("total hashes %d != sum of worker hashes %d", final.Hashes, sum)
+	}
+	if final.Hashrate <= 0 || final.ETA <= 0 {
+		t.Errorf("hashrate %f and ETA %v must be positive", final.Hashrate, final.ETA)
+	}
+	if final.ExpectedHashes != 1024 {
+		t.Errorf("got expected hashes %f, want 1024 for difficulty 10", final.ExpectedHashes)
+	}
+}
+
+func TestBlock_Mine_ProgressResult(t *testing.T) {
+	blk := helpers.CreateTestBlock(1, 10)
+
+	var final block.MineProgress
+	calls := 0
+	config := block.MineConfig{
+		NumWorkers: 2,
+		Hasher:     &mocks.MockHashCalculator{Valid: true},
+		OnProgress: func(p block.MineProgress) {
+			calls++
+			final = p
+		},
+	}
+
+	if err := blk.Mine(context.Background(), config); err != nil {
+		t.Fatalf("Mine failed: %v", err)
+	}
+
+	if calls != 1 {
+		t.Errorf("got %d reports, want only the final one", calls)
+	}
+	if !final.Done || !final.Found || final.Err != nil {
+		t.Fatalf("unexpected final report: %+v", final)
+	}
+	if final.Nonce != blk.Header.Nonce || !bytes.Equal(final.Hash, blk.Hash) {
+		t.Error("final report doesn`t match mined block")
+	}
+	if final.Hashes == 0 {
+		t.Error("final report must count the winning hash")
+	}
+}
+
+func TestMidstateHasher_MatchesFullHash(t *testing.T) {
+	blk := helpers.CreateTestBlock(1, 8)
+	base, nonceOffset, err := blk.Header.SerializeWithoutNonce()
+	if err != nil {
+		t.Fatalf("SerializeWithoutNonce error: %v", err)
+	}
+
+	var calc interfaces.HashCalculator = hash.HashCalculatorImpl{}
+	mc, ok := calc.(interfaces.MidstateHashCalculator)
+	if !ok {
+		t.Fatal("HashCalculatorImpl must support midstate")
+	}
+	ms, err := mc.Midstate(base[:nonceOffset])
+	if err != nil {
+		t.Fatalf("Midstate error: %v", err)
+	}
+
+	for _, nonce := range []uint64{0, 1, 255, 1 << 40, ^uint64(0)} {
+		binary.LittleEndian.PutUint64(base[nonceOffset:], nonce)
+		want := calc.Hash(base).Bytes()
+		got := ms.HashSuffix(base[nonceOffset:]).Bytes()
+		if !bytes.Equal(got, want) {
+			t.Errorf("nonce %d: midstate hash %x, want %x", nonce, got, want)
+		}
+	}
+}
+
+// fullHasher скрывает midstate-расширение HashCalculatorImpl
+type fullHasher struct{}
+
+func (fullHasher) Hash(data []byte) interfaces.Hash {
+	return hash.HashBytes(data)
+}
+
+func TestBlock_Mine_MidstateSameResult(t *testing.T) {
+	first := helpers.CreateTestBlock(1, 10)
+	second := helpers.CreateTestBlock(1, 10)
+	second.Header.Timestamp = first.Header.Timestamp
+
+	if err := first.Mine(context.Background(), block.MineConfig{NumWorkers: 1, Hasher: hash.HashCalculatorImpl{}}); err != nil {
+		t.Fatalf("Mine with midstate failed: %v", err)
+	}
+	if err := second.Mine(context.Background(), block.MineConfig{NumWorkers: 1, Hasher: fullHasher{}}); err != nil {
+		t.Fatalf("Mine without midstate failed: %v", err)
+	}
+
+	if first.Header.Nonce != second.Header.Nonce || !bytes.Equal(first.Hash, second.Hash) {
+		t.Errorf("midstate mined nonce %d, full hashing mined %d", first.Header.Nonce, second.Header.Nonce)
+	}
+	if err := first.Validate(); err != nil {
+		t.Errorf("block mined with midstate is invalid: %v", err)
+	}
+}
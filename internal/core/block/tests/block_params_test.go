@@ -0,0 +1,227 @@
+package tests
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/Alex1997377/weave/internal/core/block"
+	"github.com/Alex1997377/weave/internal/core/block/tests/helpers"
+	"github.com/Alex1997377/weave/internal/core/consensus"
+	"github.com/Alex1997377/weave/internal/core/header"
+	"github.com/Alex1997377/weave/internal/core/transaction"
+	"github.com/Alex1997377/weave/internal/crypto/hash"
+	"github.com/Alex1997377/weave/internal/crypto/pow"
+	"github.com/Alex1997377/weave/internal/crypto/powalgo"
+)
+
+func TestNewGenesisBlock_Profiles(t *testing.T) {
+	for _, name := range []string{consensus.NameMainnet, consensus.NameTestnet, consensus.NameRegtest} {
+		t.Run(name, func(t *testing.T) {
+			p, err := consensus.ByName(name)
+			if err != nil {
+				t.Fatalf("ByName error: %v", err)
+			}
+			if err := p.Validate(); err != nil {
+				t.Fatalf("profile is invalid: %v", err)
+			}
+
+			first, err := block.NewGenesisBlock(p)
+			if err != nil {
+				t.Fatalf("NewGenesisBlock error: %v", err)
+			}
+			second, err := block.NewGenesisBlock(p)
+			if err != nil {
+				t.Fatalf("NewGenesisBlock error: %v", err)
+			}
+
+			if !bytes.Equal(first.Hash, second.Hash) {
+				t.Error("genesis block is not deterministic")
+			}
+			if first.Hash.String() != p.Genesis.Hash {
+				t.Errorf("got genesis hash %s, want %s", first.Hash.String(), p.Genesis.Hash)
+			}
+		})
+	}
+
+	p := consensus.Regtest()
+	p.Genesis.Nonce++
+	if _, err := block.NewGenesisBlock(p); err == nil || !strings.Contains(err.Error(), "genesis hash mismatch") {
+		t.Errorf("expected genesis hash mismatch, got %v", err)
+	}
+}
+
+func TestValidateWithParams(t *testing.T) {
+	tests := []struct {
+		name   string
+		mutate func(p *consensus.Params)
+		errMsg string
+	}{
+		{
+			name:   "valid",
+			mutate: func(p *consensus.Params) {},
+		},
+		{
+			name:   "difficulty below minimum",
+			mutate: func(p *consensus.Params) { p.MinDifficulty = 8 },
+			errMsg: "invalid block difficulty",
+		},
+		{
+			name:   "too many transactions",
+			mutate: func(p *consensus.Params) { p.MaxTransactions = 1 },
+			errMsg: "transaction count too high: 2 (max: 1)",
+		},
+		{
+			name:   "block too large",
+			mutate: func(p *consensus.Params) { p.MaxBlockSize = 64 },
+			errMsg: "exceeds limit of 64 bytes",
+		},
+		{
+			name:   "signature too large",
+			mutate: func(p *consensus.Params) { p.MaxSignatureSize = 16 },
+			errMsg: "signature too large",
+		},
+	}
+
+	transactions := []transaction.Transaction{helpers.CreateBankTransaction(1), helpers.CreateBankTransaction(2)}
+	work := consensus.WorkRequirement{Version: header.Version2, Bits: pow.DifficultyToTarget(1).Compact()}
+	blk, err := block.NewBlockWithParams(t.Context(), consensus.Regtest(), transactions, bytes.Repeat([]byte{0xAA}, 32), 1, work)
+	if err != nil {
+		t.Fatalf("NewBlockWithParams error: %v", err)
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := consensus.Regtest()
+			tt.mutate(p)
+
+			err := blk.ValidateWithParams(p)
+			if tt.errMsg == "" {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.errMsg) {
+				t.Errorf("error = %v, want contain %q", err, tt.errMsg)
+			}
+		})
+	}
+}
+
+func TestDeserializeBlockWithLimits(t *testing.T) {
+	data, err := helpers.CreateMinedBankBlock(t, 3).Serialize()
+	if err != nil {
+		t.Fatalf("Serialize error: %v", err)
+	}
+
+	p := consensus.Regtest()
+	if _, err := block.DeserializeBlockWithLimits(data, block.LimitsFor(p)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	p.MaxBlockSize = 128
+	if _, err := block.DeserializeBlockWithLimits(data, block.LimitsFor(p)); !errors.Is(err, block.ErrBlockTooLarge) {
+		t.Errorf("expected ErrBlockTooLarge, got %v", err)
+	}
+
+	p = consensus.Regtest()
+	p.MaxTransactions = 2
+	if _, err := block.DeserializeBlockWithLimits(data, block.LimitsFor(p)); err == nil {
+		t.Error("expected error for too many transactions")
+	}
+}
+
+func TestParams_BlockReward(t *testing.T) {
+	p := consensus.Regtest()
+
+	tests := []struct {
+		height int
+		want   float64
+	}{
+		{0, 50},
+		{p.HalvingInterval - 1, 50},
+		{p.HalvingInterval, 25},
+		{3 * p.HalvingInterval, 6.25},
+		{64 * p.HalvingInterval, 0},
+		{-1, 0},
+	}
+
+	for _, tt := range tests {
+		if got := p.BlockReward(tt.height); got != tt.want {
+			t.Errorf("BlockReward(%d) = %v, want %v", tt.height, got, tt.want)
+		}
+	}
+}
+
+func TestMineGenesis_PowAlgorithm(t *testing.T) {
+	for _, name := range []string{powalgo.NameDoubleSHA256, powalgo.NameMemoryHard} {
+		t.Run(name, func(t *testing.T) {
+			p := consensus.Regtest()
+			p.PowAlgorithm = name
+			p.Genesis.Difficulty = 6
+			p.Genesis.Hash = ""
+
+			mined, err := block.MineGenesis(context.Background(), p)
+			if err != nil {
+				t.Fatalf("MineGenesis error: %v", err)
+			}
+
+			p.Genesis.Nonce = mined.Header.Nonce
+			p.Genesis.Hash = mined.Hash.String()
+			genesis, err := block.NewGenesisBlock(p)
+			if err != nil {
+				t.Fatalf("NewGenesisBlock error: %v", err)
+			}
+			if !bytes.Equal(genesis.Hash, mined.Hash) {
+				t.Error("rebuilt genesis differs from mined one")
+			}
+
+			// идентификатор блока остаётся SHA-256 заголовка
+			data, _ := genesis.Header.Serialize()
+			if !bytes.Equal(genesis.Hash, hash.HashBytes(data)) {
+				t.Error("block hash must stay sha256 of the header")
+			}
+
+			// та же сеть на SHA-256 не принимает блок, добытый другим алгоритмом
+			sha := consensus.Regtest()
+			sha.Genesis = p.Genesis
+			if err := genesis.ValidateWithParams(sha); err == nil || !strings.Contains(err.Error(), "invalid proof of work") {
+				t.Errorf("expected proof-of-work error under sha256, got %v", err)
+			}
+
+			next, err := block.NewBlockWithParams(t.Context(), p, []transaction.Transaction{helpers.CreateBankTransaction(1)},
+				genesis.Hash, 1, consensus.WorkRequirement{Version: header.Version2, Bits: pow.DifficultyToTarget(6).Compact()})
+			if err != nil {
+				t.Fatalf("NewBlockWithParams error: %v", err)
+			}
+			algo, _ := p.Algorithm()
+			powHash, err := next.PowHash(algo)
+			if err != nil {
+				t.Fatalf("PowHash error: %v", err)
+			}
+			if !powHash.MeetsTarget(pow.DifficultyToTarget(6)) {
+				t.Error("mined block doesn`t meet target under its algorithm")
+			}
+		})
+	}
+}
+
+func TestParams_UnknownPowAlgorithm(t *testing.T) {
+	p := consensus.Regtest()
+	p.PowAlgorithm = "scrypt"
+
+	if err := p.Validate(); !errors.Is(err, powalgo.ErrUnknownAlgorithm) {
+		t.Errorf("expected ErrUnknownAlgorithm, got %v", err)
+	}
+
+	genesis, err := block.NewGenesisBlock(consensus.Regtest())
+	if err != nil {
+		t.Fatalf("NewGenesisBlock error: %v", err)
+	}
+	if err := genesis.ValidateWithParams(p); !errors.Is(err, powalgo.ErrUnknownAlgorithm) {
+		t.Errorf("expected ErrUnknownAlgorithm, got %v", err)
+	}
+}
@@ -0,0 +1,151 @@
+package tests
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"testing/iotest"
+
+	"github.com/Alex1997377/weave/internal/core/block"
+	"github.com/Alex1997377/weave/internal/core/block/tests/helpers"
+)
+
+func TestEncodeBlock_MatchesSerialize(t *testing.T) {
+	blk := helpers.CreateMinedBankBlock(t, 2)
+
+	data, err := blk.Serialize()
+	if err != nil {
+		t.Fatalf("Serialize error: %v", err)
+	}
+
+	var buf bytes.Buffer
+	if err := block.EncodeBlock(&buf, blk); err != nil {
+		t.Fatalf("EncodeBlock error: %v", err)
+	}
+
+	if !bytes.Equal(data, buf.Bytes()) {
+		t.Error("EncodeBlock output differs from Serialize")
+	}
+}
+
+func TestDecodeBlock_ConsecutiveBlocks(t *testing.T) {
+	first := helpers.CreateMinedBankBlock(t, 1)
+	second := helpers.CreateMinedBankBlock(t, 3)
+
+	var stream bytes.Buffer
+	for _, blk := range []*block.Block{first, second} {
+		if err := block.EncodeBlock(&stream, blk); err != nil {
+			t.Fatalf("EncodeBlock error: %v", err)
+		}
+	}
+
+	// чтение по одному байту проверяет, что декодер не забирает байты следующего блока
+	r := iotest.OneByteReader(&stream)
+	for i, want := range []*block.Block{first, second} {
+		got, err := block.DecodeBlock(r)
+		if err != nil {
+			t.Fatalf("DecodeBlock %d error: %v", i, err)
+		}
+		if !bytes.Equal(got.Hash, want.Hash) || len(got.Transaction) != len(want.Transaction) {
+			t.Errorf("block %d decoded incorrectly", i)
+		}
+		if got.Size != want.Size {
+			t.Errorf("block %d: got size %d, want %d", i, got.Size, want.Size)
+		}
+	}
+
+	if _, err := block.DecodeBlock(r); !errors.Is(err, io.EOF) {
+		t.Errorf("expected io.EOF after last block, got %v", err)
+	}
+}
+
+func TestDecodeBlock_Limits(t *testing.T) {
+	blk := helpers.CreateMinedBankBlock(t, 3)
+	data, err := blk.Serialize()
+	if err != nil {
+		t.Fatalf("Serialize error: %v", err)
+	}
+
+	tests := []struct {
+		name   string
+		limits block.Limits
+		errMsg string
+	}{
+		{
+			name:   "block too large",
+			limits: block.Limits{MaxBlockSize: 128, MaxTransactions: 10, MaxTransactionSize: 1024},
+			errMsg: block.ErrBlockTooLarge.Error(),
+		},
+		{
+			name:   "too many transactions",
+			limits: block.Limits{MaxBlockSize: 1 << 20, MaxTransactions: 2, MaxTransactionSize: 1024},
+			errMsg: "transaction count too high: 3 (max: 2)",
+		},
+		{
+			name:   "transaction too large",
+			limits: block.Limits{MaxBlockSize: 1 << 20, MaxTransactions: 10, MaxTransactionSize: 16},
+			errMsg: "transaction too large at tx 0",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := block.DecodeBlockWithLimits(bytes.NewReader(data), tt.limits)
+			if err == nil {
+				t.Fatal("expected error, got nil")
+			}
+			if !strings.Contains(err.Error(), tt.errMsg) {
+				t.Errorf("error = %v, want contain %q", err, tt.errMsg)
+			}
+		})
+	}
+}
+
+func TestDecodeBlock_PartialLimits(t *testing.T) {
+	blk := helpers.CreateMinedBankBlock(t, 3)
+	data, err := blk.Serialize()
+	if err != nil {
+		t.Fatalf("Serialize error: %v", err)
+	}
+
+	// незаданные поля берутся из DefaultLimits, а не запрещают всё
+	limits := block.Limits{MaxBlockSize: 1 << 20}
+	if _, err := block.DecodeBlockWithLimits(bytes.NewReader(data), limits); err != nil {
+		t.Errorf("DecodeBlockWithLimits error: %v", err)
+	}
+	if _, err := block.DeserializeBlockWithLimits(data, limits); err != nil {
+		t.Errorf("DeserializeBlockWithLimits error: %v", err)
+	}
+
+	limits = block.Limits{MaxTransactions: 2}
+	if _, err := block.DeserializeBlockWithLimits(data, limits); err == nil || !strings.Contains(err.Error(), "transaction count too high") {
+		t.Errorf("expected transaction count error, got %v", err)
+	}
+}
+
+func TestDecodeBlock_Truncated(t *testing.T) {
+	data, err := helpers.CreateMinedBankBlock(t, 1).Serialize()
+	if err != nil {
+		t.Fatalf("Serialize error: %v", err)
+	}
+
+	_, err = block.DecodeBlock(bytes.NewReader(data[:len(data)-3]))
+	if !errors.Is(err, io.ErrUnexpectedEOF) {
+		t.Errorf("expected io.ErrUnexpectedEOF, got %v", err)
+	}
+}
+
+func TestDecodeBlock_ChecksumMismatch(t *testing.T) {
+	data, err := helpers.CreateMinedBankBlock(t, 1).Serialize()
+	if err != nil {
+		t.Fatalf("Serialize error: %v", err)
+	}
+	data[len(data)-1] ^= 0xFF
+
+	_, err = block.DecodeBlock(bytes.NewReader(data))
+	if err == nil || !strings.HasPrefix(err.Error(), "block checksum mismatch") {
+		t.Errorf("expected checksum mismatch, got %v", err)
+	}
+}
@@ -0,0 +1,117 @@
+package tests
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Alex1997377/weave/internal/clock"
+	"github.com/Alex1997377/weave/internal/core/block"
+	"github.com/Alex1997377/weave/internal/core/block/tests/helpers"
+	"github.com/Alex1997377/weave/internal/core/consensus"
+	"github.com/Alex1997377/weave/internal/core/header"
+	"github.com/Alex1997377/weave/internal/core/transaction"
+	"github.com/Alex1997377/weave/internal/crypto/pow"
+)
+
+var fixtureTime = time.Unix(1735689600, 0)
+
+func templateTransactions() []transaction.Transaction {
+	return []transaction.Transaction{
+		transaction.NewCoinbase(1, bytes.Repeat([]byte{0x42}, 32), 50),
+		&transaction.BankTransaction{
+			ID:        bytes.Repeat([]byte{0x07}, 32),
+			Sender:    bytes.Repeat([]byte{0x01}, 32),
+			Recipient: bytes.Repeat([]byte{0x02}, 32),
+			Amount:    1,
+		},
+	}
+}
+
+func TestNewBlockTemplate(t *testing.T) {
+	p := consensus.Regtest()
+	work := consensus.WorkRequirement{Version: header.Version1, Difficulty: 4}
+	prevHash := bytes.Repeat([]byte{0xAA}, 32)
+
+	tpl, err := block.NewBlockTemplate(p, templateTransactions(), prevHash, 1, work, clock.NewManual(fixtureTime))
+	if err != nil {
+		t.Fatalf("NewBlockTemplate error: %v", err)
+	}
+
+	if tpl.Hash != nil || tpl.Header.Nonce != 0 {
+		t.Errorf("template must not be mined: nonce=%d hash=%x", tpl.Header.Nonce, tpl.Hash)
+	}
+	if tpl.Header.Timestamp != fixtureTime.Unix() {
+		t.Errorf("timestamp = %d, want clock time %d", tpl.Header.Timestamp, fixtureTime.Unix())
+	}
+	if tpl.Header.Difficulty != 4 || !bytes.Equal(tpl.Header.MerkleRoot, tpl.CalculateMerkleRoot()) {
+		t.Errorf("unexpected template header: %+v", tpl.Header)
+	}
+
+	errCases := []struct {
+		name     string
+		txs      []transaction.Transaction
+		prevHash []byte
+		index    int
+	}{
+		{"nil previous hash", templateTransactions(), nil, 1},
+		{"negative index", templateTransactions(), prevHash, -1},
+		{"no transactions", nil, prevHash, 1},
+		{"nil transaction", []transaction.Transaction{nil}, prevHash, 1},
+	}
+	for _, tc := range errCases {
+		if _, err := block.NewBlockTemplate(p, tc.txs, tc.prevHash, tc.index, work, nil); err == nil {
+			t.Errorf("%s: expected error", tc.name)
+		}
+	}
+}
+
+// Одинаковые часы и входные данные дают побайтно одинаковые добытые блоки
+func TestNewBlockTemplate_Deterministic(t *testing.T) {
+	p := consensus.Regtest()
+	work := consensus.WorkRequirement{Version: header.Version2, Bits: pow.DifficultyToTarget(10).Compact()}
+	prevHash := bytes.Repeat([]byte{0xAA}, 32)
+
+	mine := func() []byte {
+		clk := clock.NewManual(fixtureTime)
+		tpl, err := block.NewBlockTemplate(p, templateTransactions(), prevHash, 1, work, clk)
+		if err != nil {
+			t.Fatalf("NewBlockTemplate error: %v", err)
+		}
+		if err := tpl.Mine(context.Background(), block.MineConfig{NumWorkers: 1, Clock: clk}); err != nil {
+			t.Fatalf("Mine error: %v", err)
+		}
+		if err := tpl.ValidateWithParams(p); err != nil {
+			t.Fatalf("mined template is invalid: %v", err)
+		}
+		data, err := tpl.Serialize()
+		if err != nil {
+			t.Fatalf("Serialize error: %v", err)
+		}
+		return data
+	}
+
+	if first, second := mine(), mine(); !bytes.Equal(first, second) {
+		t.Error("blocks built from the same clock and inputs differ")
+	}
+}
+
+func TestBlock_Mine_RollsTimestampFromClock(t *testing.T) {
+	blk := helpers.CreateTestBlock(1, 8)
+	initial, _, _ := blk.Header.SerializeWithoutNonce()
+	rolled := time.Unix(blk.Header.Timestamp+3600, 0)
+
+	err := blk.Mine(context.Background(), block.MineConfig{
+		NumWorkers: 1,
+		NonceRange: 16,
+		Hasher:     &headerChangedHasher{initial: initial[:len(initial)-8]},
+		Clock:      clock.NewManual(rolled),
+	})
+	if err != nil {
+		t.Fatalf("Mine failed: %v", err)
+	}
+	if blk.Header.Timestamp != rolled.Unix() {
+		t.Errorf("timestamp = %d, want clock time %d", blk.Header.Timestamp, rolled.Unix())
+	}
+}
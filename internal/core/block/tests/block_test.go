@@ -2,6 +2,8 @@ package tests
 
 import (
 	"bytes"
+	"context"
+	"errors"
 	"strings"
 	"testing"
 
@@ -93,7 +95,7 @@ func TestNewBlock(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			got, err := block.NewBlock(tt.transactions, tt.prevHash, tt.index, tt.difficulty)
+			got, err := block.NewBlock(t.Context(), tt.transactions, tt.prevHash, tt.index, tt.difficulty)
 			if (err != nil) != tt.wantErr {
 				t.Errorf("NewBlock() error = %v, wantErr %v", err, tt.wantErr)
 				return
@@ -107,3 +109,14 @@ func TestNewBlock(t *testing.T) {
 		})
 	}
 }
+
+func TestNewBlock_Canceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(t.Context())
+	cancel()
+
+	// цель недостижима, поэтому майнинг заканчивается только отменой
+	_, err := block.NewBlock(ctx, []transaction.Transaction{helpers.CreateTestTransaction(1)}, bytes.Repeat([]byte{0xAA}, 32), 1, 64)
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("NewBlock() error = %v, want context.Canceled", err)
+	}
+}
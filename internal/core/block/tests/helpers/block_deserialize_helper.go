@@ -3,8 +3,11 @@ package helpers
 import (
 	"bytes"
 	"encoding/binary"
+	"hash/crc32"
+	"io"
 	"testing"
 
+	"github.com/Alex1997377/weave/internal/core/block"
 	"github.com/Alex1997377/weave/internal/core/transaction"
 )
 
@@ -47,26 +50,94 @@ func (tt *TestTransaction) TransactionValidate() error      { return nil }
 func (tt *TestTransaction) TransactionSign([]byte) error    { return nil }
 func (tt *TestTransaction) TransactionVerify([]byte) bool   { return true }
 
+// CreateValidBlockData собирает конверт блока с 32-байтовым фиктивным заголовком,
+// переданными транзакциями и хешем 0xCC
 func CreateValidBlockData(t *testing.T, txCount uint32, transactions []transaction.Transaction) *bytes.Buffer {
 	t.Helper()
-	buf := &bytes.Buffer{}
-	buf.Write(bytes.Repeat([]byte{0xAA}, 32))
-	t.Logf("After header: %d bytes", buf.Len())
-	binary.Write(buf, binary.LittleEndian, txCount)
-	t.Logf("After txCount: %d bytes", buf.Len())
+	records := make([][]byte, 0, len(transactions))
 	for i, tx := range transactions {
-		txData, err := tx.TransactionSerialize()
+		txData, err := transaction.EncodeTransaction(tx)
 		if err != nil {
 			t.Fatalf("failed to serialize transaction %d: %v", i, err)
 		}
-		buf.Write(txData)
-		t.Logf("After tx %d: %d bytes", i, buf.Len())
+		records = append(records, txData)
+	}
+	return bytes.NewBuffer(BuildEnvelope(bytes.Repeat([]byte{0xAA}, 32), txCount, records, bytes.Repeat([]byte{0xCC}, 32)))
+}
+
+// BuildEnvelope собирает конверт блока из готовых частей, вычисляя размер и контрольную сумму
+func BuildEnvelope(headerData []byte, txCount uint32, records [][]byte, hash []byte) []byte {
+	buf := &bytes.Buffer{}
+	buf.Write(block.EnvelopeMagic[:])
+	buf.WriteByte(block.EnvelopeVersion)
+	binary.Write(buf, binary.LittleEndian, uint32(len(headerData)))
+	buf.Write(headerData)
+	binary.Write(buf, binary.LittleEndian, txCount)
+	for _, record := range records {
+		binary.Write(buf, binary.LittleEndian, uint32(len(record)))
+		buf.Write(record)
 	}
-	buf.Write(bytes.Repeat([]byte{0xCC}, 32))
-	t.Logf("After hash: %d bytes", buf.Len())
-	if err := binary.Write(buf, binary.LittleEndian, uint32(12345)); err != nil {
-		t.Fatalf("failed to write block size: %v", err)
+	buf.Write(hash)
+	binary.Write(buf, binary.LittleEndian, uint32(buf.Len()+8))
+	binary.Write(buf, binary.LittleEndian, crc32.ChecksumIEEE(buf.Bytes()))
+	return buf.Bytes()
+}
+
+// ReadTestTransaction читает запись, созданную EncodeTransaction для TestTransaction
+func ReadTestTransaction(r io.Reader) (*TestTransaction, error) {
+	if _, err := io.ReadFull(r, make([]byte, 1)); err != nil {
+		return nil, err
+	}
+
+	sender := make([]byte, 32)
+	r.Read(sender)
+
+	recipient := make([]byte, 32)
+	r.Read(recipient)
+
+	id := make([]byte, 32)
+	r.Read(id)
+
+	var amount float64
+	binary.Read(r, binary.LittleEndian, &amount)
+
+	var sigLen uint32
+	binary.Read(r, binary.LittleEndian, &sigLen)
+
+	signature := make([]byte, sigLen)
+	r.Read(signature)
+
+	return &TestTransaction{
+		Id:        id,
+		Sender:    sender,
+		Recipient: recipient,
+		Amount:    amount,
+		Signature: signature,
+	}, nil
+}
+
+// CreateBankTransaction создаёт корректную банковскую транзакцию с детерминированными полями
+func CreateBankTransaction(id byte) *transaction.BankTransaction {
+	return &transaction.BankTransaction{
+		ID:        bytes.Repeat([]byte{id}, 32),
+		Sender:    bytes.Repeat([]byte{0xA0 + id}, 32),
+		Recipient: bytes.Repeat([]byte{0xB0 + id}, 32),
+		Amount:    float64(id) * 10,
+		Signature: bytes.Repeat([]byte{0xC0 + id}, 64),
+	}
+}
+
+// CreateMinedBankBlock создаёт блок с txCount банковскими транзакциями через block.NewBlock
+func CreateMinedBankBlock(t testing.TB, txCount int) *block.Block {
+	t.Helper()
+	transactions := make([]transaction.Transaction, txCount)
+	for i := range transactions {
+		transactions[i] = CreateBankTransaction(byte(i + 1))
+	}
+
+	blk, err := block.NewBlock(t.Context(), transactions, bytes.Repeat([]byte{0xAA}, 32), 1, 0)
+	if err != nil {
+		t.Fatalf("failed to create block: %v", err)
 	}
-	t.Logf("After size: %d bytes", buf.Len())
-	return buf
+	return blk
 }
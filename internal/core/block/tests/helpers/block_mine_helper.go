@@ -8,6 +8,7 @@ import (
 func CreateTestBlock(index, difficulty int) *block.Block {
 	return &block.Block{
 		Header: header.Header{
+			Version:      header.Version1,
 			Index:        index,
 			Difficulty:   difficulty,
 			PreviousHash: make([]byte, 32),
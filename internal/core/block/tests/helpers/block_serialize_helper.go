@@ -52,6 +52,7 @@ func CreateTestBlockForSerialize() *block.Block {
 
 	return &block.Block{
 		Header: header.Header{
+			Version:      header.Version1,
 			Index:        1,
 			Timestamp:    1234567890,
 			PreviousHash: bytes.Repeat([]byte{0xAA}, 32),
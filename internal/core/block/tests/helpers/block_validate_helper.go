@@ -38,6 +38,7 @@ func (tt *TestTransactionWithValidate) TransactionSerialize() ([]byte, error) {
 func CreateValidBlockForValidate() *block.Block {
 	// Подготавливаем заголовок
 	h := header.Header{
+		Version:      header.Version1,
 		Index:        1,
 		Timestamp:    1234567890,
 		PreviousHash: bytes.Repeat([]byte{0xAA}, 32),
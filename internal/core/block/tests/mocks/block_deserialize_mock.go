@@ -2,6 +2,7 @@ package mocks
 
 import (
 	"bytes"
+	"io"
 	"sync"
 	"testing"
 
@@ -10,15 +11,16 @@ import (
 )
 
 type MockHeaderDeserializer struct {
-	MockFunc func(r *bytes.Reader) (*header.Header, error)
+	MockFunc func(r io.Reader) (*header.Header, error)
 }
 
-func (m MockHeaderDeserializer) DeserializeHeader(r *bytes.Reader) (*header.Header, error) {
+func (m MockHeaderDeserializer) DeserializeHeader(r io.Reader) (*header.Header, error) {
 	if m.MockFunc != nil {
 		return m.MockFunc(r)
 	}
-	r.Seek(32, 0)
+	io.ReadFull(r, make([]byte, 32))
 	return &header.Header{
+		Version:      header.Version1,
 		Index:        0,
 		Timestamp:    1234567890,
 		PreviousHash: bytes.Repeat([]byte{0xAA}, 32),
@@ -30,12 +32,12 @@ func (m MockHeaderDeserializer) DeserializeHeader(r *bytes.Reader) (*header.Head
 
 type MockTransactionDeserializer struct {
 	mu           sync.Mutex
-	MockFunc     func(r *bytes.Reader) (transaction.Transaction, error)
+	MockFunc     func(r io.Reader) (transaction.Transaction, error)
 	CallCount    int
 	Transactions []transaction.Transaction
 }
 
-func (m *MockTransactionDeserializer) DeserializeTransaction(r *bytes.Reader) (transaction.Transaction, error) {
+func (m *MockTransactionDeserializer) DeserializeTransaction(r io.Reader) (transaction.Transaction, error) {
 	m.mu.Lock()
 	m.CallCount++
 	callCount := m.CallCount
@@ -2,6 +2,7 @@ package mocks
 
 import (
 	"github.com/Alex1997377/weave/internal/core/block/interfaces"
+	"github.com/Alex1997377/weave/internal/crypto/pow"
 )
 
 // MockHash реализует interfaces.Hash
@@ -14,6 +15,10 @@ func (m MockHash) IsValidForDifficulty(difficulty int) bool {
 	return m.Valid
 }
 
+func (m MockHash) MeetsTarget(target pow.Target) bool {
+	return m.Valid
+}
+
 // Bytes возвращает байтовое представление хеша
 func (m MockHash) Bytes() []byte { // ← исправлено: было BytesHash
 	return m.BytesHash
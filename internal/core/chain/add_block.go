@@ -1,6 +1,8 @@
 package chain
 
 import (
+	"bytes"
+	"context"
 	"errors"
 	"fmt"
 
@@ -8,12 +10,10 @@ import (
 	"github.com/Alex1997377/weave/internal/core/transaction"
 )
 
-// AddBlock добавляет новый блок в цепочку
-func (bc *Blockchain) AddBlock(transactions []transaction.Transaction) error {
-	if len(bc.Blocks) == 0 {
-		return errors.New("cannot add block to empty blockchain")
-	}
-
+// AddBlock добавляет новый блок в цепочку. Майнинг идёт без блокировки;
+// если за это время вершина сменилась, блок отклоняется как устаревший.
+// Отмена ctx прерывает майнинг.
+func (bc *Blockchain) AddBlock(ctx context.Context, transactions []transaction.Transaction) error {
 	// Валидация транзакций
 	for i, tx := range transactions {
 		if tx == nil {
@@ -24,32 +24,50 @@ func (bc *Blockchain) AddBlock(transactions []transaction.Transaction) error {
 		}
 	}
 
-	prevBlock := bc.Blocks[len(bc.Blocks)-1]
-	newBlock, err := block.NewBlock(transactions, prevBlock.Hash, prevBlock.Header.Index+1, DIFFICULTY)
+	algo, err := bc.params.Algorithm()
 	if err != nil {
-		return fmt.Errorf("failed to create new block: %w", err)
+		return fmt.Errorf("invalid consensus params: %w", err)
 	}
 
-	// Проверка размера блока
-	size, err := newBlock.CalculateSize()
+	newBlock, err := bc.newBlock(ctx, transactions)
 	if err != nil {
-		return fmt.Errorf("failed to calculate block size: %w", err)
+		return err
 	}
 
-	if size > 1024*1024 {
-		return fmt.Errorf("block size %d exceeds limit of 1MB", size)
+	if err := newBlock.Mine(ctx, block.MineConfig{Algorithm: algo, Clock: bc.clock}); err != nil {
+		return fmt.Errorf("failed to mine new block: %w", err)
 	}
 
-	if err := newBlock.Validate(); err != nil {
-		return fmt.Errorf("new block validation failed: %w", err)
+	bc.mu.Lock()
+	defer bc.mu.Unlock()
+
+	if !bytes.Equal(newBlock.Header.PreviousHash, bc.tip) {
+		return NewStaleBlockError(fmt.Sprintf("tip moved from %x while mining block %d", newBlock.Header.PreviousHash, newBlock.Header.Index), nil)
 	}
+	_, err = bc.acceptBlock(ctx, newBlock)
+	return err
+}
+
+// newBlock собирает шаблон блока на текущей вершине
+func (bc *Blockchain) newBlock(ctx context.Context, transactions []transaction.Transaction) (*block.Block, error) {
+	bc.mu.RLock()
+	defer bc.mu.RUnlock()
 
-	// Сохраняем в хранилище
-	if err := bc.store.SaveBlock(newBlock); err != nil {
-		return fmt.Errorf("failed to save block to store: %w", err)
+	if bc.tipNode == nil {
+		return nil, errors.New("cannot add block to empty blockchain")
 	}
 
-	bc.Blocks = append(bc.Blocks, newBlock)
-	bc.Tip = newBlock.Hash
-	return nil
+	work, err := bc.nextWorkRequired(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to calculate difficulty: %w", err)
+	}
+
+	newBlock, err := block.NewBlockTemplate(bc.params, transactions, bc.tipHash(), bc.height()+1, work, bc.clock)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create new block: %w", err)
+	}
+	if err := bc.applyMinTimestamp(ctx, newBlock); err != nil {
+		return nil, err
+	}
+	return newBlock, nil
 }
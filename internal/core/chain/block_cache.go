@@ -0,0 +1,72 @@
+package chain
+
+import (
+	"container/list"
+	"sync"
+
+	"github.com/Alex1997377/weave/internal/core/block"
+)
+
+// DefaultBlockCacheSize - число тел блоков, которые цепочка держит в памяти
+const DefaultBlockCacheSize = 256
+
+// blockCache - LRU-кеш тел блоков по хешу. Читатели цепочки обращаются к
+// нему одновременно под RLock, а get меняет порядок, поэтому у кеша свой мьютекс.
+// Кеш хранит и выдаёт копии (block.Block.Clone): изменение блока, полученного
+// из цепочки или переданного в неё, не портит то, что видят другие читатели.
+type blockCache struct {
+	mu    sync.Mutex
+	size  int
+	order *list.List // от недавно использованных к давним
+	items map[string]*list.Element
+}
+
+func newBlockCache(size int) *blockCache {
+	if size <= 0 {
+		size = DefaultBlockCacheSize
+	}
+	return &blockCache{
+		size:  size,
+		order: list.New(),
+		items: make(map[string]*list.Element, size),
+	}
+}
+
+func (c *blockCache) get(hash []byte) (*block.Block, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	el, ok := c.items[string(hash)]
+	if !ok {
+		return nil, false
+	}
+	c.order.MoveToFront(el)
+	return el.Value.(*block.Block).Clone(), true
+}
+
+func (c *blockCache) add(b *block.Block) {
+	b = b.Clone()
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	key := string(b.Hash)
+	if el, ok := c.items[key]; ok {
+		el.Value = b
+		c.order.MoveToFront(el)
+		return
+	}
+
+	c.items[key] = c.order.PushFront(b)
+	for c.order.Len() > c.size {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.items, string(oldest.Value.(*block.Block).Hash))
+	}
+}
+
+func (c *blockCache) len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.order.Len()
+}
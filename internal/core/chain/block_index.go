@@ -0,0 +1,261 @@
+package chain
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"math/big"
+	"sort"
+
+	"github.com/Alex1997377/weave/internal/core/block"
+	"github.com/Alex1997377/weave/internal/core/consensus"
+	"github.com/Alex1997377/weave/internal/core/header"
+)
+
+// blockNode - узел дерева блоков основной или боковой ветки. Узел хранит
+// только заголовок: тело блока читается из хранилища по требованию.
+type blockNode struct {
+	blockHash []byte
+	header    header.Header
+	chainWork *big.Int
+	parent    *blockNode
+	height    int
+	// hasChildren - у узла есть потомки, то есть он не вершина ветки
+	hasChildren bool
+}
+
+func (n *blockNode) hash() []byte {
+	return n.blockHash
+}
+
+func (n *blockNode) work() *big.Int {
+	if n.chainWork == nil {
+		return new(big.Int)
+	}
+	return n.chainWork
+}
+
+// ChainTip - вершина одной из веток дерева блоков
+type ChainTip struct {
+	Hash      []byte
+	Height    int
+	ChainWork *big.Int
+	// BranchLen - число блоков ветки после точки расхождения с основной цепочкой
+	BranchLen int
+	// Active - вершина основной цепочки
+	Active bool
+}
+
+// addNode добавляет заголовок в дерево потомком parent
+func (bc *Blockchain) addNode(h *block.StoredHeader, parent *blockNode) *blockNode {
+	node := &blockNode{
+		blockHash: h.Hash,
+		header:    h.Header,
+		chainWork: h.ChainWork,
+		parent:    parent,
+		height:    h.Header.Index,
+	}
+	if parent != nil {
+		parent.hasChildren = true
+	}
+	bc.index[string(h.Hash)] = node
+	return node
+}
+
+// lookupNode ищет блок в дереве; блоки боковых веток, сохранённые до
+// перезапуска, подгружаются из записей заголовков вместе с предками
+func (bc *Blockchain) lookupNode(ctx context.Context, hash []byte) (*blockNode, error) {
+	if node, ok := bc.index[string(hash)]; ok {
+		return node, nil
+	}
+
+	var missing []*block.StoredHeader
+	var parent *blockNode
+	for current := hash; parent == nil; {
+		h, err := bc.store.GetHeader(ctx, current)
+		if err != nil {
+			return nil, err
+		}
+		if h.Header.Index <= 0 {
+			return nil, fmt.Errorf("stored block %x doesn`t connect to the block tree", h.Hash)
+		}
+		missing = append(missing, h)
+
+		current = h.Header.PreviousHash
+		parent = bc.index[string(current)]
+	}
+
+	for i := len(missing) - 1; i >= 0; i-- {
+		h := missing[i]
+		if h.Header.Index != parent.height+1 {
+			return nil, fmt.Errorf("stored block %x has index %d, expected %d", h.Hash, h.Header.Index, parent.height+1)
+		}
+		if h.ChainWork == nil {
+			if err := h.SetChainWork(parent.work()); err != nil {
+				return nil, fmt.Errorf("failed to calculate chain work: %w", err)
+			}
+		}
+		parent = bc.addNode(h, parent)
+	}
+	return parent, nil
+}
+
+// HasBlock сообщает, есть ли блок в дереве (в основной или боковой ветке)
+func (bc *Blockchain) HasBlock(hash []byte) bool {
+	bc.mu.RLock()
+	defer bc.mu.RUnlock()
+	return bc.hasBlock(hash)
+}
+
+func (bc *Blockchain) hasBlock(hash []byte) bool {
+	_, ok := bc.index[string(hash)]
+	return ok
+}
+
+// onMainChain сообщает, входит ли узел в основную цепочку
+func (bc *Blockchain) onMainChain(ctx context.Context, n *blockNode) (bool, error) {
+	if n.height > bc.tipNode.height {
+		return false, nil
+	}
+	hash, err := bc.hashAt(ctx, n.height)
+	if err != nil {
+		return false, err
+	}
+	return bytes.Equal(hash, n.hash()), nil
+}
+
+// hashAt возвращает хеш блока основной цепочки на высоте height по индексу высот хранилища
+func (bc *Blockchain) hashAt(ctx context.Context, height int) ([]byte, error) {
+	if height < 0 || bc.tipNode == nil || height > bc.tipNode.height {
+		return nil, fmt.Errorf("block index %d out of range", height)
+	}
+	if height == bc.tipNode.height {
+		return bc.tipNode.hash(), nil
+	}
+	return bc.store.GetHashByHeight(ctx, height)
+}
+
+// mainChainSnapshot возвращает узлы основной цепочки на момент вызова по
+// высоте от генезиса до вершины. Узлы дерева после добавления не меняются,
+// поэтому снимок обходится без mu: смена вершины после снимка его не трогает.
+func (bc *Blockchain) mainChainSnapshot() []*blockNode {
+	bc.mu.RLock()
+	tip := bc.tipNode
+	bc.mu.RUnlock()
+
+	if tip == nil {
+		return nil
+	}
+	nodes := make([]*blockNode, tip.height+1)
+	for n := tip; n != nil; n = n.parent {
+		nodes[n.height] = n
+	}
+	return nodes
+}
+
+// snapshotHeaderSource - consensus.HeaderSource по снимку основной цепочки
+func snapshotHeaderSource(nodes []*blockNode) consensus.HeaderSource {
+	return func(height int) (*header.Header, error) {
+		if height < 0 || height >= len(nodes) {
+			return nil, fmt.Errorf("block index %d out of range", height)
+		}
+		return &nodes[height].header, nil
+	}
+}
+
+// nodeAt возвращает узел основной цепочки на высоте height
+func (bc *Blockchain) nodeAt(ctx context.Context, height int) (*blockNode, error) {
+	hash, err := bc.hashAt(ctx, height)
+	if err != nil {
+		return nil, err
+	}
+	node, ok := bc.index[string(hash)]
+	if !ok {
+		return nil, NewChainCorruptedError(fmt.Sprintf("block %x at height %d missing from index", hash, height), nil)
+	}
+	return node, nil
+}
+
+// branchFrom возвращает узлы ветки n после точки расхождения с основной
+// цепочкой (от n к корню) и высоту точки расхождения
+func (bc *Blockchain) branchFrom(ctx context.Context, n *blockNode) ([]*blockNode, int, error) {
+	var branch []*blockNode
+	for ; n != nil; n = n.parent {
+		main, err := bc.onMainChain(ctx, n)
+		if err != nil {
+			return nil, 0, err
+		}
+		if main {
+			return branch, n.height, nil
+		}
+		branch = append(branch, n)
+	}
+	return branch, -1, nil
+}
+
+// headerSource - consensus.HeaderSource основной цепочки по дереву заголовков
+func (bc *Blockchain) headerSource(ctx context.Context) consensus.HeaderSource {
+	return func(height int) (*header.Header, error) {
+		node, err := bc.nodeAt(ctx, height)
+		if err != nil {
+			return nil, err
+		}
+		return &node.header, nil
+	}
+}
+
+// headerSourceFor - consensus.HeaderSource ветки, заканчивающейся узлом tip
+func (bc *Blockchain) headerSourceFor(ctx context.Context, tip *blockNode) (consensus.HeaderSource, error) {
+	branch, fork, err := bc.branchFrom(ctx, tip)
+	if err != nil {
+		return nil, err
+	}
+	mainAt := bc.headerSource(ctx)
+	return func(height int) (*header.Header, error) {
+		switch {
+		case height < 0 || height > tip.height:
+			return nil, fmt.Errorf("block index %d out of range", height)
+		case height <= fork:
+			return mainAt(height)
+		default:
+			return &branch[tip.height-height].header, nil
+		}
+	}, nil
+}
+
+// ChainTips возвращает вершины всех известных веток: первой активную,
+// затем остальные по убыванию накопленной работы
+func (bc *Blockchain) ChainTips(ctx context.Context) ([]ChainTip, error) {
+	bc.mu.RLock()
+	defer bc.mu.RUnlock()
+
+	tips := make([]ChainTip, 0, 1)
+	for _, node := range bc.index {
+		if node.hasChildren {
+			continue
+		}
+
+		branch, _, err := bc.branchFrom(ctx, node)
+		if err != nil {
+			return nil, err
+		}
+		tips = append(tips, ChainTip{
+			Hash:      append([]byte(nil), node.hash()...),
+			Height:    node.height,
+			ChainWork: new(big.Int).Set(node.work()),
+			BranchLen: len(branch),
+			Active:    node == bc.tipNode,
+		})
+	}
+
+	sort.Slice(tips, func(i, j int) bool {
+		if tips[i].Active != tips[j].Active {
+			return tips[i].Active
+		}
+		if c := tips[i].ChainWork.Cmp(tips[j].ChainWork); c != 0 {
+			return c > 0
+		}
+		return bytes.Compare(tips[i].Hash, tips[j].Hash) < 0
+	})
+	return tips, nil
+}
@@ -1,67 +1,148 @@
 package chain
 
 import (
+	"bytes"
+	"context"
 	"encoding/hex"
 	"errors"
 	"fmt"
+	"sync"
 
+	"github.com/Alex1997377/weave/internal/clock"
 	"github.com/Alex1997377/weave/internal/core/block"
-	"github.com/Alex1997377/weave/internal/core/transaction"
+	"github.com/Alex1997377/weave/internal/core/consensus"
 )
 
-const DIFFICULTY int = 4
-
+// Blockchain безопасна для конкурентного использования. Подключение блоков
+// (ProcessBlock, SubmitBlock, AddBlock) и смена настроек идут через один
+// путь записи под mu; читатели видят цепочку целиком на один момент. Полные
+// обходы (IsValid, GetBalance) берут под mu только снимок основной цепочки
+// и читают блоки без него.
 type Blockchain struct {
+	mu sync.RWMutex
+
 	store  block.BlockStore // Приватное поле
-	Tip    []byte
-	Blocks []*block.Block
+	params *consensus.Params
+	clock  clock.Clock
+	tip    []byte
+
+	// index - дерево заголовков всех известных блоков по хешу, включая
+	// боковые ветки. Высоты основной цепочки разрешаются через индекс высот
+	// хранилища, тела блоков читаются по требованию через cache.
+	index   map[string]*blockNode
+	tipNode *blockNode
+	orphans *orphanPool
+	cache   *blockCache
+	events  *eventBus
+}
+
+// NewBlockchain создает новую или восстанавливает существующую цепочку сети params.
+// ctx ограничивает загрузку цепочки из хранилища.
+func NewBlockchain(ctx context.Context, store block.BlockStore, params *consensus.Params) (*Blockchain, error) {
+	return NewBlockchainWithClock(ctx, store, params, clock.System)
 }
 
-// NewBlockchain создает новую или восстанавливает существующую цепочку
-func NewBlockchain(store block.BlockStore) (*Blockchain, error) {
+// NewBlockchainWithClock - NewBlockchain, в котором время новых блоков берётся из clk
+func NewBlockchainWithClock(ctx context.Context, store block.BlockStore, params *consensus.Params, clk clock.Clock) (*Blockchain, error) {
 	if store == nil {
 		return nil, errors.New("store cannot be nil")
 	}
 
-	lastHash, err := store.GetLastHash()
+	if err := params.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid consensus params: %w", err)
+	}
+
+	genesis, err := block.NewGenesisBlock(params)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create genesis block: %w", err)
+	}
+
+	lastHash, err := store.GetLastHash(ctx)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get last hash: %w", err)
 	}
 
+	bc := &Blockchain{
+		store:   store,
+		params:  params,
+		clock:   clock.OrSystem(clk),
+		orphans: newOrphanPool(OrphanConfig{}),
+		cache:   newBlockCache(DefaultBlockCacheSize),
+		events:  newEventBus(),
+	}
+
 	// Если нет последнего хеша, создаем генезис блок
 	if lastHash == nil {
-		genesis, err := block.NewBlock([]transaction.Transaction{}, make([]byte, 32), 0, DIFFICULTY)
-		if err != nil {
-			return nil, fmt.Errorf("failed to create genesis block: %w", err)
+		if err := genesis.SetChainWork(nil); err != nil {
+			return nil, fmt.Errorf("failed to calculate genesis chain work: %w", err)
 		}
 
-		if err := store.SaveBlock(genesis); err != nil {
+		if err := store.SaveBlock(ctx, genesis); err != nil {
 			return nil, fmt.Errorf("failed to save genesis block: %w", err)
 		}
 
-		return &Blockchain{
-			store:  store,
-			Tip:    genesis.Hash,
-			Blocks: []*block.Block{genesis},
-		}, nil
+		bc.index = map[string]*blockNode{}
+		bc.tipNode = bc.addNode(genesis.StoredHeader(), nil)
+		bc.tip = genesis.Hash
+		bc.cache.add(genesis)
+		return bc, nil
 	}
 
 	// Загружаем существующую цепочку
-	bc := &Blockchain{
-		store: store,
-		Tip:   lastHash,
+	bc.tip = lastHash
+	if err := bc.loadIndex(ctx); err != nil {
+		return nil, fmt.Errorf("failed to load blocks: %w", err)
 	}
 
-	if err := bc.loadBlocks(); err != nil {
-		return nil, fmt.Errorf("failed to load blocks: %w", err)
+	// Хранилище другой сети открывать нельзя
+	first, err := bc.hashAt(ctx, 0)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read genesis hash: %w", err)
+	}
+	if !bytes.Equal(first, genesis.Hash) {
+		return nil, fmt.Errorf("stored chain does not belong to network %q: genesis mismatch", params.Name)
+	}
+	if err := bc.verifyCheckpoints(ctx); err != nil {
+		return nil, fmt.Errorf("stored chain violates checkpoints: %w", err)
 	}
 
 	return bc, nil
 }
 
-// Display отображает все блоки
-func (bc *Blockchain) Display() {
-	for i, b := range bc.Blocks {
+// SetBlockCacheSize задаёт число тел блоков в LRU-кеше; size <= 0 - значение
+// по умолчанию. Кеш при этом очищается.
+func (bc *Blockchain) SetBlockCacheSize(size int) {
+	bc.mu.Lock()
+	defer bc.mu.Unlock()
+	bc.cache = newBlockCache(size)
+}
+
+// CachedBlocks возвращает число тел блоков в кеше
+func (bc *Blockchain) CachedBlocks() int {
+	bc.mu.RLock()
+	defer bc.mu.RUnlock()
+	return bc.cache.len()
+}
+
+// Params возвращает параметры консенсуса цепочки
+func (bc *Blockchain) Params() *consensus.Params {
+	return bc.params
+}
+
+// Display отображает все блоки основной цепочки
+func (bc *Blockchain) Display(ctx context.Context) {
+	bc.mu.RLock()
+	defer bc.mu.RUnlock()
+
+	for i := 0; i <= bc.height(); i++ {
+		b, err := bc.blockAt(ctx, i, false)
+		if ctx.Err() != nil {
+			return
+		}
+		if err != nil {
+			fmt.Printf("--- Block ID: %d --- %v\n", i, err)
+			continue
+		}
 		fmt.Printf("--- Block ID: %d ---\n", i)
 		fmt.Printf("Timestamp: 	%d\n", b.Header.Timestamp)
 		fmt.Printf("Transactions: 	%d\n", len(b.Transaction))
@@ -74,5 +155,7 @@ func (bc *Blockchain) Display() {
 
 // Close закрывает хранилище
 func (bc *Blockchain) Close() error {
+	bc.mu.Lock()
+	defer bc.mu.Unlock()
 	return bc.store.Close()
 }
@@ -0,0 +1,64 @@
+package chain
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"fmt"
+
+	"github.com/Alex1997377/weave/internal/core/block"
+)
+
+// checkCheckpoint отклоняет блок, противоречащий контрольной точке на его высоте
+func (bc *Blockchain) checkCheckpoint(b *block.Block) error {
+	if err := bc.params.CheckCheckpoint(b.Header.Index, b.Hash); err != nil {
+		return newBlockError(ErrCheckpointMismatch, "block conflicts with checkpoint", err)
+	}
+	return nil
+}
+
+// checkForkPoint отклоняет блок, ответвляющийся от основной цепочки ниже
+// последней пройденной контрольной точки
+func (bc *Blockchain) checkForkPoint(parent *blockNode) error {
+	cp, ok := bc.params.LastCheckpoint(bc.tipNode.height)
+	if !ok || parent.height >= cp.Height {
+		return nil
+	}
+	return newBlockError(ErrForkBelowCheckpoint,
+		fmt.Sprintf("block forks at height %d below checkpoint %d", parent.height, cp.Height), nil)
+}
+
+// verifyCheckpoints проверяет загруженную основную цепочку по контрольным точкам
+func (bc *Blockchain) verifyCheckpoints(ctx context.Context) error {
+	for _, cp := range bc.params.Checkpoints {
+		if cp.Height > bc.height() {
+			break
+		}
+		hash, err := bc.hashAt(ctx, cp.Height)
+		if err != nil {
+			return err
+		}
+		if err := bc.params.CheckCheckpoint(cp.Height, hash); err != nil {
+			return newBlockError(ErrCheckpointMismatch, "block conflicts with checkpoint", err)
+		}
+	}
+	return nil
+}
+
+// assumedValidHeight возвращает высоту блока AssumeValid в снимке основной
+// цепочки nodes или -1, если он не задан или не входит в снимок
+func (bc *Blockchain) assumedValidHeight(nodes []*blockNode) (int, error) {
+	if bc.params.AssumeValid == "" {
+		return -1, nil
+	}
+	hash, err := hex.DecodeString(bc.params.AssumeValid)
+	if err != nil {
+		return -1, fmt.Errorf("invalid assume-valid hash: %w", err)
+	}
+	for i := len(nodes) - 1; i >= 0; i-- {
+		if bytes.Equal(nodes[i].hash(), hash) {
+			return i, nil
+		}
+	}
+	return -1, nil
+}
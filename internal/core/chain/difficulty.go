@@ -0,0 +1,30 @@
+package chain
+
+import (
+	"context"
+	"math/big"
+
+	"github.com/Alex1997377/weave/internal/core/consensus"
+)
+
+// NextWorkRequired возвращает версию заголовка и цель, которые должен иметь следующий блок
+func (bc *Blockchain) NextWorkRequired(ctx context.Context) (consensus.WorkRequirement, error) {
+	bc.mu.RLock()
+	defer bc.mu.RUnlock()
+	return bc.nextWorkRequired(ctx)
+}
+
+func (bc *Blockchain) nextWorkRequired(ctx context.Context) (consensus.WorkRequirement, error) {
+	return bc.params.NextWorkRequired(bc.height()+1, bc.headerSource(ctx))
+}
+
+// ChainWork возвращает суммарную работу цепочки до вершины
+func (bc *Blockchain) ChainWork() *big.Int {
+	bc.mu.RLock()
+	defer bc.mu.RUnlock()
+
+	if bc.tipNode == nil {
+		return new(big.Int)
+	}
+	return new(big.Int).Set(bc.tipNode.work())
+}
@@ -1,6 +1,9 @@
 package chain
 
-import "fmt"
+import (
+	"errors"
+	"fmt"
+)
 
 type BlockchainError struct {
 	Code    string
@@ -26,8 +29,44 @@ const (
 	ErrBlockNotFound    = "BLOCK_NOT_FOUND"
 	ErrChainCorrupted   = "CHAIN_CORRUPTED"
 	ErrCreateWallet     = "GENERATE_KEY_PAIR_ERROR"
+	ErrStaleBlock       = "STALE_BLOCK"
+	ErrDuplicateBlock   = "DUPLICATE_BLOCK"
+	ErrOrphanBlock      = "ORPHAN_BLOCK"
+
+	// Причины отклонения блока в ProcessBlock
+	ErrInvalidHeader      = "INVALID_HEADER"
+	ErrInvalidHeight      = "INVALID_HEIGHT"
+	ErrInvalidTimestamp   = "INVALID_TIMESTAMP"
+	ErrInvalidDifficulty  = "INVALID_DIFFICULTY"
+	ErrInvalidProofOfWork = "INVALID_POW"
+	ErrInvalidMerkleRoot  = "INVALID_MERKLE_ROOT"
+	ErrInvalidTransaction = "INVALID_TRANSACTION"
+	ErrInvalidCoinbase    = "INVALID_COINBASE"
+	ErrBlockTooLarge      = "BLOCK_TOO_LARGE"
+
+	// Нарушение контрольных точек сети
+	ErrCheckpointMismatch  = "CHECKPOINT_MISMATCH"
+	ErrForkBelowCheckpoint = "FORK_BELOW_CHECKPOINT"
 )
 
+// ErrorCode возвращает код BlockchainError из цепочки ошибок err или пустую строку
+func ErrorCode(err error) string {
+	var bcErr *BlockchainError
+	if errors.As(err, &bcErr) {
+		return bcErr.Code
+	}
+	return ""
+}
+
+// newBlockError создаёт ошибку отклонения блока с кодом code
+func newBlockError(code, message string, err error) *BlockchainError {
+	return &BlockchainError{
+		Code:    code,
+		Message: message,
+		Err:     err,
+	}
+}
+
 func NewInvalidBlockError(message string, err error) *BlockchainError {
 	return &BlockchainError{
 		Code:    ErrInvalidBlock,
@@ -75,3 +114,27 @@ func NewCreateWalletError(message string, err error) *BlockchainError {
 		Err:     err,
 	}
 }
+
+func NewStaleBlockError(message string, err error) *BlockchainError {
+	return &BlockchainError{
+		Code:    ErrStaleBlock,
+		Message: message,
+		Err:     err,
+	}
+}
+
+func NewDuplicateBlockError(message string, err error) *BlockchainError {
+	return &BlockchainError{
+		Code:    ErrDuplicateBlock,
+		Message: message,
+		Err:     err,
+	}
+}
+
+func NewOrphanBlockError(message string, err error) *BlockchainError {
+	return &BlockchainError{
+		Code:    ErrOrphanBlock,
+		Message: message,
+		Err:     err,
+	}
+}
@@ -0,0 +1,250 @@
+package chain
+
+import (
+	"sync"
+	"sync/atomic"
+
+	"github.com/Alex1997377/weave/internal/core/block"
+	"github.com/Alex1997377/weave/internal/core/transaction"
+)
+
+// EventType - вид события цепочки
+type EventType int
+
+const (
+	// EventBlockConnected - блок стал частью основной цепочки
+	EventBlockConnected EventType = iota + 1
+	// EventBlockDisconnected - блок отключён от основной цепочки реорганизацией
+	EventBlockDisconnected
+	// EventTipChanged - сменилась вершина; Update описывает смену целиком
+	EventTipChanged
+	// EventTxConfirmed - транзакция вошла в подключённый блок
+	EventTxConfirmed
+)
+
+func (t EventType) String() string {
+	switch t {
+	case EventBlockConnected:
+		return "block_connected"
+	case EventBlockDisconnected:
+		return "block_disconnected"
+	case EventTipChanged:
+		return "tip_changed"
+	case EventTxConfirmed:
+		return "tx_confirmed"
+	}
+	return "unknown"
+}
+
+// Event - событие цепочки. На одну смену вершины приходят сначала
+// EventBlockDisconnected от старой вершины к точке расхождения, затем
+// EventBlockConnected и EventTxConfirmed от точки расхождения к новой
+// вершине и последним EventTipChanged.
+type Event struct {
+	Type EventType
+	// Hash и Height - блок события; для EventTipChanged - новая вершина
+	Hash   []byte
+	Height int
+	// Block - блок события, своя копия у каждого подписчика; nil для EventTipChanged
+	Block *block.Block
+	// Tx - подтверждённая транзакция (только EventTxConfirmed)
+	Tx transaction.Transaction
+	// Update - смена вершины (только EventTipChanged)
+	Update *TipUpdate
+}
+
+// OverflowPolicy - что делать, когда буфер подписчика заполнен
+type OverflowPolicy int
+
+const (
+	// OverflowDropOldest вытесняет самое старое непрочитанное событие
+	OverflowDropOldest OverflowPolicy = iota
+	// OverflowDropNewest отбрасывает новое событие
+	OverflowDropNewest
+	// OverflowBlock ждёт, пока подписчик прочитает событие. Цепочка при этом
+	// заблокирована на запись, поэтому подписчик не должен обращаться к ней
+	// из обработчика.
+	OverflowBlock
+)
+
+// DefaultEventBuffer - размер буфера подписки по умолчанию
+const DefaultEventBuffer = 64
+
+// SubscribeOptions - настройки подписки
+type SubscribeOptions struct {
+	// Buffer - размер буфера канала; <= 0 - DefaultEventBuffer
+	Buffer int
+	// Types - какие события доставлять; пусто - все
+	Types []EventType
+	// Overflow - поведение при заполненном буфере
+	Overflow OverflowPolicy
+}
+
+// Subscription - подписка на события цепочки
+type Subscription struct {
+	// C - канал событий; закрывается после Close
+	C <-chan Event
+
+	ch       chan Event
+	types    map[EventType]bool
+	overflow OverflowPolicy
+	dropped  atomic.Uint64
+
+	mu     sync.Mutex // защищает отправку и закрытие ch
+	closed bool
+	done   chan struct{}
+	bus    *eventBus
+}
+
+// Dropped возвращает число событий, потерянных из-за переполнения буфера
+func (s *Subscription) Dropped() uint64 {
+	return s.dropped.Load()
+}
+
+// Close отменяет подписку и закрывает канал C. Повторный вызов ничего не делает.
+func (s *Subscription) Close() {
+	s.bus.remove(s)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return
+	}
+	s.closed = true
+	close(s.ch)
+}
+
+// deliver передаёт событие подписчику согласно политике переполнения
+func (s *Subscription) deliver(ev Event) {
+	if len(s.types) > 0 && !s.types[ev.Type] {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return
+	}
+
+	select {
+	case s.ch <- ev:
+		return
+	default:
+	}
+
+	switch s.overflow {
+	case OverflowDropNewest:
+		s.dropped.Add(1)
+	case OverflowBlock:
+		select {
+		case s.ch <- ev:
+		case <-s.done:
+		}
+	default:
+		select {
+		case <-s.ch:
+			s.dropped.Add(1)
+		default:
+		}
+		select {
+		case s.ch <- ev:
+		default:
+			s.dropped.Add(1)
+		}
+	}
+}
+
+// eventBus рассылает события подписчикам
+type eventBus struct {
+	mu   sync.Mutex
+	subs map[*Subscription]struct{}
+}
+
+func newEventBus() *eventBus {
+	return &eventBus{subs: make(map[*Subscription]struct{})}
+}
+
+func (b *eventBus) remove(s *Subscription) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if _, ok := b.subs[s]; ok {
+		delete(b.subs, s)
+		close(s.done)
+	}
+}
+
+func (b *eventBus) subscribers() []*Subscription {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	subs := make([]*Subscription, 0, len(b.subs))
+	for s := range b.subs {
+		subs = append(subs, s)
+	}
+	return subs
+}
+
+// Subscribe подписывает на события цепочки. События рассылаются синхронно
+// из ProcessBlock, SubmitBlock и AddBlock под блокировкой записи; при
+// заполненном буфере действует opts.Overflow.
+func (bc *Blockchain) Subscribe(opts SubscribeOptions) *Subscription {
+	if opts.Buffer <= 0 {
+		opts.Buffer = DefaultEventBuffer
+	}
+
+	ch := make(chan Event, opts.Buffer)
+	s := &Subscription{
+		C:        ch,
+		ch:       ch,
+		overflow: opts.Overflow,
+		done:     make(chan struct{}),
+		bus:      bc.events,
+	}
+	if len(opts.Types) > 0 {
+		s.types = make(map[EventType]bool, len(opts.Types))
+		for _, t := range opts.Types {
+			s.types[t] = true
+		}
+	}
+
+	bc.events.mu.Lock()
+	bc.events.subs[s] = struct{}{}
+	bc.events.mu.Unlock()
+	return s
+}
+
+// SubscribeFunc вызывает fn для каждого события в отдельной горутине.
+// Буферизация и переполнение - как у Subscribe; Close останавливает доставку.
+func (bc *Blockchain) SubscribeFunc(opts SubscribeOptions, fn func(Event)) *Subscription {
+	s := bc.Subscribe(opts)
+	go func() {
+		for ev := range s.C {
+			fn(ev)
+		}
+	}()
+	return s
+}
+
+// notify рассылает события смены вершины update. Каждый подписчик
+// получает свою копию смены и блоков (см. TipUpdate.clone): блоки одних
+// событий не делятся ни с вызывающим, ни с другими подписчиками.
+func (bc *Blockchain) notify(update *TipUpdate) {
+	if update == nil {
+		return
+	}
+	subs := bc.events.subscribers()
+	height := bc.height()
+
+	for _, s := range subs {
+		u := update.clone()
+		for _, b := range u.Disconnected {
+			s.deliver(Event{Type: EventBlockDisconnected, Hash: b.Hash, Height: b.Header.Index, Block: b})
+		}
+		for _, b := range u.Connected {
+			s.deliver(Event{Type: EventBlockConnected, Hash: b.Hash, Height: b.Header.Index, Block: b})
+			for _, tx := range b.Transaction {
+				s.deliver(Event{Type: EventTxConfirmed, Hash: b.Hash, Height: b.Header.Index, Block: b, Tx: tx})
+			}
+		}
+		s.deliver(Event{Type: EventTipChanged, Hash: u.NewTip, Height: height, Update: u})
+	}
+}
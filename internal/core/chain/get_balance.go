@@ -2,18 +2,23 @@ package chain
 
 import (
 	"bytes"
+	"context"
 	"errors"
 )
 
-// GetBalance вычисляет баланс адреса
-func (bc *Blockchain) GetBalance(address []byte) (float64, error) {
+// GetBalance вычисляет баланс адреса по снимку основной цепочки на момент
+// вызова; mu на время чтения блоков не держится
+func (bc *Blockchain) GetBalance(ctx context.Context, address []byte) (float64, error) {
 	if address == nil {
 		return 0, errors.New("address cannot be nil")
 	}
 
 	var balance float64
-
-	for _, b := range bc.Blocks {
+	for _, node := range bc.mainChainSnapshot() {
+		b, err := bc.readNodeBlock(ctx, node)
+		if err != nil {
+			return 0, err
+		}
 		for _, tx := range b.Transaction {
 			if tx == nil {
 				continue
@@ -1,24 +1,42 @@
 package chain
 
 import (
+	"context"
 	"errors"
-	"fmt"
 
 	"github.com/Alex1997377/weave/internal/core/block"
 )
 
-// GetBlockByHash возвращает блок по хешу
-func (bc *Blockchain) GetBlockByHash(hash []byte) (*block.Block, error) {
+// GetBlockByHash возвращает блок по хешу. Каждый вызов возвращает свою копию.
+func (bc *Blockchain) GetBlockByHash(ctx context.Context, hash []byte) (*block.Block, error) {
 	if hash == nil {
 		return nil, errors.New("hash cannot be nil")
 	}
-	return bc.store.GetBlock(hash)
+
+	bc.mu.RLock()
+	defer bc.mu.RUnlock()
+	return bc.getBlock(ctx, hash)
+}
+
+// GetBlockByIndex возвращает блок основной цепочки по индексу; высота
+// разрешается через индекс высот хранилища, тело - через LRU-кеш. Каждый
+// вызов возвращает свою копию.
+func (bc *Blockchain) GetBlockByIndex(ctx context.Context, index int) (*block.Block, error) {
+	bc.mu.RLock()
+	defer bc.mu.RUnlock()
+	return bc.blockAt(ctx, index, true)
 }
 
-// GetBlockByIndex возвращает блок по индексу
-func (bc *Blockchain) GetBlockByIndex(index int) (*block.Block, error) {
-	if index < 0 || index >= len(bc.Blocks) {
-		return nil, fmt.Errorf("block index %d out of range", index)
+// blockAt читает блок основной цепочки на высоте height. Полные проходы по
+// цепочке читают в обход кеша (cached = false), чтобы не вытеснять из него
+// недавние блоки.
+func (bc *Blockchain) blockAt(ctx context.Context, height int, cached bool) (*block.Block, error) {
+	hash, err := bc.hashAt(ctx, height)
+	if err != nil {
+		return nil, err
+	}
+	if cached {
+		return bc.getBlock(ctx, hash)
 	}
-	return bc.Blocks[index], nil
+	return bc.readBlock(ctx, hash)
 }
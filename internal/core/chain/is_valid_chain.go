@@ -2,20 +2,37 @@ package chain
 
 import (
 	"bytes"
-	"encoding/hex"
+	"context"
 	"errors"
 	"fmt"
-	"strings"
+	"math/big"
+
+	"github.com/Alex1997377/weave/internal/core/block"
 )
 
-// IsValid проверяет целостность цепочки
-func (bc *Blockchain) IsValid() error {
-	if len(bc.Blocks) == 0 {
+// IsValid проверяет целостность цепочки, читая блоки из хранилища по одному.
+// Проверяется снимок основной цепочки на момент вызова; mu на время чтения
+// блоков не держится, так что запись новых блоков не ждёт проверки.
+// У блоков до AssumeValid включительно не перепроверяются правила
+// транзакций (block.CheckTransactions); заголовки, хеши, связи, MerkleRoot,
+// работа, размер и контрольные точки проверяются у всех блоков.
+func (bc *Blockchain) IsValid(ctx context.Context) error {
+	nodes := bc.mainChainSnapshot()
+	if len(nodes) == 0 {
 		return errors.New("blockchain is empty")
 	}
+	assumedValid, err := bc.assumedValidHeight(nodes)
+	if err != nil {
+		return err
+	}
+	headerAt := snapshotHeaderSource(nodes)
 
-	for i := 0; i < len(bc.Blocks); i++ {
-		current := bc.Blocks[i]
+	var previous *block.Block
+	for i, node := range nodes {
+		current, err := bc.readNodeBlock(ctx, node)
+		if err != nil {
+			return fmt.Errorf("failed to load block %d: %w", i, err)
+		}
 
 		// Проверяем хеш текущего блока
 		currentHash, err := current.CalculateHash()
@@ -28,25 +45,72 @@ func (bc *Blockchain) IsValid() error {
 		}
 
 		// Проверяем ссылку на предыдущий блок (кроме генезиса)
-		if i > 0 {
-			previous := bc.Blocks[i-1]
+		if previous != nil {
 			if !bytes.Equal(current.Header.PreviousHash, previous.Hash) {
 				return fmt.Errorf("block %d: PreviousHash does not match hash of block %d", i, i-1)
 			}
 		}
 
+		if err := bc.checkCheckpoint(current); err != nil {
+			return fmt.Errorf("block %d: %w", i, err)
+		}
+
 		// Проверяем Merkle root
 		expectedMerkleRoot := current.CalculateMerkleRoot()
 		if !bytes.Equal(current.Header.MerkleRoot, expectedMerkleRoot) {
 			return fmt.Errorf("block %d: Merkle Root mismatch (transactions modified)", i)
 		}
 
-		// Проверяем proof of work
-		hashStr := hex.EncodeToString(current.Hash)
-		target := strings.Repeat("0", current.Header.Difficulty)
-		if !strings.HasPrefix(hashStr, target) {
-			return fmt.Errorf("block %d: hash does not satisfy difficulty %d", i, current.Header.Difficulty)
+		// Проверяем, что цель совпадает с рассчитанной по предыдущим блокам
+		if err := bc.params.CheckWork(&current.Header, headerAt); err != nil {
+			return fmt.Errorf("block %d: %w", i, err)
+		}
+
+		// Проверяем время относительно медианы предыдущих блоков и часов узла
+		if err := bc.params.CheckTimestamp(&current.Header, headerAt, bc.clock.Now()); err != nil {
+			return fmt.Errorf("block %d: %w", i, err)
+		}
+
+		// Проверяем накопленную работу
+		var parentWork *big.Int
+		if previous != nil {
+			parentWork = previous.ChainWork
+		}
+		expectedWork, err := current.CalculateChainWork(parentWork)
+		if err != nil {
+			return fmt.Errorf("failed to calculate chain work for block %d: %w", i, err)
 		}
+		if current.ChainWork == nil || current.ChainWork.Cmp(expectedWork) != 0 {
+			return fmt.Errorf("block %d: chain work mismatch", i)
+		}
+
+		// Заголовок, proof of work и размер проверяем у всех блоков,
+		// правила транзакций - только выше AssumeValid
+		if err := bc.checkHeader(current); err != nil {
+			return fmt.Errorf("block %d: %w", i, err)
+		}
+		if err := bc.checkProofOfWork(current); err != nil {
+			return fmt.Errorf("block %d: %w", i, err)
+		}
+		if i > assumedValid {
+			if err := bc.checkTransactions(current); err != nil {
+				return fmt.Errorf("block %d: %w", i, err)
+			}
+		}
+		if err := bc.checkSize(current); err != nil {
+			return fmt.Errorf("block %d: %w", i, err)
+		}
+		previous = current
+	}
+
+	// Генезис должен совпадать с генезисом сети
+	genesis, err := block.NewGenesisBlock(bc.params)
+	if err != nil {
+		return fmt.Errorf("failed to create genesis block: %w", err)
 	}
+	if !bytes.Equal(nodes[0].hash(), genesis.Hash) {
+		return errors.New("block 0 is not the genesis block of this network")
+	}
+
 	return nil
 }
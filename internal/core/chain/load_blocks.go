@@ -1,40 +1,101 @@
 package chain
 
 import (
+	"bytes"
+	"context"
 	"fmt"
+	"math/big"
 
 	"github.com/Alex1997377/weave/internal/core/block"
 )
 
-// loadBlocks загружает все блоки из хранилища
-func (bc *Blockchain) loadBlocks() error {
-	var blocks []*block.Block
-	currentHash := bc.Tip
+// loadIndex строит дерево заголовков основной цепочки по индексу высот
+// и записям заголовков хранилища; тела блоков при загрузке не читаются.
+// Хранилище без индекса высот (или с индексом, отстающим от вершины)
+// переиндексируется от вершины к генезису.
+func (bc *Blockchain) loadIndex(ctx context.Context) error {
+	tip, err := bc.store.GetHeader(ctx, bc.tip)
+	if err != nil {
+		return fmt.Errorf("failed to get tip header %x: %w", bc.tip, err)
+	}
+	height := tip.Header.Index
 
-	for currentHash != nil {
-		b, err := bc.store.GetBlock(currentHash)
-		if err != nil {
-			return fmt.Errorf("failed to get block %x: %w", currentHash, err)
+	indexed, err := bc.store.GetHashByHeight(ctx, height)
+	if err != nil || !bytes.Equal(indexed, bc.tip) {
+		if err := bc.store.SetLastHash(ctx, bc.tip); err != nil {
+			return fmt.Errorf("failed to rebuild height index: %w", err)
 		}
+	}
 
-		// Вставляем в начало среза (обратный порядок)
-		blocks = append([]*block.Block{b}, blocks...)
+	bc.index = make(map[string]*blockNode, height+1)
+	var parent *blockNode
+	for i := 0; i <= height; i++ {
+		hash, err := bc.store.GetHashByHeight(ctx, i)
+		if err != nil {
+			return fmt.Errorf("failed to resolve height %d: %w", i, err)
+		}
+		h, err := bc.store.GetHeader(ctx, hash)
+		if err != nil {
+			return fmt.Errorf("failed to get header %x: %w", hash, err)
+		}
+		if h.Header.Index != i || (parent != nil && !bytes.Equal(h.Header.PreviousHash, parent.hash())) {
+			return NewChainCorruptedError(fmt.Sprintf("block %x doesn`t link to height %d", hash, i), nil)
+		}
 
-		// Проверяем, дошли ли до генезис блока
-		isGenesis := true
-		for _, bVal := range b.Header.PreviousHash {
-			if bVal != 0 {
-				isGenesis = false
-				break
+		// Накопленная работа считается для блоков, сохранённых без неё
+		if h.ChainWork == nil {
+			var parentWork *big.Int
+			if parent != nil {
+				parentWork = parent.work()
+			}
+			if err := h.SetChainWork(parentWork); err != nil {
+				return fmt.Errorf("failed to calculate chain work for block %d: %w", i, err)
 			}
 		}
+		parent = bc.addNode(h, parent)
+	}
+
+	bc.tipNode = parent
+	return nil
+}
 
-		if isGenesis {
-			break
+// readBlock читает тело блока из хранилища в обход кеша; накопленная
+// работа, если её нет в хранилище, берётся из дерева
+func (bc *Blockchain) readBlock(ctx context.Context, hash []byte) (*block.Block, error) {
+	b, err := bc.store.GetBlock(ctx, hash)
+	if err != nil {
+		return nil, err
+	}
+	if b.ChainWork == nil {
+		if node, ok := bc.index[string(hash)]; ok {
+			b.ChainWork = node.work()
 		}
-		currentHash = b.Header.PreviousHash
 	}
+	return b, nil
+}
 
-	bc.Blocks = blocks
-	return nil
+// readNodeBlock читает тело блока узла n в обход кеша и дерева: годится
+// для обхода снимка основной цепочки без mu
+func (bc *Blockchain) readNodeBlock(ctx context.Context, n *blockNode) (*block.Block, error) {
+	b, err := bc.store.GetBlock(ctx, n.hash())
+	if err != nil {
+		return nil, err
+	}
+	if b.ChainWork == nil {
+		b.ChainWork = n.work()
+	}
+	return b, nil
+}
+
+// getBlock возвращает тело блока через LRU-кеш
+func (bc *Blockchain) getBlock(ctx context.Context, hash []byte) (*block.Block, error) {
+	if b, ok := bc.cache.get(hash); ok {
+		return b, nil
+	}
+	b, err := bc.readBlock(ctx, hash)
+	if err != nil {
+		return nil, err
+	}
+	bc.cache.add(b)
+	return b, nil
 }
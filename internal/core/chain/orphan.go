@@ -0,0 +1,224 @@
+package chain
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/Alex1997377/weave/internal/core/block"
+)
+
+// Ограничения пула сирот по умолчанию
+const (
+	DefaultMaxOrphans   = 100
+	DefaultMaxOrphanAge = 20 * time.Minute
+)
+
+// OrphanConfig - настройки пула сирот: блоков, родитель которых ещё неизвестен
+type OrphanConfig struct {
+	// MaxOrphans - сколько сирот держать; при переполнении вытесняется самая старая
+	MaxOrphans int
+	// MaxAge - сколько ждать родителя, прежде чем забыть сироту
+	MaxAge time.Duration
+	// OnMissingParent вызывается с хешем недостающего блока, который нужно
+	// запросить у сети. Вызывается синхронно из ProcessBlock под блокировкой
+	// записи, поэтому обращаться к цепочке из него нельзя.
+	OnMissingParent func(hash []byte)
+}
+
+// orphan - блок, ожидающий родителя
+type orphan struct {
+	block *block.Block
+	added time.Time
+	seq   uint64
+}
+
+// orphanPool хранит сирот по хешу и по хешу родителя
+type orphanPool struct {
+	config   OrphanConfig
+	byHash   map[string]*orphan
+	byParent map[string][]*orphan
+	seq      uint64
+}
+
+func newOrphanPool(config OrphanConfig) *orphanPool {
+	if config.MaxOrphans <= 0 {
+		config.MaxOrphans = DefaultMaxOrphans
+	}
+	if config.MaxAge <= 0 {
+		config.MaxAge = DefaultMaxOrphanAge
+	}
+	return &orphanPool{
+		config:   config,
+		byHash:   make(map[string]*orphan),
+		byParent: make(map[string][]*orphan),
+	}
+}
+
+func (p *orphanPool) has(hash []byte) bool {
+	_, ok := p.byHash[string(hash)]
+	return ok
+}
+
+// add кладёт блок в пул, освобождая место от просроченных и самых старых сирот
+func (p *orphanPool) add(b *block.Block, now time.Time) {
+	p.expire(now)
+	for len(p.byHash) >= p.config.MaxOrphans {
+		p.remove(p.oldest())
+	}
+
+	p.seq++
+	o := &orphan{block: b, added: now, seq: p.seq}
+	p.byHash[string(b.Hash)] = o
+	parent := string(b.Header.PreviousHash)
+	p.byParent[parent] = append(p.byParent[parent], o)
+}
+
+func (p *orphanPool) oldest() *orphan {
+	var oldest *orphan
+	for _, o := range p.byHash {
+		if oldest == nil || o.seq < oldest.seq {
+			oldest = o
+		}
+	}
+	return oldest
+}
+
+func (p *orphanPool) remove(o *orphan) {
+	if o == nil {
+		return
+	}
+	delete(p.byHash, string(o.block.Hash))
+
+	parent := string(o.block.Header.PreviousHash)
+	siblings := p.byParent[parent]
+	for i, s := range siblings {
+		if s == o {
+			siblings = append(siblings[:i], siblings[i+1:]...)
+			break
+		}
+	}
+	if len(siblings) == 0 {
+		delete(p.byParent, parent)
+	} else {
+		p.byParent[parent] = siblings
+	}
+}
+
+// expire забывает сирот старше MaxAge
+func (p *orphanPool) expire(now time.Time) {
+	for _, o := range p.byHash {
+		if now.Sub(o.added) > p.config.MaxAge {
+			p.remove(o)
+		}
+	}
+}
+
+// takeChildren извлекает из пула сирот, ожидавших блок parent
+func (p *orphanPool) takeChildren(parent []byte) []*block.Block {
+	children := p.byParent[string(parent)]
+	blocks := make([]*block.Block, 0, len(children))
+	for _, o := range append([]*orphan(nil), children...) {
+		p.remove(o)
+		blocks = append(blocks, o.block)
+	}
+	return blocks
+}
+
+// removeDescendants забывает всех потомков блока: они уже не смогут подключиться
+func (p *orphanPool) removeDescendants(hash []byte) {
+	for _, child := range p.takeChildren(hash) {
+		p.removeDescendants(child.Hash)
+	}
+}
+
+// missingParents возвращает хеши недостающих блоков, без которых не подключить сирот
+func (p *orphanPool) missingParents() [][]byte {
+	var missing [][]byte
+	for parent := range p.byParent {
+		if _, ok := p.byHash[parent]; !ok {
+			missing = append(missing, []byte(parent))
+		}
+	}
+	sort.Slice(missing, func(i, j int) bool { return string(missing[i]) < string(missing[j]) })
+	return missing
+}
+
+// SetOrphanConfig заменяет настройки пула сирот; уже принятые сироты сохраняются
+func (bc *Blockchain) SetOrphanConfig(config OrphanConfig) {
+	bc.mu.Lock()
+	defer bc.mu.Unlock()
+
+	pool := newOrphanPool(config)
+	if bc.orphans != nil {
+		for _, o := range bc.orphans.byHash {
+			pool.add(o.block, o.added)
+		}
+	}
+	bc.orphans = pool
+}
+
+// OrphanCount возвращает число блоков в пуле сирот
+func (bc *Blockchain) OrphanCount() int {
+	bc.mu.RLock()
+	defer bc.mu.RUnlock()
+	return len(bc.orphans.byHash)
+}
+
+// IsOrphan сообщает, ждёт ли блок в пуле сирот своего родителя
+func (bc *Blockchain) IsOrphan(hash []byte) bool {
+	bc.mu.RLock()
+	defer bc.mu.RUnlock()
+	return bc.orphans.has(hash)
+}
+
+// MissingParents возвращает хеши блоков, которые нужно запросить у сети,
+// чтобы подключить сирот
+func (bc *Blockchain) MissingParents() [][]byte {
+	// просроченные сироты удаляются, поэтому нужна блокировка записи
+	bc.mu.Lock()
+	defer bc.mu.Unlock()
+
+	bc.orphans.expire(bc.clock.Now())
+	return bc.orphans.missingParents()
+}
+
+// addOrphan кладёт блок в пул сирот и запрашивает недостающего предка
+func (bc *Blockchain) addOrphan(b *block.Block) {
+	bc.orphans.add(b, bc.clock.Now())
+
+	// если родитель сам сирота, запрашивать нужно корень его ветки
+	missing := b.Header.PreviousHash
+	for {
+		o, ok := bc.orphans.byHash[string(missing)]
+		if !ok {
+			break
+		}
+		missing = o.block.Header.PreviousHash
+	}
+
+	if bc.orphans.config.OnMissingParent != nil {
+		bc.orphans.config.OnMissingParent(append([]byte(nil), missing...))
+	}
+}
+
+// connectOrphans подключает сирот, ожидавших блок parent, и их потомков.
+// Смены вершины складываются в update.
+func (bc *Blockchain) connectOrphans(ctx context.Context, parent []byte, update *TipUpdate) *TipUpdate {
+	queue := [][]byte{parent}
+	for len(queue) > 0 {
+		hash := queue[0]
+		queue = queue[1:]
+
+		for _, child := range bc.orphans.takeChildren(hash) {
+			next, err := bc.acceptBlock(ctx, child)
+			if err != nil {
+				bc.orphans.removeDescendants(child.Hash)
+				continue
+			}
+			update = update.merge(next)
+			queue = append(queue, child.Hash)
+		}
+	}
+	return update
+}
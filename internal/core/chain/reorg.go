@@ -0,0 +1,232 @@
+package chain
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+
+	"github.com/Alex1997377/weave/internal/core/block"
+)
+
+// TipUpdate описывает смену вершины после принятия блока. При простом
+// продолжении цепочки Disconnected пуст; при реорганизации в нём блоки
+// старой ветки от бывшей вершины к точке расхождения, а Connected - блоки
+// новой ветки от точки расхождения к новой вершине. Транзакции отключённых
+// блоков, не вошедшие в новую ветку, можно вернуть в mempool.
+type TipUpdate struct {
+	OldTip       []byte
+	NewTip       []byte
+	ForkHeight   int
+	Disconnected []*block.Block
+	Connected    []*block.Block
+}
+
+// IsReorg сообщает, что часть основной цепочки была отключена
+func (u *TipUpdate) IsReorg() bool {
+	return u != nil && len(u.Disconnected) > 0
+}
+
+// clone возвращает копию смены вершины с копиями блоков (см. block.Block.Clone)
+func (u *TipUpdate) clone() *TipUpdate {
+	c := &TipUpdate{
+		OldTip:     slices.Clone(u.OldTip),
+		NewTip:     slices.Clone(u.NewTip),
+		ForkHeight: u.ForkHeight,
+	}
+	for _, b := range u.Disconnected {
+		c.Disconnected = append(c.Disconnected, b.Clone())
+	}
+	for _, b := range u.Connected {
+		c.Connected = append(c.Connected, b.Clone())
+	}
+	return c
+}
+
+// merge объединяет последовательные смены вершины u и next в одну
+func (u *TipUpdate) merge(next *TipUpdate) *TipUpdate {
+	if u == nil {
+		return next
+	}
+	if next == nil {
+		return u
+	}
+
+	merged := &TipUpdate{
+		OldTip:       u.OldTip,
+		NewTip:       next.NewTip,
+		ForkHeight:   u.ForkHeight,
+		Disconnected: append([]*block.Block(nil), u.Disconnected...),
+		Connected:    append([]*block.Block(nil), u.Connected...),
+	}
+	if next.ForkHeight < merged.ForkHeight {
+		merged.ForkHeight = next.ForkHeight
+	}
+
+	// блок, подключённый в u и отключённый в next, в итоге не менялся
+	for _, b := range next.Disconnected {
+		if n := len(merged.Connected); n > 0 && bytes.Equal(merged.Connected[n-1].Hash, b.Hash) {
+			merged.Connected = merged.Connected[:n-1]
+			continue
+		}
+		merged.Disconnected = append(merged.Disconnected, b)
+	}
+	merged.Connected = append(merged.Connected, next.Connected...)
+	return merged
+}
+
+// ProcessBlock принимает блок, добытый другим узлом или процессом, и
+// продолжающий любую известную ветку. Блок проходит полную проверку:
+// заголовок и хеш, сложность относительно своей ветки, доказательство
+// работы, MerkleRoot, транзакции и размер; причина отклонения передаётся
+// кодом BlockchainError (см. ErrorCode). Принятый блок сохраняется, и если
+// его ветка набрала больше работы, чем основная, она становится основной.
+// Возвращает смену вершины или nil, если блок лёг в боковую ветку.
+//
+// Блок с неизвестным родителем попадает в пул сирот (код ErrOrphanBlock)
+// и подключается автоматически, когда родитель будет принят; смена вершины
+// тогда включает и подключённых сирот.
+//
+// ctx прерывает обработку, пока блок не сохранён; после сохранения смена
+// вершины и подключение сирот доводятся до конца, чтобы хранилище и дерево
+// блоков не разошлись.
+func (bc *Blockchain) ProcessBlock(ctx context.Context, b *block.Block) (*TipUpdate, error) {
+	if b == nil {
+		return nil, NewInvalidBlockError("block is nil", nil)
+	}
+
+	bc.mu.Lock()
+	defer bc.mu.Unlock()
+	return bc.processBlock(ctx, b)
+}
+
+// processBlock - ProcessBlock под блокировкой записи
+func (bc *Blockchain) processBlock(ctx context.Context, b *block.Block) (*TipUpdate, error) {
+	// пока ждали блокировку, вызывающий мог передумать
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	if bc.tipNode == nil {
+		return nil, NewChainCorruptedError("cannot accept block into empty blockchain", nil)
+	}
+	if b.Hash != nil && bc.orphans.has(b.Hash) {
+		return nil, NewDuplicateBlockError(fmt.Sprintf("block %x already in orphan pool", b.Hash), nil)
+	}
+
+	update, err := bc.acceptBlock(ctx, b)
+	if err != nil {
+		if ErrorCode(err) != ErrBlockNotFound {
+			return nil, err
+		}
+		bc.addOrphan(b)
+		return nil, NewOrphanBlockError(fmt.Sprintf("parent %x of block %x is unknown", b.Header.PreviousHash, b.Hash), err)
+	}
+
+	return bc.connectOrphans(context.WithoutCancel(ctx), b.Hash, update), nil
+}
+
+// acceptBlock проверяет и подключает блок с известным родителем; неизвестный
+// родитель возвращается ошибкой с кодом ErrBlockNotFound
+func (bc *Blockchain) acceptBlock(ctx context.Context, b *block.Block) (*TipUpdate, error) {
+	if b.Hash == nil {
+		return nil, NewInvalidHashError("block hash is nil", nil)
+	}
+	if bc.hasBlock(b.Hash) {
+		return nil, NewDuplicateBlockError(fmt.Sprintf("block %x already known", b.Hash), nil)
+	}
+
+	if err := bc.checkBlockSanity(b); err != nil {
+		return nil, err
+	}
+
+	parent, err := bc.lookupNode(ctx, b.Header.PreviousHash)
+	if err != nil {
+		// сиротой блок становится, только если родителя действительно нет;
+		// сбой чтения или отмена возвращаются как есть
+		if !errors.Is(err, block.ErrBlockNotFound) {
+			return nil, fmt.Errorf("failed to look up parent %x: %w", b.Header.PreviousHash, err)
+		}
+		return nil, NewBlockNotFoundError(fmt.Sprintf("parent %x of block %x not found", b.Header.PreviousHash, b.Hash), err)
+	}
+
+	if err := bc.checkBlockContext(ctx, b, parent); err != nil {
+		return nil, err
+	}
+
+	// продолжение вершины сохраняется вместе с указателем на последний блок
+	if parent == bc.tipNode {
+		if err := bc.store.SaveBlock(ctx, b); err != nil {
+			return nil, fmt.Errorf("failed to save block to store: %w", err)
+		}
+
+		node := bc.addNode(b.StoredHeader(), parent)
+		bc.cache.add(b)
+		update := &TipUpdate{
+			OldTip:     bc.tip,
+			NewTip:     b.Hash,
+			ForkHeight: parent.height,
+			Connected:  []*block.Block{b},
+		}
+		bc.tip = b.Hash
+		bc.tipNode = node
+		bc.notify(update)
+		return update, nil
+	}
+
+	if err := bc.store.PutBlock(ctx, b); err != nil {
+		return nil, fmt.Errorf("failed to save block to store: %w", err)
+	}
+	node := bc.addNode(b.StoredHeader(), parent)
+	bc.cache.add(b)
+
+	// при равной работе остаётся ветка, увиденная первой
+	if node.work().Cmp(bc.tipNode.work()) <= 0 {
+		return nil, nil
+	}
+	// блок уже сохранён: вершину переносим и при отменённом ctx
+	return bc.reorganize(context.WithoutCancel(ctx), node)
+}
+
+// reorganize делает основной ветку с вершиной node. Указатель вершины и
+// индекс высот в хранилище переносятся одной транзакцией, после чего
+// подменяются Tip и вершина дерева.
+func (bc *Blockchain) reorganize(ctx context.Context, node *blockNode) (*TipUpdate, error) {
+	branch, fork, err := bc.branchFrom(ctx, node)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find fork point: %w", err)
+	}
+	if fork < 0 {
+		return nil, NewChainCorruptedError(fmt.Sprintf("block %x doesn`t connect to the main chain", node.hash()), nil)
+	}
+
+	update := &TipUpdate{
+		OldTip:     bc.tip,
+		NewTip:     node.hash(),
+		ForkHeight: fork,
+	}
+	// тела отключаемых блоков читаются до смены индекса высот
+	for height := bc.height(); height > fork; height-- {
+		b, err := bc.blockAt(ctx, height, true)
+		if err != nil {
+			return nil, NewChainCorruptedError(fmt.Sprintf("failed to load main chain block %d", height), err)
+		}
+		update.Disconnected = append(update.Disconnected, b)
+	}
+	for i := len(branch) - 1; i >= 0; i-- {
+		b, err := bc.getBlock(ctx, branch[i].hash())
+		if err != nil {
+			return nil, NewChainCorruptedError(fmt.Sprintf("failed to load branch block %x", branch[i].hash()), err)
+		}
+		update.Connected = append(update.Connected, b)
+	}
+
+	if err := bc.store.SetLastHash(ctx, node.hash()); err != nil {
+		return nil, fmt.Errorf("failed to move tip in store: %w", err)
+	}
+
+	bc.tip = node.hash()
+	bc.tipNode = node
+	bc.notify(update)
+	return update, nil
+}
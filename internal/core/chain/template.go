@@ -0,0 +1,122 @@
+package chain
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/Alex1997377/weave/internal/core/block"
+	"github.com/Alex1997377/weave/internal/core/transaction"
+)
+
+// TipHash возвращает копию хеша вершины
+func (bc *Blockchain) TipHash() []byte {
+	bc.mu.RLock()
+	defer bc.mu.RUnlock()
+	return bc.tipHash()
+}
+
+func (bc *Blockchain) tipHash() []byte {
+	return append([]byte(nil), bc.tip...)
+}
+
+// Height возвращает высоту вершины
+func (bc *Blockchain) Height() int {
+	bc.mu.RLock()
+	defer bc.mu.RUnlock()
+	return bc.height()
+}
+
+func (bc *Blockchain) height() int {
+	if bc.tipNode == nil {
+		return -1
+	}
+	return bc.tipNode.height
+}
+
+// BlockTemplate собирает блок поверх вершины без доказательства работы: coinbase
+// с наградой сети на адрес recipient (если он задан), затем transactions.
+// Nonce и хеш подбирает майнер, готовый блок передаётся в SubmitBlock.
+func (bc *Blockchain) BlockTemplate(ctx context.Context, recipient []byte, transactions []transaction.Transaction) (*block.Block, error) {
+	bc.mu.RLock()
+	defer bc.mu.RUnlock()
+
+	if bc.tipNode == nil {
+		return nil, errors.New("cannot build template on empty blockchain")
+	}
+
+	height := bc.tipNode.height + 1
+
+	txs := make([]transaction.Transaction, 0, len(transactions)+1)
+	if recipient != nil {
+		txs = append(txs, transaction.NewCoinbase(height, recipient, bc.params.BlockReward(height)))
+	}
+	txs = append(txs, transactions...)
+
+	if len(txs) == 0 {
+		return nil, errors.New("non-genesis block must have at least one transaction")
+	}
+	if uint32(len(txs)) > bc.params.MaxTransactions {
+		return nil, fmt.Errorf("transaction count too high: %d (max: %d)", len(txs), bc.params.MaxTransactions)
+	}
+	for i, tx := range txs {
+		if tx == nil {
+			return nil, fmt.Errorf("transaction at index %d is nil", i)
+		}
+		if err := tx.TransactionValidate(); err != nil {
+			return nil, fmt.Errorf("transaction validation failed at index %d: %w", i, err)
+		}
+	}
+
+	work, err := bc.nextWorkRequired(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to calculate difficulty: %w", err)
+	}
+
+	tpl, err := block.NewBlockTemplate(bc.params, txs, bc.tipHash(), height, work, bc.clock)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build block template: %w", err)
+	}
+	if err := bc.applyMinTimestamp(ctx, tpl); err != nil {
+		return nil, err
+	}
+	return tpl, nil
+}
+
+// applyMinTimestamp поднимает время шаблона до MedianTimePast+1, если часы
+// узла отстают от медианы (например, при быстром майнинге в regtest)
+func (bc *Blockchain) applyMinTimestamp(ctx context.Context, tpl *block.Block) error {
+	minTime, err := bc.params.MinTimestamp(tpl.Header.Index, bc.headerSource(ctx))
+	if err != nil {
+		return fmt.Errorf("failed to calculate median time past: %w", err)
+	}
+	if tpl.Header.Timestamp < minTime {
+		tpl.Header.Timestamp = minTime
+	}
+	return nil
+}
+
+// SubmitBlock принимает блок, добытый по шаблону BlockTemplate. Это
+// ProcessBlock с одним дополнительным условием: блок должен продолжать
+// текущую вершину, устаревший блок отклоняется с кодом ErrStaleBlock.
+func (bc *Blockchain) SubmitBlock(ctx context.Context, b *block.Block) error {
+	if b == nil {
+		return NewInvalidBlockError("block is nil", nil)
+	}
+
+	bc.mu.Lock()
+	defer bc.mu.Unlock()
+
+	if bc.tipNode == nil {
+		return errors.New("cannot submit block to empty blockchain")
+	}
+
+	tip := bc.tipNode
+	if !bytes.Equal(b.Header.PreviousHash, tip.hash()) || b.Header.Index != tip.height+1 {
+		return NewStaleBlockError(fmt.Sprintf("block %d doesn`t extend tip %x", b.Header.Index, tip.hash()), nil)
+	}
+
+	_, err := bc.processBlock(ctx, b)
+	return err
+}
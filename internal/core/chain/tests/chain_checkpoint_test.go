@@ -0,0 +1,131 @@
+package tests
+
+import (
+	"bytes"
+	"encoding/hex"
+	"testing"
+
+	"github.com/Alex1997377/weave/internal/core/block"
+	"github.com/Alex1997377/weave/internal/core/chain"
+	"github.com/Alex1997377/weave/internal/core/consensus"
+	"github.com/Alex1997377/weave/internal/core/transaction"
+	"github.com/Alex1997377/weave/internal/tests/helpers"
+)
+
+// connect передаёт блоки ветки в цепочку по порядку
+func connect(t *testing.T, bc *chain.Blockchain, blocks []*block.Block) {
+	t.Helper()
+
+	for _, b := range blocks {
+		if _, err := bc.ProcessBlock(t.Context(), b); err != nil {
+			t.Fatalf("ProcessBlock error: %v", err)
+		}
+	}
+}
+
+func TestBlockchain_CheckpointRejectsConflictingBlock(t *testing.T) {
+	p := consensus.Regtest()
+	bc, err := chain.NewBlockchain(t.Context(), helpers.NewTestRepository(t), p)
+	if err != nil {
+		t.Fatalf("NewBlockchain error: %v", err)
+	}
+	genesis := genesisBranch(t, bc)
+
+	mainBranch := extend(t, p, genesis, 3, 0x10)
+	p.Checkpoints = []consensus.Checkpoint{{Height: 2, Hash: hex.EncodeToString(mainBranch[2].Hash)}}
+
+	// ветка расходится до контрольной точки, пока цепочка её не прошла
+	sideBranch := extend(t, p, genesis, 2, 0x20)
+	connect(t, bc, sideBranch[1:2])
+
+	_, err = bc.ProcessBlock(t.Context(), sideBranch[2])
+	if got := chain.ErrorCode(err); got != chain.ErrCheckpointMismatch {
+		t.Fatalf("ProcessBlock error %v (code %q), want %q", err, got, chain.ErrCheckpointMismatch)
+	}
+
+	connect(t, bc, mainBranch[1:])
+	if bc.Height() != 3 {
+		t.Fatalf("height %d, want 3", bc.Height())
+	}
+	if err := bc.IsValid(t.Context()); err != nil {
+		t.Fatalf("chain is invalid: %v", err)
+	}
+}
+
+func TestBlockchain_CheckpointRejectsDeepFork(t *testing.T) {
+	p := consensus.Regtest()
+	bc, err := chain.NewBlockchain(t.Context(), helpers.NewTestRepository(t), p)
+	if err != nil {
+		t.Fatalf("NewBlockchain error: %v", err)
+	}
+	genesis := genesisBranch(t, bc)
+
+	mainBranch := extend(t, p, genesis, 3, 0x10)
+	p.Checkpoints = []consensus.Checkpoint{{Height: 2, Hash: hex.EncodeToString(mainBranch[2].Hash)}}
+	connect(t, bc, mainBranch[1:])
+
+	fork := mineChild(t, p, genesis, 0x30)
+	_, err = bc.ProcessBlock(t.Context(), fork)
+	if got := chain.ErrorCode(err); got != chain.ErrForkBelowCheckpoint {
+		t.Fatalf("ProcessBlock error %v (code %q), want %q", err, got, chain.ErrForkBelowCheckpoint)
+	}
+
+	// ответвление от самой контрольной точки допустимо
+	if _, err := bc.ProcessBlock(t.Context(), mineChild(t, p, mainBranch[:3], 0x31)); err != nil {
+		t.Fatalf("fork above checkpoint rejected: %v", err)
+	}
+}
+
+func TestBlockchain_StoredChainViolatesCheckpoint(t *testing.T) {
+	repo := helpers.NewTestRepository(t)
+	bc, err := chain.NewBlockchain(t.Context(), repo, consensus.Regtest())
+	if err != nil {
+		t.Fatalf("NewBlockchain error: %v", err)
+	}
+	connect(t, bc, extend(t, bc.Params(), genesisBranch(t, bc), 2, 0x10)[1:])
+
+	p := consensus.Regtest()
+	p.Checkpoints = []consensus.Checkpoint{{Height: 1, Hash: hex.EncodeToString(make([]byte, 32))}}
+	if _, err := chain.NewBlockchain(t.Context(), repo, p); err == nil {
+		t.Fatal("expected stored chain to be rejected by checkpoint")
+	}
+}
+
+func TestBlockchain_AssumeValidSkipsTransactionChecks(t *testing.T) {
+	p := consensus.Regtest()
+	repo := helpers.NewTestRepository(t)
+	bc, err := chain.NewBlockchain(t.Context(), repo, p)
+	if err != nil {
+		t.Fatalf("NewBlockchain error: %v", err)
+	}
+	branch := extend(t, p, genesisBranch(t, bc), 3, 0x10)
+	connect(t, bc, branch[1:])
+
+	// сумма не входит в ID транзакции, поэтому хеши и MerkleRoot не меняются
+	tampered := *branch[1]
+	tampered.Transaction = []transaction.Transaction{
+		tampered.Transaction[0],
+		&transaction.BankTransaction{
+			ID:        bytes.Repeat([]byte{0x10}, 32),
+			Sender:    bytes.Repeat([]byte{0x01}, 32),
+			Recipient: bytes.Repeat([]byte{0x02}, 32),
+		},
+	}
+	if err := repo.PutBlock(t.Context(), &tampered); err != nil {
+		t.Fatalf("PutBlock error: %v", err)
+	}
+	if err := bc.IsValid(t.Context()); err == nil {
+		t.Fatal("IsValid accepted invalid transaction without assume valid")
+	}
+
+	p.AssumeValid = hex.EncodeToString(branch[2].Hash)
+	if err := bc.IsValid(t.Context()); err != nil {
+		t.Fatalf("IsValid below assume valid: %v", err)
+	}
+
+	// блок вне основной цепочки не отключает проверки
+	p.AssumeValid = hex.EncodeToString(make([]byte, 32))
+	if err := bc.IsValid(t.Context()); err == nil {
+		t.Fatal("IsValid accepted invalid transaction with unknown assume valid")
+	}
+}
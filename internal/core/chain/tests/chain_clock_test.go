@@ -0,0 +1,106 @@
+package tests
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Alex1997377/weave/internal/clock"
+	"github.com/Alex1997377/weave/internal/core/block"
+	"github.com/Alex1997377/weave/internal/core/transaction"
+	"github.com/Alex1997377/weave/internal/tests/helpers"
+)
+
+var (
+	fixtureTime   = time.Unix(1735689600, 0)
+	rewardAddress = bytes.Repeat([]byte{0x42}, 32)
+)
+
+// buildChain добывает n блоков по шаблонам цепочки одним воркером, сдвигая часы на минуту
+func buildChain(t *testing.T, n int) [][]byte {
+	t.Helper()
+
+	clk := clock.NewManual(fixtureTime)
+	bc := helpers.NewTestChain(t, helpers.WithClock(clk))
+
+	for i := 0; i < n; i++ {
+		clk.Advance(time.Minute)
+
+		tx := &transaction.BankTransaction{
+			ID:        bytes.Repeat([]byte{byte(i + 1)}, 32),
+			Sender:    bytes.Repeat([]byte{0x01}, 32),
+			Recipient: bytes.Repeat([]byte{0x02}, 32),
+			Amount:    1,
+		}
+		tpl, err := bc.BlockTemplate(t.Context(), rewardAddress, []transaction.Transaction{tx})
+		if err != nil {
+			t.Fatalf("BlockTemplate error: %v", err)
+		}
+		if err := tpl.Mine(context.Background(), block.MineConfig{NumWorkers: 1, Clock: clk}); err != nil {
+			t.Fatalf("Mine error: %v", err)
+		}
+		if err := bc.SubmitBlock(t.Context(), tpl); err != nil {
+			t.Fatalf("SubmitBlock error: %v", err)
+		}
+	}
+
+	if err := bc.IsValid(t.Context()); err != nil {
+		t.Fatalf("chain is invalid: %v", err)
+	}
+
+	blocks := make([][]byte, 0, bc.Height()+1)
+	for _, b := range mainChain(t, bc) {
+		data, err := b.Serialize()
+		if err != nil {
+			t.Fatalf("Serialize error: %v", err)
+		}
+		blocks = append(blocks, data)
+	}
+	return blocks
+}
+
+func TestBlockchain_DeterministicWithClock(t *testing.T) {
+	first := buildChain(t, 3)
+	second := buildChain(t, 3)
+
+	if len(first) != 4 || len(second) != 4 {
+		t.Fatalf("unexpected chain lengths: %d and %d", len(first), len(second))
+	}
+	for i := range first {
+		if !bytes.Equal(first[i], second[i]) {
+			t.Errorf("block %d differs between runs", i)
+		}
+	}
+}
+
+func TestBlockchain_UsesClockForNewBlocks(t *testing.T) {
+	clk := clock.NewManual(fixtureTime.Add(time.Hour))
+	bc := helpers.NewTestChain(t, helpers.WithClock(clk))
+
+	tpl, err := bc.BlockTemplate(t.Context(), rewardAddress, nil)
+	if err != nil {
+		t.Fatalf("BlockTemplate error: %v", err)
+	}
+	if tpl.Header.Timestamp != clk.Now().Unix() || tpl.Hash != nil {
+		t.Errorf("template timestamp = %d, want %d (unmined)", tpl.Header.Timestamp, clk.Now().Unix())
+	}
+
+	clk.Advance(time.Minute)
+	tx := &transaction.BankTransaction{
+		ID:        bytes.Repeat([]byte{0x09}, 32),
+		Sender:    bytes.Repeat([]byte{0x01}, 32),
+		Recipient: bytes.Repeat([]byte{0x02}, 32),
+		Amount:    1,
+	}
+	if err := bc.AddBlock(t.Context(), []transaction.Transaction{tx}); err != nil {
+		t.Fatalf("AddBlock error: %v", err)
+	}
+	tip, err := bc.GetBlockByIndex(t.Context(), bc.Height())
+	if err != nil {
+		t.Fatalf("GetBlockByIndex error: %v", err)
+	}
+	if got := tip.Header.Timestamp; got != clk.Now().Unix() {
+		t.Errorf("AddBlock timestamp = %d, want %d", got, clk.Now().Unix())
+	}
+}
@@ -0,0 +1,159 @@
+package tests
+
+import (
+	"bytes"
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/Alex1997377/weave/internal/clock"
+	"github.com/Alex1997377/weave/internal/core/block"
+	"github.com/Alex1997377/weave/internal/core/chain"
+	"github.com/Alex1997377/weave/internal/core/consensus"
+	"github.com/Alex1997377/weave/internal/core/transaction"
+	"github.com/Alex1997377/weave/internal/tests/helpers"
+)
+
+// readChain выполняет все читающие методы цепочки и проверяет, что каждый
+// видит согласованное состояние
+func readChain(t *testing.T, bc *chain.Blockchain) {
+	height := bc.Height()
+	if height < 0 {
+		t.Error("empty chain")
+		return
+	}
+	// высота не убывает: реорганизация переходит только на более тяжёлую ветку
+	if _, err := bc.GetBlockByIndex(t.Context(), height); err != nil {
+		t.Errorf("GetBlockByIndex(%d) error: %v", height, err)
+	}
+	if b, err := bc.GetBlockByHash(t.Context(), bc.TipHash()); err != nil || b.Header.Index < height {
+		t.Errorf("tip block behind height %d: %v", height, err)
+	}
+	if _, err := bc.GetBalance(t.Context(), rewardAddress); err != nil {
+		t.Errorf("GetBalance error: %v", err)
+	}
+	if err := bc.IsValid(t.Context()); err != nil {
+		t.Errorf("chain is invalid: %v", err)
+	}
+	if tips, err := bc.ChainTips(t.Context()); err != nil || len(tips) == 0 || !tips[0].Active {
+		t.Errorf("no active tip among %d tips: %v", len(tips), err)
+	}
+}
+
+func TestBlockchain_ConcurrentReadsDuringConnect(t *testing.T) {
+	bc := helpers.NewTestChain(t, helpers.WithClock(clock.NewManual(fixtureTime)))
+	p := bc.Params()
+	genesis := genesisBranch(t, bc)
+
+	// основная ветка и более тяжёлая боковая, на которую цепочка перестроится
+	active := extend(t, p, genesis, 6, 0x10)
+	side := extend(t, p, genesis, 8, 0x20)
+
+	var stop atomic.Bool
+	var readers sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		readers.Add(1)
+		go func() {
+			defer readers.Done()
+			for !stop.Load() {
+				readChain(t, bc)
+			}
+		}()
+	}
+
+	for _, b := range append(active[1:], side[1:]...) {
+		if _, err := bc.ProcessBlock(t.Context(), b); err != nil {
+			t.Errorf("ProcessBlock error: %v", err)
+			break
+		}
+	}
+	stop.Store(true)
+	readers.Wait()
+
+	if !sameHashes(hashes(mainChain(t, bc)), hashes(side)) {
+		t.Fatal("chain didn't reorganize to the heavier branch")
+	}
+}
+
+func TestBlockchain_ConcurrentAddBlock(t *testing.T) {
+	bc := helpers.NewTestChain(t, helpers.WithClock(clock.NewManual(fixtureTime)))
+
+	const writers = 4
+	var (
+		wg        sync.WaitGroup
+		connected atomic.Int32
+	)
+	for i := 0; i < writers; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+
+			tx := &transaction.BankTransaction{
+				ID:        bytes.Repeat([]byte{byte(i + 1)}, 32),
+				Sender:    bytes.Repeat([]byte{0x01}, 32),
+				Recipient: bytes.Repeat([]byte{0x02}, 32),
+				Amount:    1,
+			}
+			err := bc.AddBlock(t.Context(), []transaction.Transaction{tx})
+			switch {
+			case err == nil:
+				connected.Add(1)
+			case chain.ErrorCode(err) != chain.ErrStaleBlock:
+				t.Errorf("AddBlock error: %v", err)
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	// каждый подключённый блок продлил цепочку, устаревшие отклонены целиком
+	if got := bc.Height(); got != int(connected.Load()) || got == 0 {
+		t.Fatalf("height %d after %d connected blocks", got, connected.Load())
+	}
+	if err := bc.IsValid(t.Context()); err != nil {
+		t.Fatalf("chain is invalid: %v", err)
+	}
+}
+
+// stallingStore задерживает первое чтение тела блока до закрытия release
+type stallingStore struct {
+	block.BlockStore
+	once    sync.Once
+	entered chan struct{}
+	release chan struct{}
+}
+
+func (s *stallingStore) GetBlock(ctx context.Context, hash []byte) (*block.Block, error) {
+	s.once.Do(func() {
+		close(s.entered)
+		<-s.release
+	})
+	return s.BlockStore.GetBlock(ctx, hash)
+}
+
+func TestBlockchain_FullScanDoesNotBlockWriter(t *testing.T) {
+	ss := &stallingStore{BlockStore: helpers.NewTestRepository(t), entered: make(chan struct{}), release: make(chan struct{})}
+	p := consensus.Regtest()
+	bc, err := chain.NewBlockchainWithClock(t.Context(), ss, p, clock.NewManual(fixtureTime))
+	if err != nil {
+		t.Fatalf("NewBlockchain error: %v", err)
+	}
+	branch := extend(t, p, genesisBranch(t, bc), 2, 0x10)
+	connect(t, bc, branch[1:2])
+
+	scanned := make(chan error, 1)
+	go func() { scanned <- bc.IsValid(t.Context()) }()
+	<-ss.entered
+
+	// проверка стоит на чтении блока, а новый блок подключается
+	if _, err := bc.ProcessBlock(t.Context(), branch[2]); err != nil {
+		t.Fatalf("ProcessBlock error: %v", err)
+	}
+	close(ss.release)
+	if err := <-scanned; err != nil {
+		t.Fatalf("IsValid error: %v", err)
+	}
+	if bc.Height() != 2 {
+		t.Fatalf("height %d, want 2", bc.Height())
+	}
+}
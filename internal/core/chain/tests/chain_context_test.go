@@ -0,0 +1,78 @@
+package tests
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/Alex1997377/weave/internal/clock"
+	"github.com/Alex1997377/weave/internal/core/chain"
+	"github.com/Alex1997377/weave/internal/core/consensus"
+	"github.com/Alex1997377/weave/internal/core/transaction"
+	"github.com/Alex1997377/weave/internal/tests/helpers"
+)
+
+func canceledContext() context.Context {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	return ctx
+}
+
+func TestBlockchain_CanceledProcessBlockLeavesChainUntouched(t *testing.T) {
+	bc := helpers.NewTestChain(t, helpers.WithClock(clock.NewManual(fixtureTime)))
+	branch := extend(t, bc.Params(), genesisBranch(t, bc), 2, 0x10)
+
+	for _, b := range branch[1:] {
+		if _, err := bc.ProcessBlock(canceledContext(), b); !errors.Is(err, context.Canceled) {
+			t.Fatalf("ProcessBlock error = %v, want context.Canceled", err)
+		}
+		if bc.HasBlock(b.Hash) || bc.IsOrphan(b.Hash) {
+			t.Fatalf("canceled block %d was accepted", b.Header.Index)
+		}
+	}
+	if bc.Height() != 0 {
+		t.Fatalf("height %d after canceled blocks", bc.Height())
+	}
+
+	// после отмены те же блоки принимаются как обычно
+	connect(t, bc, branch[1:])
+	if bc.Height() != 2 {
+		t.Fatalf("height %d, want 2", bc.Height())
+	}
+}
+
+func TestBlockchain_CanceledAddBlock(t *testing.T) {
+	bc := helpers.NewTestChain(t, helpers.WithClock(clock.NewManual(fixtureTime)))
+
+	err := bc.AddBlock(canceledContext(), []transaction.Transaction{transaction.NewCoinbase(1, rewardAddress, 1)})
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("AddBlock error = %v, want context.Canceled", err)
+	}
+	if bc.Height() != 0 {
+		t.Fatalf("height %d after canceled AddBlock", bc.Height())
+	}
+}
+
+func TestBlockchain_CanceledReads(t *testing.T) {
+	repo := helpers.NewTestRepository(t)
+	p := consensus.Regtest()
+	bc, err := chain.NewBlockchain(t.Context(), repo, p)
+	if err != nil {
+		t.Fatalf("NewBlockchain error: %v", err)
+	}
+	connect(t, bc, extend(t, p, genesisBranch(t, bc), 3, 0x10)[1:])
+
+	ctx := canceledContext()
+	if _, err := chain.NewBlockchain(ctx, repo, p); !errors.Is(err, context.Canceled) {
+		t.Errorf("NewBlockchain error = %v, want context.Canceled", err)
+	}
+	if err := bc.IsValid(ctx); !errors.Is(err, context.Canceled) {
+		t.Errorf("IsValid error = %v, want context.Canceled", err)
+	}
+	if _, err := bc.GetBalance(ctx, rewardAddress); !errors.Is(err, context.Canceled) {
+		t.Errorf("GetBalance error = %v, want context.Canceled", err)
+	}
+	if _, err := bc.GetBlockByIndex(ctx, 1); !errors.Is(err, context.Canceled) {
+		t.Errorf("GetBlockByIndex error = %v, want context.Canceled", err)
+	}
+}
@@ -0,0 +1,210 @@
+package tests
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/Alex1997377/weave/internal/clock"
+	"github.com/Alex1997377/weave/internal/core/chain"
+	"github.com/Alex1997377/weave/internal/tests/helpers"
+)
+
+// drain читает из подписки все уже доставленные события
+func drain(sub *chain.Subscription) []chain.Event {
+	var events []chain.Event
+	for {
+		select {
+		case ev := <-sub.C:
+			events = append(events, ev)
+		default:
+			return events
+		}
+	}
+}
+
+func eventTypes(events []chain.Event) []chain.EventType {
+	types := make([]chain.EventType, len(events))
+	for i, ev := range events {
+		types[i] = ev.Type
+	}
+	return types
+}
+
+func sameTypes(got, want []chain.EventType) bool {
+	if len(got) != len(want) {
+		return false
+	}
+	for i := range got {
+		if got[i] != want[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func TestBlockchain_EventsOnConnect(t *testing.T) {
+	bc := helpers.NewTestChain(t, helpers.WithClock(clock.NewManual(fixtureTime)))
+	sub := bc.Subscribe(chain.SubscribeOptions{})
+	defer sub.Close()
+
+	b := mineChild(t, bc.Params(), genesisBranch(t, bc), 0x10)
+	if _, err := bc.ProcessBlock(t.Context(), b); err != nil {
+		t.Fatalf("ProcessBlock error: %v", err)
+	}
+
+	events := drain(sub)
+	want := []chain.EventType{chain.EventBlockConnected, chain.EventTxConfirmed, chain.EventTxConfirmed, chain.EventTipChanged}
+	if !sameTypes(eventTypes(events), want) {
+		t.Fatalf("events %v, want %v", eventTypes(events), want)
+	}
+	if !bytes.Equal(events[0].Hash, b.Hash) || events[0].Height != 1 {
+		t.Errorf("connected event for %x at %d", events[0].Hash, events[0].Height)
+	}
+	if !bytes.Equal(events[2].Tx.TransactionGetID(), b.Transaction[1].TransactionGetID()) {
+		t.Error("confirmed transaction doesn't match block")
+	}
+	if tip := events[3]; !bytes.Equal(tip.Hash, bc.TipHash()) || tip.Update == nil || tip.Update.IsReorg() {
+		t.Errorf("unexpected tip event: %+v", tip)
+	}
+}
+
+func TestBlockchain_EventBlocksAreCopies(t *testing.T) {
+	bc := helpers.NewTestChain(t, helpers.WithClock(clock.NewManual(fixtureTime)))
+	first := bc.Subscribe(chain.SubscribeOptions{Types: []chain.EventType{chain.EventBlockConnected}})
+	defer first.Close()
+	second := bc.Subscribe(chain.SubscribeOptions{Types: []chain.EventType{chain.EventBlockConnected}})
+	defer second.Close()
+
+	b := mineChild(t, bc.Params(), genesisBranch(t, bc), 0x10)
+	if _, err := bc.ProcessBlock(t.Context(), b); err != nil {
+		t.Fatalf("ProcessBlock error: %v", err)
+	}
+	hash := append([]byte(nil), b.Hash...)
+
+	// подписчик портит свой блок - это не видно ни вызывающему, ни другому подписчику
+	got := drain(first)
+	if len(got) != 1 || got[0].Block == b {
+		t.Fatal("subscriber received the caller's block")
+	}
+	got[0].Block.Hash[0] ^= 0xFF
+	got[0].Block.Transaction = nil
+
+	other := drain(second)
+	if len(other) != 1 || !bytes.Equal(other[0].Block.Hash, hash) || len(other[0].Block.Transaction) != len(b.Transaction) {
+		t.Fatal("change by one subscriber reached another subscriber")
+	}
+	if !bytes.Equal(b.Hash, hash) || len(b.Transaction) == 0 {
+		t.Fatal("change by a subscriber reached the caller's block")
+	}
+}
+
+func TestBlockchain_EventsOnReorg(t *testing.T) {
+	bc := helpers.NewTestChain(t, helpers.WithClock(clock.NewManual(fixtureTime)))
+	p := bc.Params()
+	genesis := genesisBranch(t, bc)
+
+	connect(t, bc, extend(t, p, genesis, 1, 0x10)[1:])
+	side := extend(t, p, genesis, 2, 0x20)
+
+	sub := bc.Subscribe(chain.SubscribeOptions{
+		Types: []chain.EventType{chain.EventBlockConnected, chain.EventBlockDisconnected, chain.EventTipChanged},
+	})
+	defer sub.Close()
+	connect(t, bc, side[1:])
+
+	want := []chain.EventType{chain.EventBlockDisconnected, chain.EventBlockConnected, chain.EventBlockConnected, chain.EventTipChanged}
+	events := drain(sub)
+	if !sameTypes(eventTypes(events), want) {
+		t.Fatalf("events %v, want %v", eventTypes(events), want)
+	}
+	if !events[3].Update.IsReorg() || events[3].Height != 2 {
+		t.Errorf("tip event doesn't describe the reorg: %+v", events[3])
+	}
+}
+
+func TestBlockchain_EventOverflow(t *testing.T) {
+	bc := helpers.NewTestChain(t, helpers.WithClock(clock.NewManual(fixtureTime)))
+	p := bc.Params()
+
+	newest := bc.Subscribe(chain.SubscribeOptions{Buffer: 1, Overflow: chain.OverflowDropNewest, Types: []chain.EventType{chain.EventTipChanged}})
+	oldest := bc.Subscribe(chain.SubscribeOptions{Buffer: 1, Overflow: chain.OverflowDropOldest, Types: []chain.EventType{chain.EventTipChanged}})
+	defer newest.Close()
+	defer oldest.Close()
+
+	branch := extend(t, p, genesisBranch(t, bc), 3, 0x10)
+	connect(t, bc, branch[1:])
+
+	if ev := <-newest.C; ev.Height != 1 || newest.Dropped() != 2 {
+		t.Errorf("drop newest kept height %d, dropped %d", ev.Height, newest.Dropped())
+	}
+	if ev := <-oldest.C; ev.Height != 3 || oldest.Dropped() != 2 {
+		t.Errorf("drop oldest kept height %d, dropped %d", ev.Height, oldest.Dropped())
+	}
+}
+
+func TestBlockchain_EventBlockingSubscriber(t *testing.T) {
+	bc := helpers.NewTestChain(t, helpers.WithClock(clock.NewManual(fixtureTime)))
+	branch := extend(t, bc.Params(), genesisBranch(t, bc), 3, 0x10)
+
+	sub := bc.Subscribe(chain.SubscribeOptions{Buffer: 1, Overflow: chain.OverflowBlock, Types: []chain.EventType{chain.EventBlockConnected}})
+	defer sub.Close()
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for _, b := range branch[1:] {
+			if _, err := bc.ProcessBlock(t.Context(), b); err != nil {
+				t.Errorf("ProcessBlock error: %v", err)
+				return
+			}
+		}
+	}()
+
+	// медленный подписчик получает все события, цепочка его ждёт
+	for h := 1; h <= 3; h++ {
+		select {
+		case ev := <-sub.C:
+			if ev.Height != h {
+				t.Fatalf("event height %d, want %d", ev.Height, h)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatal("timed out waiting for event")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	<-done
+
+	if sub.Dropped() != 0 {
+		t.Errorf("blocking subscriber dropped %d events", sub.Dropped())
+	}
+}
+
+func TestBlockchain_SubscribeFuncAndClose(t *testing.T) {
+	bc := helpers.NewTestChain(t, helpers.WithClock(clock.NewManual(fixtureTime)))
+	branch := extend(t, bc.Params(), genesisBranch(t, bc), 2, 0x10)
+
+	got := make(chan int, 4)
+	sub := bc.SubscribeFunc(chain.SubscribeOptions{Types: []chain.EventType{chain.EventTipChanged}}, func(ev chain.Event) {
+		got <- ev.Height
+	})
+
+	connect(t, bc, branch[1:2])
+	select {
+	case h := <-got:
+		if h != 1 {
+			t.Fatalf("callback height %d, want 1", h)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("callback not called")
+	}
+
+	sub.Close()
+	sub.Close()
+	connect(t, bc, branch[2:])
+	select {
+	case h := <-got:
+		t.Fatalf("callback called after Close with height %d", h)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
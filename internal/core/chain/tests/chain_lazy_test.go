@@ -0,0 +1,176 @@
+package tests
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"github.com/Alex1997377/weave/internal/clock"
+	"github.com/Alex1997377/weave/internal/core/chain"
+	"github.com/Alex1997377/weave/internal/core/consensus"
+	"github.com/Alex1997377/weave/internal/store"
+	"github.com/Alex1997377/weave/internal/tests/helpers"
+	"github.com/dgraph-io/badger/v4"
+)
+
+func TestBlockchain_RebuildsMissingHeightIndex(t *testing.T) {
+	db := helpers.OpenTestDB(t)
+	repo := store.NewRepository(db)
+
+	p := consensus.Regtest()
+	bc := helpers.NewTestChain(t, helpers.WithStore(repo), helpers.WithParams(p))
+	branch := extend(t, p, genesisBranch(t, bc), 4, 0x10)
+
+	// хранилище старого формата: блоки и указатель вершины без индекса высот
+	for _, b := range branch[1:] {
+		if err := repo.PutBlock(t.Context(), b); err != nil {
+			t.Fatalf("PutBlock error: %v", err)
+		}
+	}
+	if err := db.Update(func(txn *badger.Txn) error {
+		return txn.Set([]byte("l"), branch[4].Hash)
+	}); err != nil {
+		t.Fatalf("failed to set last hash: %v", err)
+	}
+
+	reopened, err := chain.NewBlockchain(t.Context(), repo, p)
+	if err != nil {
+		t.Fatalf("reopen error: %v", err)
+	}
+	if reopened.Height() != 4 || !sameHashes(hashes(mainChain(t, reopened)), hashes(branch)) {
+		t.Fatal("reopened chain doesn't follow the stored tip")
+	}
+	if hash, err := repo.GetHashByHeight(t.Context(), 2); err != nil || !bytes.Equal(hash, branch[2].Hash) {
+		t.Fatalf("height index not rebuilt: %x, %v", hash, err)
+	}
+	if err := reopened.IsValid(t.Context()); err != nil {
+		t.Fatalf("chain is invalid: %v", err)
+	}
+}
+
+func TestBlockchain_LoadReadsHeadersOnly(t *testing.T) {
+	fs := &failingStore{BlockStore: helpers.NewTestRepository(t)}
+	p := consensus.Regtest()
+	bc, err := chain.NewBlockchain(t.Context(), fs, p)
+	if err != nil {
+		t.Fatalf("NewBlockchain error: %v", err)
+	}
+	genesis := genesisBranch(t, bc)
+	connect(t, bc, extend(t, p, genesis, 2, 0x20)[1:])
+	branch := extend(t, p, genesis, 4, 0x10)
+	connect(t, bc, branch[1:])
+
+	// при загрузке тела не читаются: любое чтение тела завершится сбоем
+	fs.getErr = errors.New("body read")
+	reopened, err := chain.NewBlockchain(t.Context(), fs, p)
+	if err != nil {
+		t.Fatalf("reopen read block bodies: %v", err)
+	}
+	if reopened.Height() != 4 || !bytes.Equal(reopened.TipHash(), branch[4].Hash) {
+		t.Fatalf("reopened chain at height %d, want 4", reopened.Height())
+	}
+	if _, err := reopened.GetBlockByIndex(t.Context(), 2); !errors.Is(err, fs.getErr) {
+		t.Fatalf("GetBlockByIndex error = %v, want body read", err)
+	}
+
+	// тела читаются по требованию
+	fs.getErr = nil
+	if !sameHashes(hashes(mainChain(t, reopened)), hashes(branch)) {
+		t.Fatal("reopened chain doesn't follow the stored tip")
+	}
+}
+
+func TestBlockchain_BlockCacheIsBounded(t *testing.T) {
+	repo := helpers.NewTestRepository(t)
+	p := consensus.Regtest()
+	bc, err := chain.NewBlockchain(t.Context(), repo, p)
+	if err != nil {
+		t.Fatalf("NewBlockchain error: %v", err)
+	}
+	bc.SetBlockCacheSize(2)
+
+	branch := extend(t, p, genesisBranch(t, bc), 5, 0x10)
+	connect(t, bc, branch[1:])
+
+	for h := 0; h <= bc.Height(); h++ {
+		b, err := bc.GetBlockByIndex(t.Context(), h)
+		if err != nil {
+			t.Fatalf("GetBlockByIndex(%d) error: %v", h, err)
+		}
+		if !bytes.Equal(b.Hash, branch[h].Hash) {
+			t.Fatalf("block %d doesn't match main chain", h)
+		}
+	}
+	if n := bc.CachedBlocks(); n != 2 {
+		t.Errorf("cached blocks = %d, want 2", n)
+	}
+
+	// полная проверка идёт в обход кеша
+	if err := bc.IsValid(t.Context()); err != nil {
+		t.Fatalf("chain is invalid: %v", err)
+	}
+	if n := bc.CachedBlocks(); n != 2 {
+		t.Errorf("cached blocks after IsValid = %d, want 2", n)
+	}
+}
+
+func TestBlockchain_HeightIndexFollowsReorg(t *testing.T) {
+	repo := helpers.NewTestRepository(t)
+	p := consensus.Regtest()
+	bc, err := chain.NewBlockchain(t.Context(), repo, p)
+	if err != nil {
+		t.Fatalf("NewBlockchain error: %v", err)
+	}
+	genesis := genesisBranch(t, bc)
+
+	connect(t, bc, extend(t, p, genesis, 3, 0x10)[1:])
+	heavy := extend(t, p, genesis, 4, 0x20)
+	connect(t, bc, heavy[1:])
+
+	for h, b := range heavy {
+		hash, err := repo.GetHashByHeight(t.Context(), h)
+		if err != nil || !bytes.Equal(hash, b.Hash) {
+			t.Fatalf("height %d indexed as %x, want %x (%v)", h, hash, b.Hash, err)
+		}
+	}
+
+	// перенос вершины ниже удаляет записи над ней
+	if err := repo.SetLastHash(t.Context(), heavy[2].Hash); err != nil {
+		t.Fatalf("SetLastHash error: %v", err)
+	}
+	if _, err := repo.GetHashByHeight(t.Context(), 3); err != store.ErrBlockNotFound {
+		t.Fatalf("height above tip still indexed: %v", err)
+	}
+	reopened, err := chain.NewBlockchain(t.Context(), repo, p)
+	if err != nil {
+		t.Fatalf("reopen error: %v", err)
+	}
+	if !sameHashes(hashes(mainChain(t, reopened)), hashes(heavy[:3])) {
+		t.Fatal("reopened chain doesn't follow the moved tip")
+	}
+}
+
+func TestBlockchain_ReturnedBlocksAreCopies(t *testing.T) {
+	bc := helpers.NewTestChain(t, helpers.WithClock(clock.NewManual(fixtureTime)))
+	branch := extend(t, bc.Params(), genesisBranch(t, bc), 1, 0x10)
+	submitted := branch[1]
+	connect(t, bc, branch[1:])
+
+	// ни переданный блок, ни полученный из цепочки не разделяют память с кешем
+	submitted.Header.Nonce++
+	got, err := bc.GetBlockByIndex(t.Context(), 1)
+	if err != nil {
+		t.Fatalf("GetBlockByIndex error: %v", err)
+	}
+	got.Header.Nonce++
+	got.Hash[0] ^= 0xFF
+	got.Transaction = nil
+
+	again, err := bc.GetBlockByHash(t.Context(), bc.TipHash())
+	if err != nil {
+		t.Fatalf("GetBlockByHash error: %v", err)
+	}
+	if err := again.Validate(); err != nil || len(again.Transaction) == 0 {
+		t.Fatalf("cached block was modified through a returned pointer: %v", err)
+	}
+}
@@ -0,0 +1,114 @@
+package tests
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/Alex1997377/weave/internal/clock"
+	"github.com/Alex1997377/weave/internal/core/block"
+	"github.com/Alex1997377/weave/internal/core/chain"
+	"github.com/Alex1997377/weave/internal/tests/helpers"
+)
+
+func TestBlockchain_OrphansConnectOutOfOrder(t *testing.T) {
+	bc := helpers.NewTestChain(t, helpers.WithClock(clock.NewManual(fixtureTime)))
+	p := bc.Params()
+
+	var requested [][]byte
+	bc.SetOrphanConfig(chain.OrphanConfig{
+		OnMissingParent: func(hash []byte) { requested = append(requested, hash) },
+	})
+
+	branch := extend(t, p, genesisBranch(t, bc), 3, 0x10)
+	b1, b2, b3 := branch[1], branch[2], branch[3]
+
+	if _, err := bc.ProcessBlock(t.Context(), b3); chain.ErrorCode(err) != chain.ErrOrphanBlock {
+		t.Fatalf("b3: %v, want %s", err, chain.ErrOrphanBlock)
+	}
+	if _, err := bc.ProcessBlock(t.Context(), b2); chain.ErrorCode(err) != chain.ErrOrphanBlock {
+		t.Fatalf("b2: %v, want %s", err, chain.ErrOrphanBlock)
+	}
+	if _, err := bc.ProcessBlock(t.Context(), b3); chain.ErrorCode(err) != chain.ErrDuplicateBlock {
+		t.Errorf("repeated orphan: %v, want %s", err, chain.ErrDuplicateBlock)
+	}
+
+	if !sameHashes(requested, [][]byte{b2.Hash, b1.Hash}) {
+		t.Errorf("unexpected parent requests: %x", requested)
+	}
+	if missing := bc.MissingParents(); !sameHashes(missing, [][]byte{b1.Hash}) {
+		t.Errorf("MissingParents = %x, want only the root's parent", missing)
+	}
+	if bc.OrphanCount() != 2 || !bc.IsOrphan(b2.Hash) || bc.Height() != 0 {
+		t.Fatalf("orphans must wait without touching the chain")
+	}
+
+	update, err := bc.ProcessBlock(t.Context(), b1)
+	if err != nil {
+		t.Fatalf("b1: %v", err)
+	}
+	if !sameHashes(hashes(update.Connected), hashes(branch[1:])) || update.IsReorg() {
+		t.Errorf("update must connect the whole branch: %+v", update)
+	}
+	if !bytes.Equal(update.NewTip, b3.Hash) || bc.OrphanCount() != 0 || bc.Height() != 3 {
+		t.Fatalf("orphans were not connected: height=%d orphans=%d", bc.Height(), bc.OrphanCount())
+	}
+	if err := bc.IsValid(t.Context()); err != nil {
+		t.Fatalf("chain invalid after connecting orphans: %v", err)
+	}
+}
+
+func TestBlockchain_OrphanLimits(t *testing.T) {
+	clk := clock.NewManual(fixtureTime)
+	bc := helpers.NewTestChain(t, helpers.WithClock(clk))
+	p := bc.Params()
+	bc.SetOrphanConfig(chain.OrphanConfig{MaxOrphans: 2, MaxAge: time.Minute})
+
+	// три сироты от разных недостающих родителей
+	var orphans [][]byte
+	for i := 0; i < 3; i++ {
+		branch := extend(t, p, genesisBranch(t, bc), 2, byte(0x20+0x10*i))
+		if _, err := bc.ProcessBlock(t.Context(), branch[2]); chain.ErrorCode(err) != chain.ErrOrphanBlock {
+			t.Fatalf("orphan %d: %v", i, err)
+		}
+		orphans = append(orphans, branch[2].Hash)
+	}
+
+	if bc.OrphanCount() != 2 || bc.IsOrphan(orphans[0]) || !bc.IsOrphan(orphans[2]) {
+		t.Fatalf("oldest orphan must be evicted when pool is full")
+	}
+
+	clk.Advance(2 * time.Minute)
+	if missing := bc.MissingParents(); len(missing) != 0 || bc.OrphanCount() != 0 {
+		t.Errorf("expired orphans must be dropped: %d left", bc.OrphanCount())
+	}
+}
+
+func TestBlockchain_InvalidOrphanDropsDescendants(t *testing.T) {
+	bc := helpers.NewTestChain(t, helpers.WithClock(clock.NewManual(fixtureTime)))
+	p := bc.Params()
+
+	branch := extend(t, p, genesisBranch(t, bc), 1, 0x60)
+	b1 := branch[1]
+
+	// bad ссылается на b1, но имеет высоту 5: без родителя это не видно
+	fakeParent := *b1
+	fakeParent.Header.Index = 4
+	g := branch[0]
+	fake := []*block.Block{g, g, g, g, &fakeParent}
+	bad := mineChild(t, p, fake, 0x61)
+	child := mineChild(t, p, append(fake, bad), 0x62)
+
+	for _, b := range []*block.Block{child, bad} {
+		if _, err := bc.ProcessBlock(t.Context(), b); chain.ErrorCode(err) != chain.ErrOrphanBlock {
+			t.Fatalf("expected orphan, got %v", err)
+		}
+	}
+
+	if _, err := bc.ProcessBlock(t.Context(), b1); err != nil {
+		t.Fatalf("ProcessBlock(b1) error: %v", err)
+	}
+	if bc.Height() != 1 || bc.OrphanCount() != 0 || bc.HasBlock(bad.Hash) || bc.HasBlock(child.Hash) {
+		t.Errorf("invalid orphan and its descendants must be dropped: height=%d orphans=%d", bc.Height(), bc.OrphanCount())
+	}
+}
@@ -0,0 +1,217 @@
+package tests
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/Alex1997377/weave/internal/clock"
+	"github.com/Alex1997377/weave/internal/core/block"
+	"github.com/Alex1997377/weave/internal/core/chain"
+	"github.com/Alex1997377/weave/internal/core/consensus"
+	"github.com/Alex1997377/weave/internal/core/transaction"
+	"github.com/Alex1997377/weave/internal/crypto/pow"
+	"github.com/Alex1997377/weave/internal/tests/helpers"
+)
+
+// remine заново подбирает nonce после изменения блока
+func remine(t *testing.T, b *block.Block) {
+	t.Helper()
+
+	b.Header.Nonce = 0
+	if err := b.Mine(context.Background(), block.MineConfig{NumWorkers: 1}); err != nil {
+		t.Fatalf("Mine error: %v", err)
+	}
+}
+
+func TestBlockchain_ProcessBlockAcceptsExternalBlock(t *testing.T) {
+	producer := helpers.NewTestChain(t, helpers.WithClock(clock.NewManual(fixtureTime)))
+	consumer := helpers.NewTestChain(t, helpers.WithClock(clock.NewManual(fixtureTime)))
+
+	// блок добыт другой цепочкой и передан как есть
+	if err := producer.AddBlock(t.Context(), []transaction.Transaction{transaction.NewCoinbase(1, rewardAddress, 1)}); err != nil {
+		t.Fatalf("AddBlock error: %v", err)
+	}
+	external := mainChain(t, producer)[1]
+
+	update, err := consumer.ProcessBlock(t.Context(), external)
+	if err != nil {
+		t.Fatalf("ProcessBlock error: %v", err)
+	}
+	if update == nil || !bytes.Equal(update.NewTip, external.Hash) || consumer.Height() != 1 {
+		t.Fatalf("external block was not connected: %+v", update)
+	}
+	if !bytes.Equal(consumer.TipHash(), producer.TipHash()) {
+		t.Error("chains diverged after processing the same block")
+	}
+}
+
+func TestBlockchain_ProcessBlockRejectionCodes(t *testing.T) {
+	bc := helpers.NewTestChain(t, helpers.WithClock(clock.NewManual(fixtureTime)))
+	p := bc.Params()
+	genesis := genesisBranch(t, bc)
+
+	cases := []struct {
+		name   string
+		mutate func(b *block.Block)
+		code   string
+	}{
+		{
+			name:   "hash mismatch",
+			mutate: func(b *block.Block) { b.Hash = bytes.Repeat([]byte{0xEE}, 32) },
+			code:   chain.ErrInvalidHash,
+		},
+		{
+			name: "non-positive timestamp",
+			mutate: func(b *block.Block) {
+				b.Header.Timestamp = 0
+			},
+			code: chain.ErrInvalidTimestamp,
+		},
+		{
+			name: "wrong height",
+			mutate: func(b *block.Block) {
+				b.Header.Index = 5
+				b.Transaction[0].(*transaction.CoinbaseTransaction).Height = 5
+				b.SetMerkleRoot()
+				remine(t, b)
+			},
+			code: chain.ErrInvalidHeight,
+		},
+		{
+			name: "unexpected target",
+			mutate: func(b *block.Block) {
+				b.Header.Bits = pow.DifficultyToTarget(4).Compact()
+				remine(t, b)
+			},
+			code: chain.ErrInvalidDifficulty,
+		},
+		{
+			name: "insufficient proof of work",
+			mutate: func(b *block.Block) {
+				b.Header.Bits = pow.DifficultyToTarget(40).Compact()
+				b.Hash, _ = b.CalculateHash()
+			},
+			code: chain.ErrInvalidProofOfWork,
+		},
+		{
+			name: "merkle root mismatch",
+			mutate: func(b *block.Block) {
+				b.Transaction = b.Transaction[:1]
+				remine(t, b)
+			},
+			code: chain.ErrInvalidMerkleRoot,
+		},
+		{
+			name: "invalid transaction",
+			mutate: func(b *block.Block) {
+				b.Transaction[1].(*transaction.BankTransaction).Recipient = nil
+				b.Header.MerkleRoot = b.CalculateMerkleRoot()
+				remine(t, b)
+			},
+			code: chain.ErrInvalidTransaction,
+		},
+		{
+			name: "coinbase overpays",
+			mutate: func(b *block.Block) {
+				b.Transaction[0].(*transaction.CoinbaseTransaction).Amount = p.BlockReward(1) * 2
+				b.SetMerkleRoot()
+				remine(t, b)
+			},
+			code: chain.ErrInvalidCoinbase,
+		},
+		{
+			name: "coinbase not first",
+			mutate: func(b *block.Block) {
+				b.Transaction[0], b.Transaction[1] = b.Transaction[1], b.Transaction[0]
+				b.SetMerkleRoot()
+				remine(t, b)
+			},
+			code: chain.ErrInvalidCoinbase,
+		},
+	}
+
+	for i, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			b := mineChild(t, p, genesis, byte(0x70+i))
+			tc.mutate(b)
+
+			_, err := bc.ProcessBlock(t.Context(), b)
+			if got := chain.ErrorCode(err); got != tc.code {
+				t.Fatalf("ProcessBlock error %v (code %q), want code %q", err, got, tc.code)
+			}
+			if bc.Height() != 0 || bc.OrphanCount() != 0 {
+				t.Error("rejected block must not change the chain")
+			}
+		})
+	}
+}
+
+func TestBlockchain_ProcessBlockTooLarge(t *testing.T) {
+	p := consensus.Regtest()
+	p.MaxBlockSize = 512
+	bc := helpers.NewTestChain(t, helpers.WithParams(p))
+
+	b := mineChild(t, p, genesisBranch(t, bc), 0x90)
+	for i := 0; i < 8; i++ {
+		b.Transaction = append(b.Transaction, &transaction.BankTransaction{
+			ID:        bytes.Repeat([]byte{byte(0xA0 + i)}, 32),
+			Sender:    bytes.Repeat([]byte{0x01}, 32),
+			Recipient: bytes.Repeat([]byte{0x02}, 32),
+			Amount:    1,
+		})
+	}
+	b.SetMerkleRoot()
+	remine(t, b)
+
+	if _, err := bc.ProcessBlock(t.Context(), b); chain.ErrorCode(err) != chain.ErrBlockTooLarge {
+		t.Fatalf("ProcessBlock error %v, want code %s", err, chain.ErrBlockTooLarge)
+	}
+}
+
+// failingStore - хранилище, чтение тел (getErr) или заголовков (headerErr)
+// из которого завершается сбоем
+type failingStore struct {
+	block.BlockStore
+	getErr    error
+	headerErr error
+}
+
+func (s *failingStore) GetBlock(ctx context.Context, hash []byte) (*block.Block, error) {
+	if s.getErr != nil {
+		return nil, s.getErr
+	}
+	return s.BlockStore.GetBlock(ctx, hash)
+}
+
+func (s *failingStore) GetHeader(ctx context.Context, hash []byte) (*block.StoredHeader, error) {
+	if s.headerErr != nil {
+		return nil, s.headerErr
+	}
+	return s.BlockStore.GetHeader(ctx, hash)
+}
+
+func TestBlockchain_ProcessBlockStoreFailureIsNotOrphan(t *testing.T) {
+	fs := &failingStore{BlockStore: helpers.NewTestRepository(t)}
+	bc, err := chain.NewBlockchain(t.Context(), fs, consensus.Regtest())
+	if err != nil {
+		t.Fatalf("NewBlockchain error: %v", err)
+	}
+	branch := extend(t, bc.Params(), genesisBranch(t, bc), 2, 0x10)
+
+	// родителя нет в дереве, и прочитать его из хранилища не удаётся
+	fs.headerErr = errors.New("disk failure")
+	_, err = bc.ProcessBlock(t.Context(), branch[2])
+	if !errors.Is(err, fs.headerErr) || chain.ErrorCode(err) == chain.ErrOrphanBlock {
+		t.Fatalf("ProcessBlock error = %v, want store failure", err)
+	}
+	if bc.IsOrphan(branch[2].Hash) {
+		t.Fatal("block with unreadable parent went to the orphan pool")
+	}
+
+	fs.headerErr = nil
+	if _, err := bc.ProcessBlock(t.Context(), branch[2]); chain.ErrorCode(err) != chain.ErrOrphanBlock {
+		t.Fatalf("ProcessBlock error = %v, want orphan", err)
+	}
+}
@@ -0,0 +1,207 @@
+package tests
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Alex1997377/weave/internal/clock"
+	"github.com/Alex1997377/weave/internal/core/block"
+	"github.com/Alex1997377/weave/internal/core/chain"
+	"github.com/Alex1997377/weave/internal/core/consensus"
+	"github.com/Alex1997377/weave/internal/core/header"
+	"github.com/Alex1997377/weave/internal/core/transaction"
+	"github.com/Alex1997377/weave/internal/tests/helpers"
+)
+
+// mineChild добывает потомка последнего блока ветки branch (от генезиса).
+// seed отличает транзакции, чтобы ветки с одинаковыми родителями расходились.
+func mineChild(t *testing.T, p *consensus.Params, branch []*block.Block, seed byte) *block.Block {
+	t.Helper()
+
+	parent := branch[len(branch)-1]
+	height := parent.Header.Index + 1
+
+	work, err := p.NextWorkRequired(height, func(h int) (*header.Header, error) {
+		return &branch[h].Header, nil
+	})
+	if err != nil {
+		t.Fatalf("NextWorkRequired error: %v", err)
+	}
+
+	txs := []transaction.Transaction{
+		transaction.NewCoinbase(height, rewardAddress, p.BlockReward(height)),
+		&transaction.BankTransaction{
+			ID:        bytes.Repeat([]byte{seed}, 32),
+			Sender:    bytes.Repeat([]byte{0x01}, 32),
+			Recipient: bytes.Repeat([]byte{0x02}, 32),
+			Amount:    1,
+		},
+	}
+
+	clk := clock.NewManual(time.Unix(parent.Header.Timestamp+60, 0))
+	b, err := block.NewBlockTemplate(p, txs, parent.Hash, height, work, clk)
+	if err != nil {
+		t.Fatalf("NewBlockTemplate error: %v", err)
+	}
+	if err := b.Mine(context.Background(), block.MineConfig{NumWorkers: 1, Clock: clk}); err != nil {
+		t.Fatalf("Mine error: %v", err)
+	}
+	return b
+}
+
+// extend добывает n блоков поверх branch
+func extend(t *testing.T, p *consensus.Params, branch []*block.Block, n int, seed byte) []*block.Block {
+	t.Helper()
+
+	out := append([]*block.Block(nil), branch...)
+	for i := 0; i < n; i++ {
+		out = append(out, mineChild(t, p, out, seed+byte(i)))
+	}
+	return out
+}
+
+// mainChain читает основную цепочку от генезиса до вершины
+func mainChain(t *testing.T, bc *chain.Blockchain) []*block.Block {
+	t.Helper()
+
+	blocks := make([]*block.Block, 0, bc.Height()+1)
+	for h := 0; h <= bc.Height(); h++ {
+		b, err := bc.GetBlockByIndex(t.Context(), h)
+		if err != nil {
+			t.Fatalf("GetBlockByIndex(%d) error: %v", h, err)
+		}
+		blocks = append(blocks, b)
+	}
+	return blocks
+}
+
+// genesisBranch - ветка из одного генезиса для mineChild и extend
+func genesisBranch(t *testing.T, bc *chain.Blockchain) []*block.Block {
+	t.Helper()
+
+	g, err := bc.GetBlockByIndex(t.Context(), 0)
+	if err != nil {
+		t.Fatalf("GetBlockByIndex(0) error: %v", err)
+	}
+	return []*block.Block{g}
+}
+
+func hashes(blocks []*block.Block) [][]byte {
+	out := make([][]byte, len(blocks))
+	for i, b := range blocks {
+		out[i] = b.Hash
+	}
+	return out
+}
+
+func sameHashes(a, b [][]byte) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if !bytes.Equal(a[i], b[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+func TestBlockchain_Reorganization(t *testing.T) {
+	repo := helpers.NewTestRepository(t)
+	p := consensus.Regtest()
+	bc := helpers.NewTestChain(t, helpers.WithStore(repo), helpers.WithParams(p))
+	genesis := genesisBranch(t, bc)
+
+	mainBranch := extend(t, p, genesis, 2, 0x10)
+	sideBranch := extend(t, p, genesis, 3, 0x20)
+
+	for _, b := range mainBranch[1:] {
+		update, err := bc.ProcessBlock(t.Context(), b)
+		if err != nil {
+			t.Fatalf("ProcessBlock(main) error: %v", err)
+		}
+		if update == nil || update.IsReorg() || !bytes.Equal(update.NewTip, b.Hash) {
+			t.Fatalf("extending the tip must move it without reorg: %+v", update)
+		}
+	}
+
+	// ветка с не большей работой остаётся боковой
+	for _, b := range sideBranch[1:3] {
+		update, err := bc.ProcessBlock(t.Context(), b)
+		if err != nil {
+			t.Fatalf("ProcessBlock(side) error: %v", err)
+		}
+		if update != nil {
+			t.Fatalf("side block must not move the tip: %+v", update)
+		}
+	}
+	if !bytes.Equal(bc.TipHash(), mainBranch[2].Hash) {
+		t.Fatal("tip moved to a branch with equal work")
+	}
+	if tips, err := bc.ChainTips(t.Context()); err != nil || len(tips) != 2 || !tips[0].Active || tips[1].BranchLen != 2 {
+		t.Fatalf("unexpected chain tips: %+v (%v)", tips, err)
+	}
+
+	// третий блок даёт боковой ветке больше работы - реорганизация
+	update, err := bc.ProcessBlock(t.Context(), sideBranch[3])
+	if err != nil {
+		t.Fatalf("ProcessBlock(reorg) error: %v", err)
+	}
+	if !update.IsReorg() || update.ForkHeight != 0 {
+		t.Fatalf("expected reorg from genesis: %+v", update)
+	}
+	wantDisconnected := [][]byte{mainBranch[2].Hash, mainBranch[1].Hash}
+	if !sameHashes(hashes(update.Disconnected), wantDisconnected) {
+		t.Error("disconnected blocks must be old branch from tip to fork")
+	}
+	if !sameHashes(hashes(update.Connected), hashes(sideBranch[1:])) {
+		t.Error("connected blocks must be new branch from fork to tip")
+	}
+	if !sameHashes(hashes(mainChain(t, bc)), hashes(sideBranch)) || !bytes.Equal(bc.TipHash(), sideBranch[3].Hash) || bc.Height() != 3 {
+		t.Fatal("main chain was not switched to the heavier branch")
+	}
+	if err := bc.IsValid(t.Context()); err != nil {
+		t.Fatalf("chain invalid after reorg: %v", err)
+	}
+	if last, _ := repo.GetLastHash(t.Context()); !bytes.Equal(last, sideBranch[3].Hash) {
+		t.Error("store tip was not moved")
+	}
+
+	// старая ветка продолжает жить в дереве и может вернуться
+	if !bc.HasBlock(mainBranch[2].Hash) {
+		t.Error("disconnected block must stay in the block tree")
+	}
+
+	if _, err := bc.ProcessBlock(t.Context(), sideBranch[3]); chain.ErrorCode(err) != chain.ErrDuplicateBlock {
+		t.Errorf("duplicate block: %v, want %s", err, chain.ErrDuplicateBlock)
+	}
+	orphan := mineChild(t, p, extend(t, p, genesis, 1, 0x30), 0x31)
+	if _, err := bc.ProcessBlock(t.Context(), orphan); chain.ErrorCode(err) != chain.ErrOrphanBlock {
+		t.Errorf("unknown parent: %v, want %s", err, chain.ErrOrphanBlock)
+	}
+
+	// после перезапуска загружается новая основная цепочка, а блоки старой
+	// ветки поднимаются из хранилища, когда их продолжают
+	reopened, err := chain.NewBlockchain(t.Context(), repo, p)
+	if err != nil {
+		t.Fatalf("reopen error: %v", err)
+	}
+	if !sameHashes(hashes(mainChain(t, reopened)), hashes(sideBranch)) {
+		t.Fatal("reopened chain doesn't follow the stored tip")
+	}
+
+	revived := extend(t, p, mainBranch, 2, 0x40)
+	for _, b := range revived[3:] {
+		if _, err := reopened.ProcessBlock(t.Context(), b); err != nil {
+			t.Fatalf("ProcessBlock(revived) error: %v", err)
+		}
+	}
+	if !sameHashes(hashes(mainChain(t, reopened)), hashes(revived)) {
+		t.Fatal("old branch with more work did not become main after restart")
+	}
+	if err := reopened.IsValid(t.Context()); err != nil {
+		t.Fatalf("chain invalid after second reorg: %v", err)
+	}
+}
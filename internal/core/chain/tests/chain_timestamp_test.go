@@ -0,0 +1,109 @@
+package tests
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Alex1997377/weave/internal/clock"
+	"github.com/Alex1997377/weave/internal/core/block"
+	"github.com/Alex1997377/weave/internal/core/chain"
+	"github.com/Alex1997377/weave/internal/core/header"
+	"github.com/Alex1997377/weave/internal/tests/helpers"
+)
+
+// withTimestamp подменяет время блока и заново майнит его
+func withTimestamp(t *testing.T, b *block.Block, ts int64) *block.Block {
+	t.Helper()
+
+	b.Header.Timestamp = ts
+	remine(t, b)
+	return b
+}
+
+func TestBlockchain_RejectsTimestampAtMedianTimePast(t *testing.T) {
+	bc := helpers.NewTestChain(t, helpers.WithClock(clock.NewManual(fixtureTime.Add(time.Hour))))
+	p := bc.Params()
+
+	branch := extend(t, p, genesisBranch(t, bc), 5, 0x10)
+	for _, b := range branch[1:] {
+		if _, err := bc.ProcessBlock(t.Context(), b); err != nil {
+			t.Fatalf("ProcessBlock error: %v", err)
+		}
+	}
+
+	// медиана шести блоков с шагом в минуту - время блока 3
+	mtp := branch[3].Header.Timestamp
+
+	stale := withTimestamp(t, mineChild(t, p, branch, 0x30), mtp)
+	_, err := bc.ProcessBlock(t.Context(), stale)
+	if got := chain.ErrorCode(err); got != chain.ErrInvalidTimestamp {
+		t.Fatalf("ProcessBlock error %v (code %q), want %q", err, got, chain.ErrInvalidTimestamp)
+	}
+
+	next := withTimestamp(t, mineChild(t, p, branch, 0x31), mtp+1)
+	if _, err := bc.ProcessBlock(t.Context(), next); err != nil {
+		t.Fatalf("block after median time past rejected: %v", err)
+	}
+	if err := bc.IsValid(t.Context()); err != nil {
+		t.Fatalf("chain is invalid: %v", err)
+	}
+}
+
+func TestBlockchain_RejectsTimestampTooFarInFuture(t *testing.T) {
+	clk := clock.NewManual(fixtureTime)
+	bc := helpers.NewTestChain(t, helpers.WithClock(clk))
+	p := bc.Params()
+	limit := fixtureTime.Add(p.MaxFutureDrift).Unix()
+
+	future := withTimestamp(t, mineChild(t, p, genesisBranch(t, bc), 0x40), limit+1)
+	_, err := bc.ProcessBlock(t.Context(), future)
+	if got := chain.ErrorCode(err); got != chain.ErrInvalidTimestamp {
+		t.Fatalf("ProcessBlock error %v (code %q), want %q", err, got, chain.ErrInvalidTimestamp)
+	}
+	if bc.OrphanCount() != 0 || bc.Height() != 0 {
+		t.Fatal("rejected block must not change the chain")
+	}
+
+	edge := withTimestamp(t, mineChild(t, p, genesisBranch(t, bc), 0x41), limit)
+	if _, err := bc.ProcessBlock(t.Context(), edge); err != nil {
+		t.Fatalf("block at drift limit rejected: %v", err)
+	}
+
+	// часы узла ушли назад: блок оказался дальше допустимого
+	clk.Set(fixtureTime.Add(-time.Hour))
+	if err := bc.IsValid(t.Context()); err == nil {
+		t.Fatal("IsValid accepted block too far in the future")
+	}
+}
+
+func TestBlockchain_TemplateRespectsMedianTimePast(t *testing.T) {
+	// часы отстают от генезиса - шаблон всё равно должен быть допустим
+	clk := clock.NewManual(fixtureTime.Add(-time.Hour))
+	bc := helpers.NewTestChain(t, helpers.WithClock(clk))
+
+	for i := 0; i < 3; i++ {
+		tpl, err := bc.BlockTemplate(t.Context(), rewardAddress, nil)
+		if err != nil {
+			t.Fatalf("BlockTemplate error: %v", err)
+		}
+
+		mtp, err := bc.Params().MedianTimePast(tpl.Header.Index, func(h int) (*header.Header, error) {
+			b, err := bc.GetBlockByIndex(t.Context(), h)
+			if err != nil {
+				return nil, err
+			}
+			return &b.Header, nil
+		})
+		if err != nil {
+			t.Fatalf("MedianTimePast error: %v", err)
+		}
+		if tpl.Header.Timestamp != mtp+1 {
+			t.Fatalf("template timestamp %d, want %d", tpl.Header.Timestamp, mtp+1)
+		}
+
+		remine(t, tpl)
+		if err := bc.SubmitBlock(t.Context(), tpl); err != nil {
+			t.Fatalf("SubmitBlock error: %v", err)
+		}
+	}
+}
@@ -0,0 +1,151 @@
+package chain
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/Alex1997377/weave/internal/core/block"
+	headererrors "github.com/Alex1997377/weave/internal/core/header/errors"
+	"github.com/Alex1997377/weave/internal/core/header/errors/constants"
+)
+
+// checkBlockSanity проверяет правила, не зависящие от ветки: заголовок,
+// хеш, доказательство работы, транзакции, MerkleRoot и размер. Каждая
+// причина отклонения имеет свой код BlockchainError.
+func (bc *Blockchain) checkBlockSanity(b *block.Block) error {
+	p := bc.params
+
+	if err := bc.checkHeader(b); err != nil {
+		return err
+	}
+	if err := p.CheckFutureDrift(&b.Header, bc.clock.Now()); err != nil {
+		return newBlockError(ErrInvalidTimestamp, "block timestamp too far in the future", err)
+	}
+
+	calculatedHash, err := b.CalculateHash()
+	if err != nil {
+		return newBlockError(ErrInvalidHash, "failed to calculate block hash", err)
+	}
+	if !bytes.Equal(b.Hash, calculatedHash) {
+		return NewInvalidHashError("block hash doesn`t match content", nil)
+	}
+
+	if err := bc.checkCheckpoint(b); err != nil {
+		return err
+	}
+
+	if err := bc.checkProofOfWork(b); err != nil {
+		return err
+	}
+
+	if err := bc.checkTransactions(b); err != nil {
+		return err
+	}
+
+	if !bytes.Equal(b.Header.MerkleRoot, b.CalculateMerkleRoot()) {
+		return newBlockError(ErrInvalidMerkleRoot, "merkle root doesn`t match transactions", nil)
+	}
+
+	return bc.checkSize(b)
+}
+
+// checkHeader проверяет формат заголовка и его версию по расписанию активаций
+func (bc *Blockchain) checkHeader(b *block.Block) error {
+	if err := b.Header.Validate(constants.OpValidate); err != nil {
+		if headererrors.IsTimestampError(err) {
+			return newBlockError(ErrInvalidTimestamp, "invalid block timestamp", err)
+		}
+		return newBlockError(ErrInvalidHeader, "invalid block header", err)
+	}
+	if err := bc.params.Activations.Check(&b.Header); err != nil {
+		return newBlockError(ErrInvalidHeader, "invalid header version", err)
+	}
+	return nil
+}
+
+// checkSize проверяет размер блока по лимиту сети и запоминает его в b.Size
+func (bc *Blockchain) checkSize(b *block.Block) error {
+	size, err := b.CalculateSize()
+	if err != nil {
+		return NewInvalidBlockError("failed to calculate block size", err)
+	}
+	if size > bc.params.MaxBlockSize {
+		return newBlockError(ErrBlockTooLarge, fmt.Sprintf("block size %d exceeds limit of %d bytes", size, bc.params.MaxBlockSize), nil)
+	}
+	b.Size = size
+	return nil
+}
+
+// checkProofOfWork проверяет границы цели сети и доказательство работы заголовка
+func (bc *Blockchain) checkProofOfWork(b *block.Block) error {
+	p := bc.params
+
+	if err := p.CheckTarget(&b.Header); err != nil {
+		return newBlockError(ErrInvalidDifficulty, "block target out of network bounds", err)
+	}
+	target, err := b.Header.Target()
+	if err != nil {
+		return newBlockError(ErrInvalidDifficulty, "invalid block target", err)
+	}
+	algo, err := p.Algorithm()
+	if err != nil {
+		return NewChainCorruptedError("invalid consensus params", err)
+	}
+	powHash, err := b.PowHash(algo)
+	if err != nil {
+		return newBlockError(ErrInvalidProofOfWork, "failed to calculate proof-of-work hash", err)
+	}
+	if !powHash.MeetsTarget(target) {
+		return newBlockError(ErrInvalidProofOfWork, "hash doesn`t meet block target", nil)
+	}
+	return nil
+}
+
+// checkTransactions проверяет транзакции правилами block.CheckTransactions
+// и переводит нарушение в код BlockchainError
+func (bc *Blockchain) checkTransactions(b *block.Block) error {
+	err := b.CheckTransactions(bc.params)
+	switch {
+	case err == nil:
+		return nil
+	case errors.Is(err, block.ErrInvalidCoinbase):
+		return newBlockError(ErrInvalidCoinbase, "invalid coinbase transaction", err)
+	default:
+		return newBlockError(ErrInvalidTransaction, "invalid block transactions", err)
+	}
+}
+
+// checkBlockContext проверяет правила, зависящие от ветки родителя parent
+func (bc *Blockchain) checkBlockContext(ctx context.Context, b *block.Block, parent *blockNode) error {
+	if b.Header.Index != parent.height+1 {
+		return newBlockError(ErrInvalidHeight, fmt.Sprintf("block index %d doesn`t follow parent index %d", b.Header.Index, parent.height), nil)
+	}
+	if err := bc.checkForkPoint(parent); err != nil {
+		return err
+	}
+
+	headerAt, err := bc.headerSourceFor(ctx, parent)
+	if err != nil {
+		return fmt.Errorf("failed to resolve parent branch: %w", err)
+	}
+	// ошибка чтения заголовков из-за отмены - не нарушение правил
+	if err := bc.params.CheckWork(&b.Header, headerAt); err != nil {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		return newBlockError(ErrInvalidDifficulty, "unexpected block target", err)
+	}
+	if err := bc.params.CheckTimestamp(&b.Header, headerAt, bc.clock.Now()); err != nil {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		return newBlockError(ErrInvalidTimestamp, "block timestamp violates median time past", err)
+	}
+
+	if err := b.SetChainWork(parent.work()); err != nil {
+		return NewInvalidBlockError("failed to calculate chain work", err)
+	}
+	return nil
+}
@@ -0,0 +1,78 @@
+package consensus
+
+import (
+	"encoding/hex"
+	"fmt"
+	"sort"
+)
+
+// Checkpoint - жёстко заданный блок основной цепочки: на высоте Height
+// допустим только блок с хешем Hash, а ветки, расходящиеся ниже пройденной
+// контрольной точки, отклоняются
+type Checkpoint struct {
+	Height int
+	// Hash - hex-хеш блока
+	Hash string
+}
+
+// CheckpointAt возвращает контрольную точку на высоте height
+func (p *Params) CheckpointAt(height int) (Checkpoint, bool) {
+	i := sort.Search(len(p.Checkpoints), func(i int) bool { return p.Checkpoints[i].Height >= height })
+	if i < len(p.Checkpoints) && p.Checkpoints[i].Height == height {
+		return p.Checkpoints[i], true
+	}
+	return Checkpoint{}, false
+}
+
+// LastCheckpoint возвращает самую высокую контрольную точку не выше height
+func (p *Params) LastCheckpoint(height int) (Checkpoint, bool) {
+	i := sort.Search(len(p.Checkpoints), func(i int) bool { return p.Checkpoints[i].Height > height })
+	if i == 0 {
+		return Checkpoint{}, false
+	}
+	return p.Checkpoints[i-1], true
+}
+
+// CheckCheckpoint проверяет, что блок hash на высоте height не противоречит контрольной точке
+func (p *Params) CheckCheckpoint(height int, hash []byte) error {
+	cp, ok := p.CheckpointAt(height)
+	if !ok {
+		return nil
+	}
+	if hex.EncodeToString(hash) != cp.Hash {
+		return fmt.Errorf("block %x at height %d conflicts with checkpoint %s", hash, height, cp.Hash)
+	}
+	return nil
+}
+
+// validateCheckpoints проверяет порядок контрольных точек и формат хешей
+func (p *Params) validateCheckpoints() error {
+	for i, cp := range p.Checkpoints {
+		if cp.Height < 0 {
+			return fmt.Errorf("checkpoint height cannot be negative: %d", cp.Height)
+		}
+		if i > 0 && cp.Height <= p.Checkpoints[i-1].Height {
+			return fmt.Errorf("checkpoints must be sorted by height: %d after %d", cp.Height, p.Checkpoints[i-1].Height)
+		}
+		if err := checkHashHex(cp.Hash); err != nil {
+			return fmt.Errorf("checkpoint %d: %w", cp.Height, err)
+		}
+	}
+	if p.AssumeValid != "" {
+		if err := checkHashHex(p.AssumeValid); err != nil {
+			return fmt.Errorf("assume valid: %w", err)
+		}
+	}
+	return nil
+}
+
+func checkHashHex(s string) error {
+	b, err := hex.DecodeString(s)
+	if err != nil {
+		return fmt.Errorf("invalid hash %q: %w", s, err)
+	}
+	if len(b) != 32 {
+		return fmt.Errorf("invalid hash length: expected 32, got %d", len(b))
+	}
+	return nil
+}
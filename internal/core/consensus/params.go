@@ -0,0 +1,270 @@
+package consensus
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/Alex1997377/weave/internal/core/header"
+	"github.com/Alex1997377/weave/internal/crypto/pow"
+	"github.com/Alex1997377/weave/internal/crypto/powalgo"
+)
+
+// Genesis описывает генезис-блок сети. Все поля фиксированы, поэтому каждый
+// узел строит один и тот же генезис и получает один и тот же хеш.
+type Genesis struct {
+	Timestamp  int64
+	Difficulty int
+	Nonce      uint64
+	// Hash - ожидаемый hex-хеш генезиса; пустая строка отключает проверку
+	Hash string
+}
+
+// Params - правила консенсуса сети. Передаются в chain.NewBlockchain и
+// block.ValidateWithParams вместо разбросанных по пакетам констант.
+type Params struct {
+	Name    string
+	ChainID uint32
+
+	// Границы сложности в битах (число ведущих нулевых бит хеша); для
+	// заголовков с Bits задают PowLimit и HardestTarget. Начальная сложность
+	// задаётся Genesis.Difficulty.
+	MinDifficulty int
+	MaxDifficulty int
+
+	// PowAlgorithm - имя алгоритма доказательства работы в реестре powalgo;
+	// пустая строка означает powalgo.Default (SHA-256)
+	PowAlgorithm string
+
+	// Пересчёт сложности (см. NextDifficulty); RetargetWindow = 0 отключает пересчёт
+	TargetBlockInterval time.Duration
+	RetargetWindow      int
+	MaxAdjustmentFactor float64
+
+	// Правила времени блока (см. CheckTimestamp): время должно быть больше
+	// медианы MedianTimeSpan предыдущих блоков и не дальше MaxFutureDrift от
+	// текущего времени узла. Нулевое значение отключает соответствующую проверку.
+	MedianTimeSpan int
+	MaxFutureDrift time.Duration
+
+	// Ограничения блока
+	MaxBlockSize       uint32
+	MaxTransactions    uint32
+	MaxTransactionSize uint32
+	MaxSignatureSize   int
+
+	// Награда за блок уменьшается вдвое каждые HalvingInterval блоков
+	InitialReward   float64
+	HalvingInterval int
+
+	Genesis     Genesis
+	Activations header.ActivationSchedule
+
+	// Checkpoints - контрольные точки по возрастанию высоты (см. Checkpoint)
+	Checkpoints []Checkpoint
+	// AssumeValid - hex-хеш блока основной цепочки, до которого включительно
+	// проверка сохранённой цепочки (Blockchain.IsValid) не перепроверяет
+	// правила транзакций; заголовки, доказательство работы и MerkleRoot
+	// проверяются всегда. На приём новых блоков (ProcessBlock) AssumeValid
+	// не влияет. Подписи узел не проверяет вовсе: транзакция не несёт
+	// открытого ключа. Пустая строка означает полную проверку.
+	AssumeValid string
+}
+
+// Имена встроенных профилей
+const (
+	NameMainnet = "mainnet"
+	NameTestnet = "testnet"
+	NameRegtest = "regtest"
+)
+
+// maxHalvings - после стольких уменьшений награда считается нулевой
+const maxHalvings = 64
+
+// Mainnet - параметры основной сети
+func Mainnet() *Params {
+	return &Params{
+		Name:                NameMainnet,
+		ChainID:             1,
+		MinDifficulty:       1,
+		MaxDifficulty:       255,
+		PowAlgorithm:        powalgo.NameSHA256,
+		TargetBlockInterval: time.Minute,
+		RetargetWindow:      20,
+		MaxAdjustmentFactor: 4,
+		MedianTimeSpan:      11,
+		MaxFutureDrift:      2 * time.Hour,
+		MaxBlockSize:        1 << 20,
+		MaxTransactions:     10000,
+		MaxTransactionSize:  1 << 16,
+		MaxSignatureSize:    1024,
+		InitialReward:       50,
+		HalvingInterval:     210000,
+		Genesis: Genesis{
+			Timestamp:  1735689600,
+			Difficulty: 4,
+			Nonce:      2,
+			Hash:       "089324551b3f00262be6ad8863ddb315f354c4d9d188f578e9f84596fd18b070",
+		},
+		// Генезис остаётся версии 1, компактная цель действует со следующего блока
+		Activations: header.ActivationSchedule{
+			{Version: header.Version1, Height: 0},
+			{Version: header.Version2, Height: 1},
+		},
+	}
+}
+
+// Testnet - параметры тестовой сети: та же структура, меньшая сложность
+func Testnet() *Params {
+	p := Mainnet()
+	p.Name = NameTestnet
+	p.ChainID = 2
+	p.TargetBlockInterval = 30 * time.Second
+	p.Genesis = Genesis{
+		Timestamp:  1735689601,
+		Difficulty: 3,
+		Nonce:      0,
+		Hash:       "1ae7271a3a12ceb36439997e957620257d3c3a96a82b496a8b9cb1343a5cc0a6",
+	}
+	return p
+}
+
+// Regtest - параметры локальной сети для тестов: минимальная постоянная сложность и
+// частое уменьшение награды
+func Regtest() *Params {
+	p := Mainnet()
+	p.Name = NameRegtest
+	p.ChainID = 3
+	p.MinDifficulty = 0
+	p.RetargetWindow = 0
+	p.HalvingInterval = 150
+	p.Genesis = Genesis{
+		Timestamp:  1735689602,
+		Difficulty: 1,
+		Nonce:      1,
+		Hash:       "1dff4a410ace4a368b3ade13c52abd55224f5503103a6681b4b5e54ceed97bcb",
+	}
+	return p
+}
+
+// ByName возвращает встроенный профиль по имени
+func ByName(name string) (*Params, error) {
+	switch name {
+	case NameMainnet:
+		return Mainnet(), nil
+	case NameTestnet:
+		return Testnet(), nil
+	case NameRegtest:
+		return Regtest(), nil
+	}
+	return nil, fmt.Errorf("unknown network: %q", name)
+}
+
+// BlockReward возвращает награду за блок на высоте height
+func (p *Params) BlockReward(height int) float64 {
+	if height < 0 {
+		return 0
+	}
+	if p.HalvingInterval <= 0 {
+		return p.InitialReward
+	}
+
+	halvings := height / p.HalvingInterval
+	if halvings >= maxHalvings {
+		return 0
+	}
+
+	reward := p.InitialReward
+	for i := 0; i < halvings; i++ {
+		reward /= 2
+	}
+	return reward
+}
+
+// Algorithm возвращает алгоритм доказательства работы сети
+func (p *Params) Algorithm() (powalgo.Algorithm, error) {
+	return powalgo.Get(p.PowAlgorithm)
+}
+
+// PowLimit - самая лёгкая допустимая цель сети
+func (p *Params) PowLimit() pow.Target {
+	return pow.DifficultyToTarget(p.MinDifficulty)
+}
+
+// HardestTarget - самая трудная допустимая цель сети
+func (p *Params) HardestTarget() pow.Target {
+	return pow.DifficultyToTarget(p.MaxDifficulty)
+}
+
+// CheckTarget проверяет, что цель заголовка лежит в границах сети
+func (p *Params) CheckTarget(h *header.Header) error {
+	if !h.UsesBits() {
+		return p.CheckDifficulty(h.Difficulty)
+	}
+
+	target, err := h.Target()
+	if err != nil {
+		return err
+	}
+	if target.Cmp(p.PowLimit()) > 0 {
+		return fmt.Errorf("target %s is easier than pow limit %s", target, p.PowLimit())
+	}
+	if target.Cmp(p.HardestTarget()) < 0 {
+		return fmt.Errorf("target %s is harder than allowed %s", target, p.HardestTarget())
+	}
+	return nil
+}
+
+// CheckDifficulty проверяет, что сложность лежит в допустимых границах
+func (p *Params) CheckDifficulty(difficulty int) error {
+	if difficulty < p.MinDifficulty || difficulty > p.MaxDifficulty {
+		return fmt.Errorf("difficulty %d out of range [%d, %d]", difficulty, p.MinDifficulty, p.MaxDifficulty)
+	}
+	return nil
+}
+
+// Validate проверяет согласованность параметров
+func (p *Params) Validate() error {
+	if p == nil {
+		return errors.New("consensus params are nil")
+	}
+	if p.Name == "" {
+		return errors.New("network name is empty")
+	}
+	if p.MinDifficulty < 0 || p.MinDifficulty > p.MaxDifficulty {
+		return fmt.Errorf("invalid difficulty range [%d, %d]", p.MinDifficulty, p.MaxDifficulty)
+	}
+	if err := p.CheckDifficulty(p.Genesis.Difficulty); err != nil {
+		return fmt.Errorf("genesis %w", err)
+	}
+	if _, err := p.Algorithm(); err != nil {
+		return err
+	}
+	if p.RetargetWindow < 0 || p.RetargetWindow == 1 {
+		return fmt.Errorf("retarget window must be 0 or at least 2, got %d", p.RetargetWindow)
+	}
+	if p.RetargetWindow > 0 && (p.TargetBlockInterval <= 0 || p.MaxAdjustmentFactor < 1) {
+		return errors.New("retargeting requires a positive block interval and an adjustment factor >= 1")
+	}
+	if p.MedianTimeSpan < 0 || p.MaxFutureDrift < 0 {
+		return errors.New("timestamp rules cannot be negative")
+	}
+	if p.MaxBlockSize == 0 || p.MaxTransactions == 0 || p.MaxTransactionSize == 0 {
+		return errors.New("block limits must be positive")
+	}
+	if p.MaxSignatureSize <= 0 {
+		return errors.New("max signature size must be positive")
+	}
+	if p.InitialReward < 0 {
+		return errors.New("block reward cannot be negative")
+	}
+	if len(p.Activations) == 0 {
+		return errors.New("header activation schedule is empty")
+	}
+	for _, a := range p.Activations {
+		if !header.IsKnownVersion(a.Version) {
+			return fmt.Errorf("activation of unknown header version %d", a.Version)
+		}
+	}
+	return p.validateCheckpoints()
+}
@@ -0,0 +1,196 @@
+package consensus
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+
+	"github.com/Alex1997377/weave/internal/core/header"
+	"github.com/Alex1997377/weave/internal/crypto/pow"
+)
+
+// HeaderSource возвращает заголовок ветки на высоте height
+type HeaderSource func(height int) (*header.Header, error)
+
+// WorkRequirement - требуемая цель блока на высоте: Difficulty для заголовков
+// версии 1, Bits начиная с версии 2
+type WorkRequirement struct {
+	Version    uint32
+	Difficulty int
+	Bits       uint32
+}
+
+// Apply записывает версию и цель в заголовок
+func (w WorkRequirement) Apply(h *header.Header) {
+	h.Version = w.Version
+	h.Difficulty = w.Difficulty
+	h.Bits = w.Bits
+}
+
+// NextWorkRequired возвращает версию и цель, обязательные для блока на высоте height
+func (p *Params) NextWorkRequired(height int, headerAt HeaderSource) (WorkRequirement, error) {
+	version := p.Activations.VersionAt(height)
+	req := WorkRequirement{Version: version}
+
+	var err error
+	if version >= header.Version2 {
+		req.Bits, err = p.NextBits(height, headerAt)
+	} else {
+		req.Difficulty, err = p.NextDifficulty(height, headerAt)
+	}
+	return req, err
+}
+
+// CheckWork проверяет, что цель заголовка совпадает с рассчитанной по предыдущим блокам
+func (p *Params) CheckWork(h *header.Header, headerAt HeaderSource) error {
+	if h == nil {
+		return errors.New("header is nil")
+	}
+
+	if h.UsesBits() {
+		expected, err := p.NextBits(h.Index, headerAt)
+		if err != nil {
+			return err
+		}
+		if h.Bits != expected {
+			return fmt.Errorf("bits %08x do not match expected %08x", h.Bits, expected)
+		}
+		return nil
+	}
+
+	expected, err := p.NextDifficulty(h.Index, headerAt)
+	if err != nil {
+		return err
+	}
+	if h.Difficulty != expected {
+		return fmt.Errorf("difficulty %d does not match expected %d", h.Difficulty, expected)
+	}
+	return nil
+}
+
+// NextDifficulty вычисляет обязательную сложность в битах для заголовков версии 1.
+//
+// Сложность меняется только на границе окна (height кратна RetargetWindow):
+// фактическое время между первым и последним блоком окна сравнивается с
+// ожидаемым (RetargetWindow-1)*TargetBlockInterval. Отношение ограничено
+// MaxAdjustmentFactor, а так как сложность - число нулевых бит, шаг равен
+// округлённому log2 этого отношения. Результат зажат в [MinDifficulty, MaxDifficulty].
+func (p *Params) NextDifficulty(height int, headerAt HeaderSource) (int, error) {
+	if height == 0 {
+		return p.Genesis.Difficulty, nil
+	}
+
+	prev, first, err := p.retargetWindow(height, headerAt)
+	if err != nil {
+		return 0, err
+	}
+	if first == nil {
+		return prev.Difficulty, nil
+	}
+
+	ratio := p.expectedTimespan() / p.actualTimespan(prev, first)
+	next := prev.Difficulty + int(math.Round(math.Log2(ratio)))
+	if next < p.MinDifficulty {
+		next = p.MinDifficulty
+	}
+	if next > p.MaxDifficulty {
+		next = p.MaxDifficulty
+	}
+	return next, nil
+}
+
+// NextBits вычисляет обязательную компактную цель для заголовков версии 2.
+//
+// Окно и ограничение шага те же, что у NextDifficulty, но цель умножается на
+// отношение фактического времени окна к ожидаемому точно, а не степенью двойки.
+// Результат зажат между HardestTarget и PowLimit.
+func (p *Params) NextBits(height int, headerAt HeaderSource) (uint32, error) {
+	if height == 0 {
+		return pow.DifficultyToTarget(p.Genesis.Difficulty).Compact(), nil
+	}
+
+	prev, first, err := p.retargetWindow(height, headerAt)
+	if err != nil {
+		return 0, err
+	}
+
+	prevTarget, err := prev.Target()
+	if err != nil {
+		return 0, fmt.Errorf("invalid target at height %d: %w", height-1, err)
+	}
+	if first == nil {
+		if prev.UsesBits() {
+			return prev.Bits, nil
+		}
+		return prevTarget.Compact(), nil
+	}
+
+	// новая цель = старая * фактическое / ожидаемое, время в миллисекундах
+	expected := int64(p.expectedTimespan() * 1000)
+	actual := int64(p.actualTimespan(prev, first) * 1000)
+
+	next := prevTarget.Big()
+	next.Mul(next, big.NewInt(actual))
+	next.Div(next, big.NewInt(expected))
+
+	if limit := p.PowLimit().Big(); next.Cmp(limit) > 0 {
+		next = limit
+	}
+	if hardest := p.HardestTarget().Big(); next.Cmp(hardest) < 0 {
+		next = hardest
+	}
+
+	target, err := pow.TargetFromBig(next)
+	if err != nil {
+		return 0, err
+	}
+	return target.Compact(), nil
+}
+
+// retargetWindow возвращает предыдущий заголовок и, если height - граница окна,
+// первый заголовок окна
+func (p *Params) retargetWindow(height int, headerAt HeaderSource) (prev, first *header.Header, err error) {
+	if height < 0 {
+		return nil, nil, fmt.Errorf("invalid height: %d", height)
+	}
+	if headerAt == nil {
+		return nil, nil, errors.New("header source is nil")
+	}
+
+	prev, err = headerAt(height - 1)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get header %d: %w", height-1, err)
+	}
+
+	if p.RetargetWindow <= 0 || height%p.RetargetWindow != 0 {
+		return prev, nil, nil
+	}
+
+	first, err = headerAt(height - p.RetargetWindow)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get header %d: %w", height-p.RetargetWindow, err)
+	}
+	return prev, first, nil
+}
+
+func (p *Params) expectedTimespan() float64 {
+	return float64(p.RetargetWindow-1) * p.TargetBlockInterval.Seconds()
+}
+
+// actualTimespan - фактическое время окна, ограниченное MaxAdjustmentFactor
+func (p *Params) actualTimespan(prev, first *header.Header) float64 {
+	expected := p.expectedTimespan()
+	actual := float64(prev.Timestamp - first.Timestamp)
+
+	if min := expected / p.MaxAdjustmentFactor; actual < min {
+		actual = min
+	}
+	if max := expected * p.MaxAdjustmentFactor; actual > max {
+		actual = max
+	}
+	if actual < 1 {
+		actual = 1
+	}
+	return actual
+}
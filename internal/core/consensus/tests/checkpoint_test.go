@@ -0,0 +1,69 @@
+package tests
+
+import (
+	"bytes"
+	"encoding/hex"
+	"testing"
+
+	"github.com/Alex1997377/weave/internal/core/consensus"
+)
+
+func checkpointHash(b byte) string {
+	return hex.EncodeToString(bytes.Repeat([]byte{b}, 32))
+}
+
+func TestCheckpoints(t *testing.T) {
+	p := consensus.Mainnet()
+	p.Checkpoints = []consensus.Checkpoint{
+		{Height: 10, Hash: checkpointHash(0x01)},
+		{Height: 20, Hash: checkpointHash(0x02)},
+	}
+	if err := p.Validate(); err != nil {
+		t.Fatalf("Validate error: %v", err)
+	}
+
+	if _, ok := p.CheckpointAt(15); ok {
+		t.Error("unexpected checkpoint at 15")
+	}
+	if cp, ok := p.LastCheckpoint(15); !ok || cp.Height != 10 {
+		t.Errorf("LastCheckpoint(15) = %+v, %v", cp, ok)
+	}
+	if _, ok := p.LastCheckpoint(9); ok {
+		t.Error("unexpected checkpoint below 10")
+	}
+
+	if err := p.CheckCheckpoint(20, bytes.Repeat([]byte{0x02}, 32)); err != nil {
+		t.Errorf("matching block rejected: %v", err)
+	}
+	if err := p.CheckCheckpoint(20, bytes.Repeat([]byte{0x03}, 32)); err == nil {
+		t.Error("conflicting block accepted")
+	}
+	if err := p.CheckCheckpoint(21, bytes.Repeat([]byte{0x03}, 32)); err != nil {
+		t.Errorf("block without checkpoint rejected: %v", err)
+	}
+}
+
+func TestCheckpoints_Validate(t *testing.T) {
+	tests := []struct {
+		name        string
+		checkpoints []consensus.Checkpoint
+		assumeValid string
+	}{
+		{name: "unsorted", checkpoints: []consensus.Checkpoint{{Height: 20, Hash: checkpointHash(1)}, {Height: 10, Hash: checkpointHash(2)}}},
+		{name: "duplicate height", checkpoints: []consensus.Checkpoint{{Height: 10, Hash: checkpointHash(1)}, {Height: 10, Hash: checkpointHash(2)}}},
+		{name: "short hash", checkpoints: []consensus.Checkpoint{{Height: 10, Hash: "abcd"}}},
+		{name: "bad assume valid", assumeValid: "zz"},
+		{name: "short assume valid", assumeValid: "abcd"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := consensus.Mainnet()
+			p.Checkpoints = tt.checkpoints
+			p.AssumeValid = tt.assumeValid
+			if err := p.Validate(); err == nil {
+				t.Fatal("expected validation error")
+			}
+		})
+	}
+}
@@ -0,0 +1,195 @@
+package tests
+
+import (
+	"fmt"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/Alex1997377/weave/internal/core/consensus"
+	"github.com/Alex1997377/weave/internal/core/header"
+	"github.com/Alex1997377/weave/internal/crypto/pow"
+)
+
+// buildHeaders создаёт count заголовков сложности difficulty с шагом времени step
+func buildHeaders(count, difficulty int, step int64) []*header.Header {
+	headers := make([]*header.Header, count)
+	for i := range headers {
+		headers[i] = &header.Header{
+			Version:    header.Version1,
+			Index:      i,
+			Timestamp:  1_700_000_000 + int64(i)*step,
+			Difficulty: difficulty,
+		}
+	}
+	return headers
+}
+
+func source(headers []*header.Header) consensus.HeaderSource {
+	return func(height int) (*header.Header, error) {
+		if height < 0 || height >= len(headers) {
+			return nil, fmt.Errorf("no header at %d", height)
+		}
+		return headers[height], nil
+	}
+}
+
+func retargetParams() *consensus.Params {
+	p := consensus.Mainnet()
+	p.TargetBlockInterval = 10 * time.Second
+	p.RetargetWindow = 10
+	p.MaxAdjustmentFactor = 4
+	return p
+}
+
+func TestNextDifficulty(t *testing.T) {
+	tests := []struct {
+		name   string
+		height int
+		step   int64
+		want   int
+	}{
+		{name: "inside window keeps difficulty", height: 15, step: 1, want: 8},
+		{name: "on target", height: 10, step: 10, want: 8},
+		{name: "twice as fast", height: 10, step: 5, want: 9},
+		{name: "twice as slow", height: 10, step: 20, want: 7},
+		{name: "much faster is clamped", height: 10, step: 0, want: 10},
+		{name: "much slower is clamped", height: 10, step: 1000, want: 6},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			headers := buildHeaders(tt.height, 8, tt.step)
+
+			got, err := retargetParams().NextDifficulty(tt.height, source(headers))
+			if err != nil {
+				t.Fatalf("NextDifficulty error: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("got difficulty %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNextDifficulty_Bounds(t *testing.T) {
+	p := retargetParams()
+
+	slow := buildHeaders(10, p.MinDifficulty, 1000)
+	if got, _ := p.NextDifficulty(10, source(slow)); got != p.MinDifficulty {
+		t.Errorf("got %d, want minimum %d", got, p.MinDifficulty)
+	}
+
+	fast := buildHeaders(10, p.MaxDifficulty, 0)
+	if got, _ := p.NextDifficulty(10, source(fast)); got != p.MaxDifficulty {
+		t.Errorf("got %d, want maximum %d", got, p.MaxDifficulty)
+	}
+
+	if got, _ := p.NextDifficulty(0, nil); got != p.Genesis.Difficulty {
+		t.Errorf("genesis difficulty: got %d, want %d", got, p.Genesis.Difficulty)
+	}
+
+	if _, err := p.NextDifficulty(10, source(nil)); err == nil {
+		t.Error("expected error for missing headers")
+	}
+}
+
+func TestNextDifficulty_Disabled(t *testing.T) {
+	p := consensus.Regtest()
+	headers := buildHeaders(50, 3, 0)
+
+	got, err := p.NextDifficulty(len(headers), source(headers))
+	if err != nil {
+		t.Fatalf("NextDifficulty error: %v", err)
+	}
+	if got != 3 {
+		t.Errorf("got %d, want unchanged 3", got)
+	}
+}
+
+func buildBitsHeaders(count int, bits uint32, step int64) []*header.Header {
+	headers := buildHeaders(count, 0, step)
+	for _, h := range headers {
+		h.Version = header.Version2
+		h.Bits = bits
+	}
+	return headers
+}
+
+func TestNextBits(t *testing.T) {
+	p := retargetParams()
+	startBits := pow.DifficultyToTarget(16).Compact()
+	start, _ := pow.CompactToTarget(startBits)
+
+	tests := []struct {
+		name string
+		step int64
+		want *big.Int
+	}{
+		{name: "on target", step: 10, want: start.Big()},
+		{name: "twice as slow", step: 20, want: new(big.Int).Mul(start.Big(), big.NewInt(2))},
+		{name: "three times as slow", step: 30, want: new(big.Int).Mul(start.Big(), big.NewInt(3))},
+		{name: "clamped", step: 1000, want: new(big.Int).Mul(start.Big(), big.NewInt(4))},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			headers := buildBitsHeaders(10, startBits, tt.step)
+
+			bits, err := p.NextBits(10, source(headers))
+			if err != nil {
+				t.Fatalf("NextBits error: %v", err)
+			}
+			want, _ := pow.TargetFromBig(tt.want)
+			if bits != want.Compact() {
+				t.Errorf("got bits %08x, want %08x", bits, want.Compact())
+			}
+		})
+	}
+
+	// внутри окна цель не меняется
+	headers := buildBitsHeaders(15, startBits, 1)
+	if bits, _ := p.NextBits(15, source(headers)); bits != startBits {
+		t.Errorf("got bits %08x, want unchanged %08x", bits, startBits)
+	}
+}
+
+func TestNextBits_PowLimit(t *testing.T) {
+	p := retargetParams()
+	headers := buildBitsHeaders(10, p.PowLimit().Compact(), 1000)
+
+	bits, err := p.NextBits(10, source(headers))
+	if err != nil {
+		t.Fatalf("NextBits error: %v", err)
+	}
+	target, _ := pow.CompactToTarget(bits)
+	if target.Cmp(p.PowLimit()) > 0 {
+		t.Errorf("target %s exceeds pow limit %s", target, p.PowLimit())
+	}
+}
+
+func TestCheckWork(t *testing.T) {
+	p := retargetParams()
+	p.Activations = header.ActivationSchedule{{Version: header.Version1, Height: 0}, {Version: header.Version2, Height: 1}}
+	bits := pow.DifficultyToTarget(8).Compact()
+	headers := buildBitsHeaders(5, bits, 10)
+	headers[0] = &header.Header{Version: header.Version1, Difficulty: p.Genesis.Difficulty}
+
+	// на высоте 1 цель версии 2 выводится из сложности генезиса
+	req, err := p.NextWorkRequired(1, source(headers))
+	if err != nil {
+		t.Fatalf("NextWorkRequired error: %v", err)
+	}
+	if req.Version != header.Version2 || req.Bits != pow.DifficultyToTarget(p.Genesis.Difficulty).Compact() {
+		t.Errorf("unexpected requirement %+v", req)
+	}
+
+	if err := p.CheckWork(headers[3], source(headers)); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	headers[3].Bits--
+	if err := p.CheckWork(headers[3], source(headers)); err == nil {
+		t.Error("expected bits mismatch")
+	}
+}
@@ -0,0 +1,83 @@
+package tests
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Alex1997377/weave/internal/core/consensus"
+	"github.com/Alex1997377/weave/internal/core/header"
+	headererrors "github.com/Alex1997377/weave/internal/core/header/errors"
+)
+
+func TestMedianTimePast(t *testing.T) {
+	p := consensus.Mainnet()
+	headers := buildHeaders(20, 8, 10)
+	// время не обязано расти: медиана не зависит от порядка
+	headers[15].Timestamp = headers[0].Timestamp
+
+	tests := []struct {
+		name   string
+		height int
+		want   int64
+	}{
+		{name: "genesis", height: 0, want: 0},
+		{name: "single block", height: 1, want: headers[0].Timestamp},
+		{name: "short chain", height: 4, want: headers[2].Timestamp},
+		{name: "full span", height: 11, want: headers[5].Timestamp},
+		{name: "unordered span", height: 20, want: headers[13].Timestamp},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := p.MedianTimePast(tt.height, source(headers))
+			if err != nil {
+				t.Fatalf("MedianTimePast error: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("MedianTimePast(%d) = %d, want %d", tt.height, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCheckTimestamp(t *testing.T) {
+	p := consensus.Mainnet()
+	headers := buildHeaders(11, 8, 10)
+	now := time.Unix(headers[10].Timestamp, 0)
+	mtp := headers[5].Timestamp
+
+	tests := []struct {
+		name      string
+		timestamp int64
+		wantErr   bool
+	}{
+		{name: "equal to median", timestamp: mtp, wantErr: true},
+		{name: "after median", timestamp: mtp + 1},
+		{name: "at drift limit", timestamp: now.Add(p.MaxFutureDrift).Unix()},
+		{name: "beyond drift limit", timestamp: now.Add(p.MaxFutureDrift).Unix() + 1, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := &header.Header{Version: header.Version1, Index: 11, Timestamp: tt.timestamp}
+			err := p.CheckTimestamp(h, source(headers), now)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("CheckTimestamp error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil && !headererrors.IsTimestampError(err) {
+				t.Errorf("expected timestamp error, got %T", err)
+			}
+		})
+	}
+}
+
+func TestCheckTimestamp_Disabled(t *testing.T) {
+	p := consensus.Mainnet()
+	p.MedianTimeSpan = 0
+	p.MaxFutureDrift = 0
+
+	h := &header.Header{Version: header.Version1, Index: 11, Timestamp: 1}
+	if err := p.CheckTimestamp(h, nil, time.Unix(0, 0)); err != nil {
+		t.Fatalf("disabled rules rejected header: %v", err)
+	}
+}
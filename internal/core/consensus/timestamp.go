@@ -0,0 +1,90 @@
+package consensus
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/Alex1997377/weave/internal/core/header"
+	headererrors "github.com/Alex1997377/weave/internal/core/header/errors"
+	"github.com/Alex1997377/weave/internal/core/header/errors/constants"
+)
+
+// MedianTimePast возвращает медиану времени MedianTimeSpan блоков, предшествующих
+// высоте height (у начала цепочки берутся все доступные). Для генезиса и при
+// отключённом правиле возвращает 0.
+func (p *Params) MedianTimePast(height int, headerAt HeaderSource) (int64, error) {
+	if height <= 0 || p.MedianTimeSpan == 0 {
+		return 0, nil
+	}
+	if headerAt == nil {
+		return 0, errors.New("header source is nil")
+	}
+
+	first := height - p.MedianTimeSpan
+	if first < 0 {
+		first = 0
+	}
+
+	times := make([]int64, 0, height-first)
+	for i := first; i < height; i++ {
+		h, err := headerAt(i)
+		if err != nil {
+			return 0, fmt.Errorf("failed to load header %d: %w", i, err)
+		}
+		times = append(times, h.Timestamp)
+	}
+
+	sort.Slice(times, func(i, j int) bool { return times[i] < times[j] })
+	return times[len(times)/2], nil
+}
+
+// CheckTimestamp проверяет контекстные правила времени заголовка: оно должно
+// быть строго больше MedianTimePast и не дальше MaxFutureDrift от now
+func (p *Params) CheckTimestamp(h *header.Header, headerAt HeaderSource, now time.Time) error {
+	if h == nil {
+		return errors.New("header is nil")
+	}
+
+	mtp, err := p.MedianTimePast(h.Index, headerAt)
+	if err != nil {
+		return err
+	}
+	if h.Index > 0 && p.MedianTimeSpan > 0 && h.Timestamp <= mtp {
+		return headererrors.NewTimestampError(constants.OpValidate, h.Timestamp,
+			fmt.Sprintf("%s %d", constants.RuleAfterMedian, mtp))
+	}
+
+	return p.CheckFutureDrift(h, now)
+}
+
+// CheckFutureDrift проверяет, что время заголовка не дальше MaxFutureDrift от now.
+// Правило не зависит от ветки, поэтому применяется и к блокам-сиротам.
+func (p *Params) CheckFutureDrift(h *header.Header, now time.Time) error {
+	if h == nil {
+		return errors.New("header is nil")
+	}
+	if p.MaxFutureDrift == 0 {
+		return nil
+	}
+
+	limit := now.Add(p.MaxFutureDrift).Unix()
+	if h.Timestamp > limit {
+		return headererrors.NewTimestampError(constants.OpValidate, h.Timestamp,
+			fmt.Sprintf("%s (limit %d)", constants.RuleNotFuture, limit))
+	}
+	return nil
+}
+
+// MinTimestamp возвращает наименьшее допустимое время блока на высоте height
+func (p *Params) MinTimestamp(height int, headerAt HeaderSource) (int64, error) {
+	mtp, err := p.MedianTimePast(height, headerAt)
+	if err != nil {
+		return 0, err
+	}
+	if height <= 0 || p.MedianTimeSpan == 0 {
+		return 0, nil
+	}
+	return mtp + 1, nil
+}
@@ -2,10 +2,12 @@ package constants
 
 // const from field
 const (
+	FieldVersion      = "VERSION"
 	FieldIndex        = "INDEX"
 	FieldTimestamp    = "TIMESTAMP"
 	FieldPreviousHash = "PREVIOUS_HASH"
 	FieldMerkleRoot   = "MERKLE_ROOT"
 	FieldNonce        = "NONCE"
 	FieldDifficulty   = "DIFFICULTY"
+	FieldBits         = "BITS"
 )
@@ -2,11 +2,15 @@ package constants
 
 // const from validation rules
 const (
-	RuleNonNegative = "MUST_BE_NON-NEGATIVE"
-	RulePositive    = "MUST_BE_POSITIVE"
-	RuleNotNil      = "MUST_NOT_BE_NIL"
-	RuleNotEmpty    = "MUST_NOT_BE_EMPTY"
-	RuleLength      = "INVALID_LENGTH"
-	RuleNotFuture   = "MUST_NOT_BE_IN_FUTURE"
-	RuleWithinRange = "MUST_BE_WITHIN_RANGE"
+	RuleNonNegative  = "MUST_BE_NON-NEGATIVE"
+	RulePositive     = "MUST_BE_POSITIVE"
+	RuleNotNil       = "MUST_NOT_BE_NIL"
+	RuleNotEmpty     = "MUST_NOT_BE_EMPTY"
+	RuleLength       = "INVALID_LENGTH"
+	RuleNotFuture    = "MUST_NOT_BE_IN_FUTURE"
+	RuleAfterMedian  = "MUST_BE_AFTER_MEDIAN_TIME_PAST"
+	RuleWithinRange  = "MUST_BE_WITHIN_RANGE"
+	RuleKnownVersion = "MUST_BE_KNOWN_VERSION"
+	RuleValidTarget  = "MUST_BE_VALID_TARGET"
+	RuleZero         = "MUST_BE_ZERO"
 )
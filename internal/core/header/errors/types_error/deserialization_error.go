@@ -17,3 +17,7 @@ func (e *DeserializationError) Error() string {
 	return fmt.Sprintf("header error [%s]: deserialization failed at stage '%s'",
 		e.Op, e.Stage)
 }
+
+func (e *DeserializationError) Unwrap() error {
+	return e.Err
+}
@@ -1,10 +1,15 @@
 package header
 
+// Header - заголовок блока. Цель proof of work задаётся в зависимости от версии:
+// Difficulty (число нулевых бит) в версии 1 и Bits (компактная цель) начиная с версии 2.
+// Поле другой версии должно быть нулевым, см. Target.
 type Header struct {
+	Version      uint32
 	Index        int
 	Timestamp    int64
 	PreviousHash []byte
 	MerkleRoot   []byte
-	Nonce        uint64
 	Difficulty   int
+	Bits         uint32
+	Nonce        uint64
 }
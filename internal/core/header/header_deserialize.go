@@ -4,45 +4,55 @@ import (
 	"bytes"
 	"encoding/binary"
 	"fmt"
+	"io"
+
+	"github.com/Alex1997377/weave/internal/core/header/errors"
+	"github.com/Alex1997377/weave/internal/core/header/errors/constants"
 )
 
+// DeserializeHeader читает канонический заголовок из буфера
 func DeserializeHeader(buf *bytes.Reader) (*Header, error) {
-	header := &Header{}
+	return DecodeHeader(buf)
+}
 
-	if err := binary.Read(buf, binary.LittleEndian, &header.Index); err != nil {
-		return nil, fmt.Errorf("failed to read index: %w", err)
+// DecodeHeader читает канонический заголовок из потока: сначала версию, затем
+// остаток раскладки этой версии. Лишние байты из потока не читаются.
+func DecodeHeader(buf io.Reader) (*Header, error) {
+	var versionBytes [4]byte
+	if _, err := io.ReadFull(buf, versionBytes[:]); err != nil {
+		return nil, errors.NewDeserializationError(constants.OpDeserialize, constants.FieldVersion, err)
 	}
 
-	if err := binary.Read(buf, binary.LittleEndian, &header.Timestamp); err != nil {
-		return nil, fmt.Errorf("failed to read timestamp: %w", err)
+	version := binary.LittleEndian.Uint32(versionBytes[:])
+	l, ok := layouts[version]
+	if !ok {
+		return nil, errors.NewDeserializationError(constants.OpDeserialize, constants.FieldVersion,
+			fmt.Errorf("unknown header version %d", version))
 	}
 
-	header.PreviousHash = make([]byte, 32)
-	n, err := buf.Read(header.PreviousHash)
-	if err != nil {
-		return nil, fmt.Errorf("failed to read previous hash: %w", err)
-	}
-	if n != 32 {
-		return nil, fmt.Errorf("invalid previous hash length: expected 32, got %d", n)
+	data := make([]byte, l.size)
+	copy(data, versionBytes[:])
+	if _, err := io.ReadFull(buf, data[4:]); err != nil {
+		return nil, errors.NewDeserializationError(constants.OpDeserialize, "body", err)
 	}
 
-	header.MerkleRoot = make([]byte, 32)
-	n, err = buf.Read(header.MerkleRoot)
-	if err != nil {
-		return nil, fmt.Errorf("failed to read merkle root: %w", err)
-	}
-	if n != 32 {
-		return nil, fmt.Errorf("invalid previous hash length: expected 32, got %d", n)
+	header := &Header{}
+	if err := l.decode(data, header); err != nil {
+		return nil, err
 	}
 
-	if err := binary.Read(buf, binary.LittleEndian, &header.Nonce); err != nil {
-		return nil, fmt.Errorf("failed to read nonce: %w", err)
-	}
+	return header, nil
+}
 
-	if err := binary.Read(buf, binary.LittleEndian, &header.Difficulty); err != nil {
-		return nil, fmt.Errorf("failed to read difficulty: %w", err)
+// EncodeHeader пишет канонический заголовок в поток
+func EncodeHeader(w io.Writer, h *Header) error {
+	data, err := h.Serialize()
+	if err != nil {
+		return err
 	}
 
-	return header, nil
-
+	if _, err := w.Write(data); err != nil {
+		return errors.NewSerializationError(constants.OpSerialize, "write", err)
+	}
+	return nil
 }
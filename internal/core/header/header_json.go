@@ -0,0 +1,68 @@
+package header
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+
+	"github.com/Alex1997377/weave/pkg/utils"
+)
+
+// headerJSON - JSON-представление заголовка с hex-хешами
+type headerJSON struct {
+	Version      uint32 `json:"version"`
+	Index        int    `json:"index"`
+	Timestamp    int64  `json:"timestamp"`
+	PreviousHash string `json:"previous_hash"`
+	MerkleRoot   string `json:"merkle_root"`
+	Difficulty   int    `json:"difficulty"`
+	Bits         uint32 `json:"bits"`
+	Nonce        uint64 `json:"nonce"`
+}
+
+func (h Header) MarshalJSON() ([]byte, error) {
+	return json.Marshal(headerJSON{
+		Version:      h.Version,
+		Index:        h.Index,
+		Timestamp:    h.Timestamp,
+		PreviousHash: hex.EncodeToString(h.PreviousHash),
+		MerkleRoot:   hex.EncodeToString(h.MerkleRoot),
+		Difficulty:   h.Difficulty,
+		Bits:         h.Bits,
+		Nonce:        h.Nonce,
+	})
+}
+
+// UnmarshalJSON строго декодирует заголовок: неизвестные поля и хеши
+// неверной длины отклоняются
+func (h *Header) UnmarshalJSON(data []byte) error {
+	var raw headerJSON
+	if err := utils.DecodeJSONStrict(data, &raw); err != nil {
+		return fmt.Errorf("invalid header JSON: %w", err)
+	}
+
+	prevHash, err := utils.HexToFixedBytes("previous_hash", raw.PreviousHash, 32)
+	if err != nil {
+		return err
+	}
+
+	var merkleRoot []byte
+	if raw.MerkleRoot != "" {
+		merkleRoot, err = utils.HexToFixedBytes("merkle_root", raw.MerkleRoot, 32)
+		if err != nil {
+			return err
+		}
+	}
+
+	*h = Header{
+		Version:      raw.Version,
+		Index:        raw.Index,
+		Timestamp:    raw.Timestamp,
+		PreviousHash: prevHash,
+		MerkleRoot:   merkleRoot,
+		Difficulty:   raw.Difficulty,
+		Bits:         raw.Bits,
+		Nonce:        raw.Nonce,
+	}
+	return nil
+}
@@ -1,18 +1,49 @@
 package header
 
 import (
-	"bytes"
 	"encoding/binary"
+	"fmt"
 
 	"github.com/Alex1997377/weave/internal/core/header/errors"
 	"github.com/Alex1997377/weave/internal/core/header/errors/constants"
+	"github.com/Alex1997377/weave/internal/crypto/pow"
 	"github.com/Alex1997377/weave/pkg/utils"
 )
 
+// HeaderSizeV1 - размер канонического заголовка версии 1:
+//
+//	version     uint32
+//	index       uint64
+//	timestamp   int64
+//	prevHash    [32]byte
+//	merkleRoot  [32]byte   нулевой MerkleRoot кодируется 32 нулевыми байтами
+//	difficulty  uint32
+//	nonce       uint64     всегда последнее поле, см. SerializeWithoutNonce
+const HeaderSizeV1 = 4 + 8 + 8 + 32 + 32 + 4 + 8
+
+// HeaderSizeV2 - размер заголовка версии 2: раскладка версии 1, в которой
+// поле difficulty заменено компактной целью bits (uint32)
+const HeaderSizeV2 = HeaderSizeV1
+
+// layout описывает каноническую раскладку одной версии заголовка
+type layout struct {
+	size   int
+	encode func(h *Header, dst []byte) error
+	decode func(src []byte, h *Header) error
+}
+
+var layouts = map[uint32]layout{
+	Version1: {size: HeaderSizeV1, encode: encodeV1, decode: decodeV1},
+	Version2: {size: HeaderSizeV2, encode: encodeV2, decode: decodeV2},
+}
+
 func (h *Header) Validate(op string) error {
 	if h == nil {
 		return errors.NewNilHeaderError(op)
 	}
+	if !IsKnownVersion(h.Version) {
+		return errors.NewValidationError(op, constants.FieldVersion, h.Version, constants.RuleKnownVersion)
+	}
 	if h.Index < 0 {
 		return errors.NewIndexError(op, h.Index)
 	}
@@ -27,13 +58,28 @@ func (h *Header) Validate(op string) error {
 		return err
 	}
 
-	if h.Difficulty < 0 {
+	// Поле цели другой версии должно быть нулевым, иначе оно не попадает в хеш
+	if h.UsesBits() {
+		if h.Difficulty != 0 {
+			return errors.NewValidationError(op, constants.FieldDifficulty, h.Difficulty, constants.RuleZero)
+		}
+		if _, err := pow.CompactToTarget(h.Bits); err != nil {
+			return errors.NewValidationError(op, constants.FieldBits, h.Bits, constants.RuleValidTarget)
+		}
+		return nil
+	}
+
+	if h.Bits != 0 {
+		return errors.NewValidationError(op, constants.FieldBits, h.Bits, constants.RuleZero)
+	}
+	if h.Difficulty < 0 || h.Difficulty > 255 {
 		return errors.NewDifficultyError(op, h.Difficulty, 0, 255)
 	}
 
 	return nil
 }
 
+// Serialize возвращает каноническое представление заголовка, по которому считается хеш
 func (h *Header) Serialize() ([]byte, error) {
 	// Получаем сериализованный заголовок с нулевым nonce и смещение
 	data, nonceOffset, err := h.SerializeWithoutNonce()
@@ -41,26 +87,116 @@ func (h *Header) Serialize() ([]byte, error) {
 		return nil, err
 	}
 	// Записываем реальный nonce как uint64 (8 байт) в нужное место
-	binary.LittleEndian.PutUint64(data[nonceOffset:], uint64(h.Nonce))
+	binary.LittleEndian.PutUint64(data[nonceOffset:], h.Nonce)
 	return data, nil
 }
 
+// SerializeWithoutNonce возвращает каноническое представление с нулевым nonce
+// и смещение nonce. Nonce всегда занимает последние 8 байт заголовка.
 func (h *Header) SerializeWithoutNonce() ([]byte, int, error) {
-	buf := bytes.NewBuffer(make([]byte, 0, 104))
+	if h == nil {
+		return nil, 0, errors.NewNilHeaderError(constants.OpSerialize)
+	}
 
-	binary.Write(buf, binary.LittleEndian, h.Index)
-	binary.Write(buf, binary.LittleEndian, h.Timestamp)
+	l, ok := layouts[h.Version]
+	if !ok {
+		return nil, 0, errors.NewValidationError(constants.OpSerialize, constants.FieldVersion, h.Version, constants.RuleKnownVersion)
+	}
 
-	binary.Write(buf, binary.LittleEndian, uint32(len(h.PreviousHash)))
-	buf.Write(h.PreviousHash)
+	data := make([]byte, l.size)
+	if err := l.encode(h, data); err != nil {
+		return nil, 0, err
+	}
 
-	binary.Write(buf, binary.LittleEndian, uint32(len(h.MerkleRoot)))
-	buf.Write(h.MerkleRoot)
+	nonceOffset := l.size - 8
+	binary.LittleEndian.PutUint64(data[nonceOffset:], 0)
+	return data, nonceOffset, nil
+}
 
-	binary.Write(buf, binary.LittleEndian, h.Difficulty)
+func encodeV1(h *Header, dst []byte) error {
+	if h.Index < 0 {
+		return errors.NewIndexError(constants.OpSerialize, h.Index)
+	}
+	if h.Difficulty < 0 || h.Difficulty > 255 {
+		return errors.NewDifficultyError(constants.OpSerialize, h.Difficulty, 0, 255)
+	}
 
-	nonceOffset := buf.Len()
-	binary.Write(buf, binary.LittleEndian, uint64(0))
+	binary.LittleEndian.PutUint32(dst[0:], h.Version)
+	binary.LittleEndian.PutUint64(dst[4:], uint64(h.Index))
+	binary.LittleEndian.PutUint64(dst[12:], uint64(h.Timestamp))
 
-	return buf.Bytes(), nonceOffset, nil
+	if err := putHash(dst[20:52], constants.FieldPreviousHash, h.PreviousHash); err != nil {
+		return err
+	}
+	if err := putHash(dst[52:84], constants.FieldMerkleRoot, h.MerkleRoot); err != nil {
+		return err
+	}
+
+	binary.LittleEndian.PutUint32(dst[84:], uint32(h.Difficulty))
+	binary.LittleEndian.PutUint64(dst[88:], h.Nonce)
+	return nil
+}
+
+func encodeV2(h *Header, dst []byte) error {
+	if h.Index < 0 {
+		return errors.NewIndexError(constants.OpSerialize, h.Index)
+	}
+
+	binary.LittleEndian.PutUint32(dst[0:], h.Version)
+	binary.LittleEndian.PutUint64(dst[4:], uint64(h.Index))
+	binary.LittleEndian.PutUint64(dst[12:], uint64(h.Timestamp))
+
+	if err := putHash(dst[20:52], constants.FieldPreviousHash, h.PreviousHash); err != nil {
+		return err
+	}
+	if err := putHash(dst[52:84], constants.FieldMerkleRoot, h.MerkleRoot); err != nil {
+		return err
+	}
+
+	binary.LittleEndian.PutUint32(dst[84:], h.Bits)
+	binary.LittleEndian.PutUint64(dst[88:], h.Nonce)
+	return nil
+}
+
+func decodeV2(src []byte, h *Header) error {
+	h.Version = binary.LittleEndian.Uint32(src[0:])
+	h.Index = int(binary.LittleEndian.Uint64(src[4:]))
+	h.Timestamp = int64(binary.LittleEndian.Uint64(src[12:]))
+	h.PreviousHash = append([]byte(nil), src[20:52]...)
+	h.MerkleRoot = append([]byte(nil), src[52:84]...)
+	h.Bits = binary.LittleEndian.Uint32(src[84:])
+	h.Nonce = binary.LittleEndian.Uint64(src[88:])
+
+	if h.Index < 0 {
+		return errors.NewIndexError(constants.OpDeserialize, h.Index)
+	}
+	return nil
+}
+
+func decodeV1(src []byte, h *Header) error {
+	h.Version = binary.LittleEndian.Uint32(src[0:])
+	h.Index = int(binary.LittleEndian.Uint64(src[4:]))
+	h.Timestamp = int64(binary.LittleEndian.Uint64(src[12:]))
+	h.PreviousHash = append([]byte(nil), src[20:52]...)
+	h.MerkleRoot = append([]byte(nil), src[52:84]...)
+	h.Difficulty = int(binary.LittleEndian.Uint32(src[84:]))
+	h.Nonce = binary.LittleEndian.Uint64(src[88:])
+
+	if h.Index < 0 {
+		return errors.NewIndexError(constants.OpDeserialize, h.Index)
+	}
+	return nil
+}
+
+// putHash пишет 32-байтовое поле; пустое значение кодируется нулями
+func putHash(dst []byte, field string, value []byte) error {
+	if len(value) == 0 {
+		return nil
+	}
+	if len(value) != len(dst) {
+		return errors.NewSerializationError(constants.OpSerialize, field,
+			fmt.Errorf("invalid length %d (expected %d)", len(value), len(dst)))
+	}
+	copy(dst, value)
+	return nil
 }
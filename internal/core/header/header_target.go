@@ -0,0 +1,34 @@
+package header
+
+import (
+	"fmt"
+	"math/big"
+
+	"github.com/Alex1997377/weave/internal/crypto/pow"
+)
+
+// UsesBits сообщает, задаётся ли цель заголовка компактным полем Bits
+func (h *Header) UsesBits() bool {
+	return h.Version >= Version2
+}
+
+// Target возвращает цель proof of work заголовка
+func (h *Header) Target() (pow.Target, error) {
+	if h == nil {
+		return pow.Target{}, fmt.Errorf("header is nil")
+	}
+
+	if h.UsesBits() {
+		return pow.CompactToTarget(h.Bits)
+	}
+	return pow.DifficultyToTarget(h.Difficulty), nil
+}
+
+// Work возвращает работу, которую доказывает заголовок
+func (h *Header) Work() (*big.Int, error) {
+	target, err := h.Target()
+	if err != nil {
+		return nil, err
+	}
+	return target.Work(), nil
+}
@@ -0,0 +1,85 @@
+package header
+
+import (
+	"fmt"
+	"sort"
+)
+
+// Известные версии заголовка. Каждая версия имеет собственную раскладку
+// (см. layouts в header_serialize.go), поэтому старые блоки остаются читаемыми
+// после появления новых версий.
+const (
+	// Version1 - цель задаётся сложностью в битах (Difficulty)
+	Version1 uint32 = 1
+	// Version2 - цель задаётся компактным полем Bits
+	Version2 uint32 = 2
+
+	CurrentVersion = Version2
+)
+
+// Activation задаёт высоту, начиная с которой блоки обязаны иметь версию не ниже Version
+type Activation struct {
+	Version uint32
+	Height  int
+}
+
+// ActivationSchedule - набор активаций версий заголовка по высоте
+type ActivationSchedule []Activation
+
+// DefaultActivations - расписание по умолчанию: версия 1 с генезиса
+var DefaultActivations = ActivationSchedule{
+	{Version: Version1, Height: 0},
+}
+
+// IsKnownVersion сообщает, умеет ли узел кодировать заголовки этой версии
+func IsKnownVersion(version uint32) bool {
+	_, ok := layouts[version]
+	return ok
+}
+
+// VersionAt возвращает минимальную версию заголовка, обязательную на высоте height
+func (s ActivationSchedule) VersionAt(height int) uint32 {
+	sorted := s.sorted()
+
+	version := Version1
+	for _, a := range sorted {
+		if a.Height > height {
+			break
+		}
+		version = a.Version
+	}
+	return version
+}
+
+// Check проверяет, что версия заголовка известна и активирована для его высоты
+func (s ActivationSchedule) Check(h *Header) error {
+	if h == nil {
+		return fmt.Errorf("header is nil")
+	}
+
+	if !IsKnownVersion(h.Version) {
+		return fmt.Errorf("unknown header version %d at height %d", h.Version, h.Index)
+	}
+
+	required := s.VersionAt(h.Index)
+	if h.Version < required {
+		return fmt.Errorf("header version %d at height %d is below required version %d", h.Version, h.Index, required)
+	}
+
+	for _, a := range s {
+		if a.Version == h.Version && h.Index < a.Height {
+			return fmt.Errorf("header version %d is not active until height %d", h.Version, a.Height)
+		}
+	}
+
+	return nil
+}
+
+func (s ActivationSchedule) sorted() ActivationSchedule {
+	sorted := make(ActivationSchedule, len(s))
+	copy(sorted, s)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].Height < sorted[j].Height
+	})
+	return sorted
+}
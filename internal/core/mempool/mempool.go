@@ -0,0 +1,132 @@
+package mempool
+
+import (
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/Alex1997377/weave/internal/core/transaction"
+)
+
+// DefaultMaxSize - ёмкость пула по умолчанию
+const DefaultMaxSize = 10000
+
+var (
+	ErrNilTransaction = errors.New("transaction is nil")
+	ErrDuplicate      = errors.New("transaction already in mempool")
+	ErrFull           = errors.New("mempool is full")
+	ErrCoinbase       = errors.New("coinbase transaction cannot be added to mempool")
+)
+
+// Mempool - очередь неподтверждённых транзакций в порядке поступления.
+// Безопасен для конкурентного использования.
+type Mempool struct {
+	mu      sync.Mutex
+	maxSize int
+	byID    map[string]transaction.Transaction
+	order   []string
+}
+
+// New создаёт пул ёмкостью maxSize транзакций (0 - DefaultMaxSize)
+func New(maxSize int) *Mempool {
+	if maxSize <= 0 {
+		maxSize = DefaultMaxSize
+	}
+	return &Mempool{
+		maxSize: maxSize,
+		byID:    make(map[string]transaction.Transaction),
+	}
+}
+
+// Add проверяет транзакцию и ставит её в очередь
+func (m *Mempool) Add(tx transaction.Transaction) error {
+	if tx == nil {
+		return ErrNilTransaction
+	}
+	if transaction.IsCoinbase(tx) {
+		return ErrCoinbase
+	}
+	if err := tx.TransactionValidate(); err != nil {
+		return fmt.Errorf("invalid transaction: %w", err)
+	}
+
+	id := hex.EncodeToString(tx.TransactionGetID())
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.byID[id]; ok {
+		return fmt.Errorf("%w: %s", ErrDuplicate, id)
+	}
+	if len(m.byID) >= m.maxSize {
+		return ErrFull
+	}
+
+	m.byID[id] = tx
+	m.order = append(m.order, id)
+	return nil
+}
+
+// Pending возвращает до max транзакций в порядке поступления (max <= 0 - все)
+func (m *Mempool) Pending(max int) []transaction.Transaction {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	n := len(m.order)
+	if max > 0 && max < n {
+		n = max
+	}
+
+	txs := make([]transaction.Transaction, 0, n)
+	for _, id := range m.order[:n] {
+		txs = append(txs, m.byID[id])
+	}
+	return txs
+}
+
+// Remove удаляет подтверждённые транзакции; неизвестные пропускаются
+func (m *Mempool) Remove(txs []transaction.Transaction) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	removed := 0
+	for _, tx := range txs {
+		if tx == nil {
+			continue
+		}
+		id := hex.EncodeToString(tx.TransactionGetID())
+		if _, ok := m.byID[id]; ok {
+			delete(m.byID, id)
+			removed++
+		}
+	}
+	if removed == 0 {
+		return
+	}
+
+	order := m.order[:0]
+	for _, id := range m.order {
+		if _, ok := m.byID[id]; ok {
+			order = append(order, id)
+		}
+	}
+	m.order = order
+}
+
+// Has сообщает, есть ли в пуле транзакция с данным ID
+func (m *Mempool) Has(id []byte) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	_, ok := m.byID[hex.EncodeToString(id)]
+	return ok
+}
+
+// Len возвращает число транзакций в пуле
+func (m *Mempool) Len() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return len(m.order)
+}
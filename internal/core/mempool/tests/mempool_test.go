@@ -0,0 +1,63 @@
+package tests
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"github.com/Alex1997377/weave/internal/core/mempool"
+	"github.com/Alex1997377/weave/internal/core/transaction"
+	"github.com/Alex1997377/weave/internal/tests/helpers"
+)
+
+func TestMempool_AddPendingRemove(t *testing.T) {
+	pool := mempool.New(0)
+
+	for seed := byte(1); seed <= 3; seed++ {
+		if err := pool.Add(helpers.BankTransaction(seed)); err != nil {
+			t.Fatalf("Add(%d) error: %v", seed, err)
+		}
+	}
+
+	pending := pool.Pending(2)
+	if len(pending) != 2 || pending[0].TransactionGetID()[0] != 1 || pending[1].TransactionGetID()[0] != 2 {
+		t.Fatalf("Pending(2) must return the oldest transactions in order")
+	}
+
+	pool.Remove([]transaction.Transaction{helpers.BankTransaction(2), helpers.BankTransaction(9)})
+	if pool.Len() != 2 || pool.Has(helpers.BankTransaction(2).ID) {
+		t.Errorf("transaction 2 was not removed")
+	}
+
+	pending = pool.Pending(0)
+	if len(pending) != 2 || pending[0].TransactionGetID()[0] != 1 || pending[1].TransactionGetID()[0] != 3 {
+		t.Errorf("order broken after Remove")
+	}
+}
+
+func TestMempool_Rejects(t *testing.T) {
+	pool := mempool.New(1)
+
+	if err := pool.Add(nil); !errors.Is(err, mempool.ErrNilTransaction) {
+		t.Errorf("nil: got %v", err)
+	}
+	if err := pool.Add(transaction.NewCoinbase(1, bytes.Repeat([]byte{1}, 32), 50)); !errors.Is(err, mempool.ErrCoinbase) {
+		t.Errorf("coinbase: got %v", err)
+	}
+
+	invalid := helpers.BankTransaction(1)
+	invalid.Amount = 0
+	if err := pool.Add(invalid); err == nil {
+		t.Error("expected error for invalid transaction")
+	}
+
+	if err := pool.Add(helpers.BankTransaction(1)); err != nil {
+		t.Fatalf("Add error: %v", err)
+	}
+	if err := pool.Add(helpers.BankTransaction(1)); !errors.Is(err, mempool.ErrDuplicate) {
+		t.Errorf("duplicate: got %v", err)
+	}
+	if err := pool.Add(helpers.BankTransaction(2)); !errors.Is(err, mempool.ErrFull) {
+		t.Errorf("full: got %v", err)
+	}
+}
@@ -3,6 +3,7 @@ package blockdeserialize
 import (
 	"bytes"
 	"errors"
+	"fmt"
 	"sync"
 
 	"github.com/Alex1997377/weave/internal/core/block/interfaces"
@@ -57,7 +58,11 @@ func (wp *WorkerPool) worker() {
 				continue
 			}
 
-			tx, err := wp.TxDeserializer.DeserializeTransaction(bytes.NewReader(task.Data))
+			reader := bytes.NewReader(task.Data)
+			tx, err := wp.TxDeserializer.DeserializeTransaction(reader)
+			if err == nil && reader.Len() != 0 {
+				tx, err = nil, fmt.Errorf("transaction has %d trailing bytes", reader.Len())
+			}
 
 			wp.Results <- &TxResult{
 				Index: task.Index,
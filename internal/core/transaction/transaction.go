@@ -20,12 +20,14 @@ type Transaction interface {
 	TransactionSerialize() ([]byte, error)
 }
 
+// BankTransaction - перевод между адресами. В JSON байтовые поля кодируются
+// hex-строками (см. transaction_json.go).
 type BankTransaction struct {
-	ID        []byte  `json:"id"`
-	Sender    []byte  `json:"sender"`
-	Recipient []byte  `json:"recipient"`
-	Amount    float64 `json:"amount"`
-	Signature []byte  `json:"signature"`
+	ID        []byte
+	Sender    []byte
+	Recipient []byte
+	Amount    float64
+	Signature []byte
 }
 
 func (bt *BankTransaction) TransactionGetID() []byte {
@@ -0,0 +1,155 @@
+package transaction
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+)
+
+// MaxCoinbaseDataSize - максимальный размер произвольных данных майнера в coinbase
+const MaxCoinbaseDataSize = 100
+
+// CoinbaseTransaction - транзакция награды за блок. У неё нет отправителя и
+// подписи, а ID вычисляется из содержимого, поэтому изменение ExtraNonce меняет
+// MerkleRoot и даёт майнеру новое пространство перебора сверх 64-битного nonce.
+type CoinbaseTransaction struct {
+	Height     int
+	Recipient  []byte
+	Amount     float64
+	ExtraNonce uint64
+	Data       []byte
+}
+
+// NewCoinbase создаёт coinbase-транзакцию награды amount для блока на высоте height
+func NewCoinbase(height int, recipient []byte, amount float64) *CoinbaseTransaction {
+	return &CoinbaseTransaction{
+		Height:    height,
+		Recipient: recipient,
+		Amount:    amount,
+	}
+}
+
+func (ct *CoinbaseTransaction) TransactionType() TxType {
+	return TxTypeCoinbase
+}
+
+// TransactionGetID возвращает sha256 сериализованной транзакции
+func (ct *CoinbaseTransaction) TransactionGetID() []byte {
+	data, err := ct.TransactionSerialize()
+	if err != nil {
+		return nil
+	}
+	id := sha256.Sum256(data)
+	return id[:]
+}
+
+// TransactionGetSender возвращает nil: монеты coinbase создаются, а не переводятся
+func (ct *CoinbaseTransaction) TransactionGetSender() []byte {
+	return nil
+}
+
+func (ct *CoinbaseTransaction) TransactionGetRecipient() []byte {
+	return ct.Recipient
+}
+
+func (ct *CoinbaseTransaction) TransactionGetAmount() float64 {
+	return ct.Amount
+}
+
+func (ct *CoinbaseTransaction) TransactionValidate() error {
+	if ct.Height < 0 {
+		return fmt.Errorf("coinbase height cannot be negative: %d", ct.Height)
+	}
+	if len(ct.Recipient) != 32 {
+		return fmt.Errorf("invalid coinbase recipient length: expected 32, got %d", len(ct.Recipient))
+	}
+	if ct.Amount < 0 || math.IsNaN(ct.Amount) || math.IsInf(ct.Amount, 0) {
+		return fmt.Errorf("invalid coinbase amount: %v", ct.Amount)
+	}
+	if len(ct.Data) > MaxCoinbaseDataSize {
+		return fmt.Errorf("coinbase data too large: %d (max: %d)", len(ct.Data), MaxCoinbaseDataSize)
+	}
+	return nil
+}
+
+func (ct *CoinbaseTransaction) TransactionSign(privateKey []byte) error {
+	return errors.New("coinbase transaction cannot be signed")
+}
+
+// TransactionVerify всегда успешна: coinbase не требует подписи
+func (ct *CoinbaseTransaction) TransactionVerify(publicKey []byte) bool {
+	return true
+}
+
+// TransactionSerialize кодирует coinbase в little-endian:
+// height uint64, recipient [32]byte, amount float64, extraNonce uint64, dataLen uint32, data
+func (ct *CoinbaseTransaction) TransactionSerialize() ([]byte, error) {
+	if err := ct.TransactionValidate(); err != nil {
+		return nil, err
+	}
+
+	buf := bytes.NewBuffer(make([]byte, 0, 8+32+8+8+4+len(ct.Data)))
+	var scratch [8]byte
+
+	binary.LittleEndian.PutUint64(scratch[:], uint64(ct.Height))
+	buf.Write(scratch[:])
+	buf.Write(ct.Recipient)
+	binary.LittleEndian.PutUint64(scratch[:], math.Float64bits(ct.Amount))
+	buf.Write(scratch[:])
+	binary.LittleEndian.PutUint64(scratch[:], ct.ExtraNonce)
+	buf.Write(scratch[:])
+	binary.LittleEndian.PutUint32(scratch[:4], uint32(len(ct.Data)))
+	buf.Write(scratch[:4])
+	buf.Write(ct.Data)
+
+	return buf.Bytes(), nil
+}
+
+// DeserializeCoinbaseFromReader читает тело coinbase в формате TransactionSerialize
+func DeserializeCoinbaseFromReader(r io.Reader) (*CoinbaseTransaction, error) {
+	if r == nil {
+		return nil, errors.New("buffer is nil")
+	}
+
+	var fixed [8 + 32 + 8 + 8 + 4]byte
+	if _, err := io.ReadFull(r, fixed[:]); err != nil {
+		return nil, fmt.Errorf("failed to read coinbase: %w", err)
+	}
+
+	height := binary.LittleEndian.Uint64(fixed[0:])
+	if height > math.MaxInt64 {
+		return nil, fmt.Errorf("coinbase height overflows: %d", height)
+	}
+
+	ct := &CoinbaseTransaction{
+		Height:     int(height),
+		Recipient:  append([]byte(nil), fixed[8:40]...),
+		Amount:     math.Float64frombits(binary.LittleEndian.Uint64(fixed[40:])),
+		ExtraNonce: binary.LittleEndian.Uint64(fixed[48:]),
+	}
+
+	dataLen := binary.LittleEndian.Uint32(fixed[56:])
+	if dataLen > MaxCoinbaseDataSize {
+		return nil, fmt.Errorf("coinbase data too large: %d (max: %d)", dataLen, MaxCoinbaseDataSize)
+	}
+	if dataLen > 0 {
+		ct.Data = make([]byte, dataLen)
+		if _, err := io.ReadFull(r, ct.Data); err != nil {
+			return nil, fmt.Errorf("failed to read coinbase data: %w", err)
+		}
+	}
+
+	if err := ct.TransactionValidate(); err != nil {
+		return nil, err
+	}
+	return ct, nil
+}
+
+// IsCoinbase сообщает, является ли транзакция coinbase
+func IsCoinbase(tx Transaction) bool {
+	return tx != nil && TypeOf(tx) == TxTypeCoinbase
+}
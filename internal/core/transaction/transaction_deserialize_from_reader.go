@@ -1,14 +1,15 @@
 package transaction
 
 import (
-	"bytes"
 	"encoding/binary"
 	"errors"
 	"fmt"
+	"io"
 	"math"
 )
 
-func DeserializeTransactionFromReader(buf *bytes.Reader) (*BankTransaction, error) {
+// DeserializeTransactionFromReader читает тело банковской транзакции в формате TransactionSerialize
+func DeserializeTransactionFromReader(buf io.Reader) (*BankTransaction, error) {
 	if buf == nil {
 		return nil, errors.New("buffer is nil")
 	}
@@ -16,7 +17,7 @@ func DeserializeTransactionFromReader(buf *bytes.Reader) (*BankTransaction, erro
 	tx := &BankTransaction{}
 
 	tx.Sender = make([]byte, 32)
-	n, err := buf.Read(tx.Sender)
+	n, err := io.ReadFull(buf, tx.Sender)
 	if err != nil {
 		return nil, fmt.Errorf("failed to read sender: %w", err)
 	}
@@ -25,7 +26,7 @@ func DeserializeTransactionFromReader(buf *bytes.Reader) (*BankTransaction, erro
 	}
 
 	tx.Recipient = make([]byte, 32)
-	n, err = buf.Read(tx.Recipient)
+	n, err = io.ReadFull(buf, tx.Recipient)
 	if err != nil {
 		return nil, fmt.Errorf("failed to read recipient: %w", err)
 	}
@@ -44,12 +45,12 @@ func DeserializeTransactionFromReader(buf *bytes.Reader) (*BankTransaction, erro
 		return nil, fmt.Errorf("failed to read signature length: %w", err)
 	}
 
-	if sigLen > 1024 {
+	if sigLen > MaxSignatureSize {
 		return nil, fmt.Errorf("signature length too large: %d", sigLen)
 	}
 
 	tx.Signature = make([]byte, sigLen)
-	n, err = buf.Read(tx.Signature)
+	n, err = io.ReadFull(buf, tx.Signature)
 	if err != nil {
 		return nil, fmt.Errorf("failed to read signature: %w", err)
 	}
@@ -58,7 +59,7 @@ func DeserializeTransactionFromReader(buf *bytes.Reader) (*BankTransaction, erro
 	}
 
 	tx.ID = make([]byte, 32)
-	n, err = buf.Read(tx.ID)
+	n, err = io.ReadFull(buf, tx.ID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to read transaction ID: %w", err)
 	}
@@ -0,0 +1,208 @@
+package transaction
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+
+	"github.com/Alex1997377/weave/pkg/utils"
+)
+
+// JSON-имена типов транзакций
+var typeNames = map[TxType]string{
+	TxTypeBank:     "bank",
+	TxTypeCoinbase: "coinbase",
+}
+
+// jsonDecoders восстанавливают транзакцию конкретного типа из JSON
+var jsonDecoders = map[TxType]func(data []byte) (Transaction, error){
+	TxTypeBank: func(data []byte) (Transaction, error) {
+		tx := &BankTransaction{}
+		if err := json.Unmarshal(data, tx); err != nil {
+			return nil, err
+		}
+		return tx, nil
+	},
+	TxTypeCoinbase: func(data []byte) (Transaction, error) {
+		tx := &CoinbaseTransaction{}
+		if err := json.Unmarshal(data, tx); err != nil {
+			return nil, err
+		}
+		return tx, nil
+	},
+}
+
+func (t TxType) String() string {
+	if name, ok := typeNames[t]; ok {
+		return name
+	}
+	return fmt.Sprintf("0x%02x", uint8(t))
+}
+
+// bankTransactionJSON - JSON-представление банковской транзакции с hex-полями
+type bankTransactionJSON struct {
+	Type      string  `json:"type"`
+	ID        string  `json:"id"`
+	Sender    string  `json:"sender"`
+	Recipient string  `json:"recipient"`
+	Amount    float64 `json:"amount"`
+	Signature string  `json:"signature"`
+}
+
+func (bt *BankTransaction) MarshalJSON() ([]byte, error) {
+	return json.Marshal(bankTransactionJSON{
+		Type:      TxTypeBank.String(),
+		ID:        hex.EncodeToString(bt.ID),
+		Sender:    hex.EncodeToString(bt.Sender),
+		Recipient: hex.EncodeToString(bt.Recipient),
+		Amount:    bt.Amount,
+		Signature: hex.EncodeToString(bt.Signature),
+	})
+}
+
+// UnmarshalJSON строго декодирует банковскую транзакцию: неизвестные поля,
+// чужой тип и поля неверной длины отклоняются
+func (bt *BankTransaction) UnmarshalJSON(data []byte) error {
+	var raw bankTransactionJSON
+	if err := utils.DecodeJSONStrict(data, &raw); err != nil {
+		return fmt.Errorf("invalid bank transaction JSON: %w", err)
+	}
+
+	if raw.Type != TxTypeBank.String() {
+		return fmt.Errorf("unexpected transaction type %q", raw.Type)
+	}
+
+	id, err := utils.HexToFixedBytes("id", raw.ID, 32)
+	if err != nil {
+		return err
+	}
+	sender, err := utils.HexToFixedBytes("sender", raw.Sender, 32)
+	if err != nil {
+		return err
+	}
+	recipient, err := utils.HexToFixedBytes("recipient", raw.Recipient, 32)
+	if err != nil {
+		return err
+	}
+	signature, err := utils.HexToBytes(raw.Signature)
+	if err != nil {
+		return fmt.Errorf("signature: %w", err)
+	}
+	if len(signature) > MaxSignatureSize {
+		return fmt.Errorf("signature length too large: %d", len(signature))
+	}
+
+	*bt = BankTransaction{
+		ID:        id,
+		Sender:    sender,
+		Recipient: recipient,
+		Amount:    raw.Amount,
+		Signature: signature,
+	}
+	return nil
+}
+
+// coinbaseTransactionJSON - JSON-представление coinbase. Поле id вычисляемое:
+// при декодировании оно сверяется с содержимым.
+type coinbaseTransactionJSON struct {
+	Type       string  `json:"type"`
+	ID         string  `json:"id"`
+	Height     int     `json:"height"`
+	Recipient  string  `json:"recipient"`
+	Amount     float64 `json:"amount"`
+	ExtraNonce uint64  `json:"extra_nonce"`
+	Data       string  `json:"data"`
+}
+
+func (ct *CoinbaseTransaction) MarshalJSON() ([]byte, error) {
+	return json.Marshal(coinbaseTransactionJSON{
+		Type:       TxTypeCoinbase.String(),
+		ID:         hex.EncodeToString(ct.TransactionGetID()),
+		Height:     ct.Height,
+		Recipient:  hex.EncodeToString(ct.Recipient),
+		Amount:     ct.Amount,
+		ExtraNonce: ct.ExtraNonce,
+		Data:       hex.EncodeToString(ct.Data),
+	})
+}
+
+// UnmarshalJSON строго декодирует coinbase и проверяет, что id соответствует содержимому
+func (ct *CoinbaseTransaction) UnmarshalJSON(data []byte) error {
+	var raw coinbaseTransactionJSON
+	if err := utils.DecodeJSONStrict(data, &raw); err != nil {
+		return fmt.Errorf("invalid coinbase transaction JSON: %w", err)
+	}
+
+	if raw.Type != TxTypeCoinbase.String() {
+		return fmt.Errorf("unexpected transaction type %q", raw.Type)
+	}
+
+	id, err := utils.HexToFixedBytes("id", raw.ID, 32)
+	if err != nil {
+		return err
+	}
+	recipient, err := utils.HexToFixedBytes("recipient", raw.Recipient, 32)
+	if err != nil {
+		return err
+	}
+	extra, err := utils.HexToBytes(raw.Data)
+	if err != nil {
+		return fmt.Errorf("data: %w", err)
+	}
+
+	decoded := CoinbaseTransaction{
+		Height:     raw.Height,
+		Recipient:  recipient,
+		Amount:     raw.Amount,
+		ExtraNonce: raw.ExtraNonce,
+	}
+	if len(extra) > 0 {
+		decoded.Data = extra
+	}
+	if err := decoded.TransactionValidate(); err != nil {
+		return err
+	}
+	if !bytes.Equal(decoded.TransactionGetID(), id) {
+		return fmt.Errorf("coinbase id doesn`t match content")
+	}
+
+	*ct = decoded
+	return nil
+}
+
+// MarshalTransactionJSON кодирует транзакцию любого типа. Транзакции, не
+// умеющие кодировать себя в JSON, отклоняются.
+func MarshalTransactionJSON(tx Transaction) ([]byte, error) {
+	if tx == nil {
+		return nil, fmt.Errorf("transaction is nil")
+	}
+
+	marshaler, ok := tx.(json.Marshaler)
+	if !ok {
+		return nil, fmt.Errorf("transaction of type %s does not support JSON", TypeOf(tx))
+	}
+	return marshaler.MarshalJSON()
+}
+
+// UnmarshalTransactionJSON читает поле "type" и декодирует транзакцию соответствующего типа
+func UnmarshalTransactionJSON(data []byte) (Transaction, error) {
+	var probe struct {
+		Type string `json:"type"`
+	}
+	if err := json.Unmarshal(data, &probe); err != nil {
+		return nil, fmt.Errorf("invalid transaction JSON: %w", err)
+	}
+
+	for typ, name := range typeNames {
+		if name == probe.Type {
+			decode, ok := jsonDecoders[typ]
+			if !ok {
+				break
+			}
+			return decode(data)
+		}
+	}
+
+	return nil, fmt.Errorf("unknown transaction type %q", probe.Type)
+}
@@ -0,0 +1,111 @@
+package transaction
+
+import (
+	"errors"
+	"fmt"
+	"io"
+)
+
+// TxType - однобайтовый тег типа транзакции в конверте блока
+type TxType uint8
+
+const (
+	TxTypeBank     TxType = 0x01
+	TxTypeCoinbase TxType = 0x02
+)
+
+// MaxSignatureSize - верхняя граница длины подписи при декодировании. Правило
+// консенсуса может быть строже (consensus.Params.MaxSignatureSize).
+const MaxSignatureSize = 1024
+
+// SignedTransaction реализуют транзакции, которые несут подпись
+type SignedTransaction interface {
+	TransactionGetSignature() []byte
+}
+
+// TypedTransaction реализуют транзакции, которые сами сообщают свой тип.
+// Транзакции без этого метода считаются банковскими.
+type TypedTransaction interface {
+	TransactionType() TxType
+}
+
+// DecodeFunc восстанавливает транзакцию конкретного типа из её сериализованного тела
+type DecodeFunc func(r io.Reader) (Transaction, error)
+
+var decoders = map[TxType]DecodeFunc{
+	TxTypeBank: func(r io.Reader) (Transaction, error) {
+		return DeserializeTransactionFromReader(r)
+	},
+	TxTypeCoinbase: func(r io.Reader) (Transaction, error) {
+		return DeserializeCoinbaseFromReader(r)
+	},
+}
+
+func (bt *BankTransaction) TransactionType() TxType {
+	return TxTypeBank
+}
+
+func (bt *BankTransaction) TransactionGetSignature() []byte {
+	return bt.Signature
+}
+
+// TypeOf возвращает тег типа транзакции
+func TypeOf(tx Transaction) TxType {
+	if typed, ok := tx.(TypedTransaction); ok {
+		return typed.TransactionType()
+	}
+	return TxTypeBank
+}
+
+// EncodeTransaction сериализует транзакцию вместе с тегом типа
+func EncodeTransaction(tx Transaction) ([]byte, error) {
+	if tx == nil {
+		return nil, errors.New("transaction is nil")
+	}
+
+	body, err := tx.TransactionSerialize()
+	if err != nil {
+		return nil, err
+	}
+
+	data := make([]byte, 0, 1+len(body))
+	data = append(data, byte(TypeOf(tx)))
+	return append(data, body...), nil
+}
+
+// WriteTransaction пишет транзакцию с тегом типа в поток
+func WriteTransaction(w io.Writer, tx Transaction) error {
+	data, err := EncodeTransaction(tx)
+	if err != nil {
+		return err
+	}
+
+	if _, err := w.Write(data); err != nil {
+		return fmt.Errorf("failed to write transaction: %w", err)
+	}
+	return nil
+}
+
+// DecodeTransaction читает тег типа и восстанавливает транзакцию соответствующего типа
+func DecodeTransaction(r io.Reader) (Transaction, error) {
+	if r == nil {
+		return nil, errors.New("reader is nil")
+	}
+
+	var typ [1]byte
+	if _, err := io.ReadFull(r, typ[:]); err != nil {
+		return nil, fmt.Errorf("failed to read transaction type: %w", err)
+	}
+
+	return DecodeTransactionBody(TxType(typ[0]), r)
+}
+
+// DecodeTransactionBody восстанавливает транзакцию по уже известному тегу типа
+func DecodeTransactionBody(typ TxType, r io.Reader) (Transaction, error) {
+	decode, ok := decoders[typ]
+	if !ok {
+		return nil, fmt.Errorf("unknown transaction type: 0x%02x", uint8(typ))
+	}
+
+	return decode(r)
+}
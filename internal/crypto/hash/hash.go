@@ -3,8 +3,11 @@ package hash
 import (
 	"crypto/sha256"
 	"encoding/hex"
+	"encoding/json"
+	"fmt"
 
 	"github.com/Alex1997377/weave/internal/core/block/interfaces"
+	"github.com/Alex1997377/weave/internal/crypto/pow"
 )
 
 // Hash представляет собой 32-байтовый хеш
@@ -28,32 +31,18 @@ func (h Hash) Bytes() []byte {
 	return result
 }
 
-// IsValidForDifficulty проверяет, удовлетворяет ли хеш заданной сложности
+// IsValidForDifficulty проверяет, удовлетворяет ли хеш сложности в битах.
+// Эквивалентно MeetsTarget(pow.DifficultyToTarget(difficulty)).
 func (h Hash) IsValidForDifficulty(difficulty int) bool {
-	if difficulty < 0 || len(h) == 0 {
+	if difficulty < 0 {
 		return false
 	}
+	return h.MeetsTarget(pow.DifficultyToTarget(difficulty))
+}
 
-	fullBytes := difficulty / 8
-	if fullBytes > len(h) {
-		fullBytes = len(h)
-	}
-
-	for i := 0; i < fullBytes; i++ {
-		if h[i] != 0 {
-			return false
-		}
-	}
-
-	remainingBits := difficulty % 8
-	if remainingBits > 0 && fullBytes < len(h) {
-		mask := byte(0xFF << (8 - remainingBits))
-		if h[fullBytes]&mask != 0 {
-			return false
-		}
-	}
-
-	return true
+// MeetsTarget проверяет, что хеш как big-endian число не больше цели
+func (h Hash) MeetsTarget(target pow.Target) bool {
+	return target.Meets(h)
 }
 
 // HashPublicKey хеширует публичный ключ (возвращает первые 20 байт)
@@ -75,3 +64,31 @@ type HashCalculatorImpl struct{}
 func (HashCalculatorImpl) Hash(data []byte) interfaces.Hash {
 	return HashBytes(data)
 }
+
+// MarshalJSON кодирует хеш hex-строкой, nil - как null
+func (h Hash) MarshalJSON() ([]byte, error) {
+	if h == nil {
+		return []byte("null"), nil
+	}
+	return json.Marshal(hex.EncodeToString(h))
+}
+
+// UnmarshalJSON принимает null или hex-строку ровно из 32 байт
+func (h *Hash) UnmarshalJSON(data []byte) error {
+	if string(data) == "null" {
+		*h = nil
+		return nil
+	}
+
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return fmt.Errorf("hash must be a hex string: %w", err)
+	}
+
+	parsed, err := HashFromString(s)
+	if err != nil {
+		return err
+	}
+	*h = parsed
+	return nil
+}
@@ -0,0 +1,56 @@
+package hash
+
+import (
+	"crypto/sha256"
+	"encoding"
+	"fmt"
+	gohash "hash"
+
+	"github.com/Alex1997377/weave/internal/core/block/interfaces"
+)
+
+// midstateHasher хранит сериализованное состояние SHA-256 после префикса
+// и восстанавливает его перед каждым хешем
+type midstateHasher struct {
+	state    []byte
+	digest   gohash.Hash
+	restorer encoding.BinaryUnmarshaler
+	sum      [sha256.Size]byte
+	out      Hash
+}
+
+// Midstate считает состояние SHA-256 после prefix. Полученный хешер
+// даёт тот же результат, что Hash(prefix||suffix)
+func (HashCalculatorImpl) Midstate(prefix []byte) (interfaces.MidstateHasher, error) {
+	digest := sha256.New()
+	digest.Write(prefix)
+
+	marshaler, ok := digest.(encoding.BinaryMarshaler)
+	if !ok {
+		return nil, fmt.Errorf("sha256 digest doesn`t support state export")
+	}
+	state, err := marshaler.MarshalBinary()
+	if err != nil {
+		return nil, fmt.Errorf("failed to export sha256 state: %w", err)
+	}
+
+	restorer, ok := digest.(encoding.BinaryUnmarshaler)
+	if !ok {
+		return nil, fmt.Errorf("sha256 digest doesn`t support state import")
+	}
+
+	m := &midstateHasher{state: state, digest: digest, restorer: restorer}
+	m.out = m.sum[:]
+	return m, nil
+}
+
+// HashSuffix возвращает хеш prefix||suffix. Результат указывает на
+// внутренний буфер и действителен до следующего вызова
+func (m *midstateHasher) HashSuffix(suffix []byte) interfaces.Hash {
+	// состояние получено из того же типа digest, ошибка невозможна
+	_ = m.restorer.UnmarshalBinary(m.state)
+	m.digest.Write(suffix)
+	m.digest.Sum(m.sum[:0])
+	// указатель на поле не требует аллокации при приведении к интерфейсу
+	return &m.out
+}
@@ -0,0 +1,145 @@
+package pow
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"math/big"
+)
+
+// TargetSize - размер цели и хеша в байтах
+const TargetSize = 32
+
+// Target - 256-битная цель proof of work. Хеш, прочитанный как big-endian
+// число, удовлетворяет цели, если он не больше её. Это единственное
+// определение proof of work: сложность в битах (заголовки версии 1) и
+// компактные bits (версия 2) сводятся к Target.
+type Target [TargetSize]byte
+
+var (
+	ErrNegativeTarget = errors.New("compact target is negative")
+	ErrTargetOverflow = errors.New("compact target overflows 256 bits")
+	ErrZeroTarget     = errors.New("target is zero")
+)
+
+// MaxTarget - самая лёгкая цель: подходит любой хеш
+var MaxTarget = func() Target {
+	var t Target
+	for i := range t {
+		t[i] = 0xFF
+	}
+	return t
+}()
+
+// DifficultyToTarget переводит сложность в битах (число ведущих нулевых бит)
+// в цель 2^(256-difficulty) - 1
+func DifficultyToTarget(difficulty int) Target {
+	if difficulty <= 0 {
+		return MaxTarget
+	}
+	if difficulty >= TargetSize*8 {
+		return Target{}
+	}
+
+	v := new(big.Int).Lsh(big.NewInt(1), uint(TargetSize*8-difficulty))
+	v.Sub(v, big.NewInt(1))
+
+	t, _ := TargetFromBig(v)
+	return t
+}
+
+// TargetFromBig создаёт цель из неотрицательного числа не длиннее 256 бит
+func TargetFromBig(v *big.Int) (Target, error) {
+	var t Target
+	if v == nil || v.Sign() < 0 {
+		return t, errors.New("target must be non-negative")
+	}
+	if v.BitLen() > TargetSize*8 {
+		return t, ErrTargetOverflow
+	}
+	v.FillBytes(t[:])
+	return t, nil
+}
+
+// Big возвращает цель как число
+func (t Target) Big() *big.Int {
+	return new(big.Int).SetBytes(t[:])
+}
+
+// IsZero сообщает, что цель недостижима
+func (t Target) IsZero() bool {
+	return t == Target{}
+}
+
+// Meets проверяет, что хеш не больше цели
+func (t Target) Meets(hash []byte) bool {
+	if len(hash) != TargetSize {
+		return false
+	}
+	return bytes.Compare(hash, t[:]) <= 0
+}
+
+// Cmp сравнивает цели: -1, если t труднее other, 0 при равенстве, +1, если легче
+func (t Target) Cmp(other Target) int {
+	return bytes.Compare(t[:], other[:])
+}
+
+// Work возвращает ожидаемое число хешей для нахождения блока: 2^256 / (target + 1)
+func (t Target) Work() *big.Int {
+	denominator := new(big.Int).Add(t.Big(), big.NewInt(1))
+	return new(big.Int).Div(new(big.Int).Lsh(big.NewInt(1), TargetSize*8), denominator)
+}
+
+func (t Target) String() string {
+	return fmt.Sprintf("%x", t[:])
+}
+
+// CompactToTarget раскрывает компактную запись bits: старший байт - длина числа
+// в байтах, младшие три - мантисса (старший бит мантиссы - знак и должен быть 0)
+func CompactToTarget(bits uint32) (Target, error) {
+	exponent := uint(bits >> 24)
+	mantissa := bits & 0x007FFFFF
+
+	if bits&0x00800000 != 0 && mantissa != 0 {
+		return Target{}, ErrNegativeTarget
+	}
+
+	v := new(big.Int)
+	if exponent <= 3 {
+		v.SetUint64(uint64(mantissa >> (8 * (3 - exponent))))
+	} else {
+		v.SetUint64(uint64(mantissa))
+		v.Lsh(v, 8*(exponent-3))
+	}
+
+	t, err := TargetFromBig(v)
+	if err != nil {
+		return Target{}, fmt.Errorf("bits %08x: %w", bits, err)
+	}
+	if t.IsZero() {
+		return Target{}, fmt.Errorf("bits %08x: %w", bits, ErrZeroTarget)
+	}
+	return t, nil
+}
+
+// Compact возвращает компактную запись цели. Младшие байты отбрасываются,
+// поэтому CompactToTarget(t.Compact()) не легче t.
+func (t Target) Compact() uint32 {
+	v := t.Big()
+	size := uint((v.BitLen() + 7) / 8)
+
+	var mantissa uint32
+	if size <= 3 {
+		mantissa = uint32(v.Uint64() << (8 * (3 - size)))
+	} else {
+		mantissa = uint32(new(big.Int).Rsh(v, 8*(size-3)).Uint64())
+	}
+
+	// старший бит мантиссы зарезервирован под знак
+	if mantissa&0x00800000 != 0 {
+		mantissa >>= 8
+		size++
+	}
+
+	return uint32(size)<<24 | mantissa
+}
@@ -0,0 +1,96 @@
+package tests
+
+import (
+	"bytes"
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/Alex1997377/weave/internal/crypto/hash"
+	"github.com/Alex1997377/weave/internal/crypto/pow"
+)
+
+func TestCompactToTarget(t *testing.T) {
+	tests := []struct {
+		bits uint32
+		want string // hex без ведущих нулей
+	}{
+		{bits: 0x1d00ffff, want: "ffff0000000000000000000000000000000000000000000000000000"},
+		{bits: 0x1b0404cb, want: "404cb000000000000000000000000000000000000000000000000"},
+		{bits: 0x03123456, want: "123456"},
+		{bits: 0x02123456, want: "1234"},
+		{bits: 0x01123456, want: "12"},
+	}
+
+	for _, tt := range tests {
+		target, err := pow.CompactToTarget(tt.bits)
+		if err != nil {
+			t.Fatalf("CompactToTarget(%08x) error: %v", tt.bits, err)
+		}
+		if got := target.Big().Text(16); got != tt.want {
+			t.Errorf("CompactToTarget(%08x) = %s, want %s", tt.bits, got, tt.want)
+		}
+		if got := target.Compact(); got != tt.bits && tt.bits >= 0x03000000 {
+			t.Errorf("Compact() = %08x, want %08x", got, tt.bits)
+		}
+	}
+}
+
+func TestCompactToTarget_Invalid(t *testing.T) {
+	if _, err := pow.CompactToTarget(0x04923456); !errors.Is(err, pow.ErrNegativeTarget) {
+		t.Errorf("expected ErrNegativeTarget, got %v", err)
+	}
+	if _, err := pow.CompactToTarget(0x2200ffff); !errors.Is(err, pow.ErrTargetOverflow) {
+		t.Errorf("expected ErrTargetOverflow, got %v", err)
+	}
+	if _, err := pow.CompactToTarget(0x1d000000); !errors.Is(err, pow.ErrZeroTarget) {
+		t.Errorf("expected ErrZeroTarget, got %v", err)
+	}
+}
+
+func TestCompact_RoundsDown(t *testing.T) {
+	for difficulty := 0; difficulty < 256; difficulty++ {
+		target := pow.DifficultyToTarget(difficulty)
+		if target.IsZero() {
+			continue
+		}
+
+		rounded, err := pow.CompactToTarget(target.Compact())
+		if err != nil {
+			t.Fatalf("difficulty %d: %v", difficulty, err)
+		}
+		if rounded.Cmp(target) > 0 {
+			t.Errorf("difficulty %d: compact target is easier than original", difficulty)
+		}
+	}
+}
+
+func TestDifficultyToTarget_MatchesLeadingZeroBits(t *testing.T) {
+	withZeros := hash.Hash(append([]byte{0x00, 0x0F}, bytes.Repeat([]byte{0xFF}, 30)...)) // 12 нулевых бит
+
+	for difficulty := 0; difficulty <= 16; difficulty++ {
+		want := difficulty <= 12
+		if got := withZeros.MeetsTarget(pow.DifficultyToTarget(difficulty)); got != want {
+			t.Errorf("difficulty %d: MeetsTarget = %v, want %v", difficulty, got, want)
+		}
+		if got := withZeros.IsValidForDifficulty(difficulty); got != want {
+			t.Errorf("difficulty %d: IsValidForDifficulty = %v, want %v", difficulty, got, want)
+		}
+	}
+}
+
+func TestTarget_Work(t *testing.T) {
+	// цель 2^(256-d)-1 требует в среднем 2^d хешей
+	for _, difficulty := range []int{0, 1, 8, 32} {
+		want := new(big.Int).Lsh(big.NewInt(1), uint(difficulty))
+		if got := pow.DifficultyToTarget(difficulty).Work(); got.Cmp(want) != 0 {
+			t.Errorf("difficulty %d: work %s, want %s", difficulty, got, want)
+		}
+	}
+
+	easy, _ := pow.CompactToTarget(0x2000ffff)
+	hard, _ := pow.CompactToTarget(0x1f00ffff)
+	if easy.Work().Cmp(hard.Work()) >= 0 {
+		t.Error("harder target must prove more work")
+	}
+}
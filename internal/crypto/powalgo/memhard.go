@@ -0,0 +1,62 @@
+package powalgo
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"sync"
+
+	"github.com/Alex1997377/weave/internal/core/block/interfaces"
+	"github.com/Alex1997377/weave/internal/crypto/hash"
+)
+
+// DefaultMemoryHardBlocks - число 32-байтовых ячеек памяти (32 КиБ) по умолчанию
+const DefaultMemoryHardBlocks = 1 << 10
+
+// MemoryHard - упрощённый ROMix (как в scrypt) поверх SHA-256: сначала
+// последовательно заполняется таблица из Blocks хешей, затем столько же
+// раз читается ячейка, номер которой зависит от текущего состояния.
+// Без всей таблицы в памяти хеш приходится пересчитывать заново, что
+// уравнивает перебор на CPU и специализированном железе.
+type MemoryHard struct {
+	// Blocks - размер таблицы в 32-байтовых ячейках; 0 - DefaultMemoryHardBlocks
+	Blocks int
+}
+
+func (MemoryHard) Name() string { return NameMemoryHard }
+
+var memoryHardPool = sync.Pool{
+	New: func() interface{} {
+		buf := make([][sha256.Size]byte, 0, DefaultMemoryHardBlocks)
+		return &buf
+	},
+}
+
+func (m MemoryHard) Hash(data []byte) interfaces.Hash {
+	n := m.Blocks
+	if n <= 0 {
+		n = DefaultMemoryHardBlocks
+	}
+
+	tablePtr := memoryHardPool.Get().(*[][sha256.Size]byte)
+	defer memoryHardPool.Put(tablePtr)
+	if cap(*tablePtr) < n {
+		*tablePtr = make([][sha256.Size]byte, n)
+	}
+	table := (*tablePtr)[:n]
+
+	x := sha256.Sum256(data)
+	for i := range table {
+		table[i] = x
+		x = sha256.Sum256(x[:])
+	}
+
+	var mix [2 * sha256.Size]byte
+	for i := 0; i < n; i++ {
+		j := binary.LittleEndian.Uint64(x[:8]) % uint64(n)
+		copy(mix[:sha256.Size], x[:])
+		copy(mix[sha256.Size:], table[j][:])
+		x = sha256.Sum256(mix[:])
+	}
+
+	return hash.Hash(append([]byte(nil), x[:]...))
+}
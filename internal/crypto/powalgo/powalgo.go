@@ -0,0 +1,27 @@
+package powalgo
+
+import (
+	"errors"
+
+	"github.com/Alex1997377/weave/internal/core/block/interfaces"
+)
+
+var (
+	ErrUnknownAlgorithm   = errors.New("unknown proof-of-work algorithm")
+	ErrDuplicateAlgorithm = errors.New("proof-of-work algorithm already registered")
+)
+
+// Algorithm - функция доказательства работы: хеш канонического заголовка,
+// который сравнивается с целью блока. Идентификатор блока (Block.Hash)
+// от выбора алгоритма не зависит и всегда считается SHA-256.
+type Algorithm interface {
+	interfaces.HashCalculator
+	// Name - имя алгоритма в реестре и в consensus.Params
+	Name() string
+}
+
+// IsIdentity сообщает, что хеш доказательства работы совпадает с
+// идентификатором блока и его не нужно считать отдельно
+func IsIdentity(a Algorithm) bool {
+	return a == nil || a.Name() == NameSHA256
+}
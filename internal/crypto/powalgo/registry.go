@@ -0,0 +1,83 @@
+package powalgo
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+)
+
+// Имена встроенных алгоритмов
+const (
+	NameSHA256       = "sha256"
+	NameDoubleSHA256 = "sha256d"
+	NameMemoryHard   = "memhard"
+)
+
+var (
+	registryMu sync.RWMutex
+	byName     = map[string]Algorithm{}
+)
+
+func init() {
+	MustRegister(SHA256{})
+	MustRegister(DoubleSHA256{})
+	MustRegister(MemoryHard{})
+}
+
+// Register добавляет алгоритм в реестр. Имя должно быть уникальным.
+func Register(a Algorithm) error {
+	if a == nil {
+		return fmt.Errorf("algorithm is nil")
+	}
+
+	registryMu.Lock()
+	defer registryMu.Unlock()
+
+	if _, ok := byName[a.Name()]; ok {
+		return fmt.Errorf("%w: %q", ErrDuplicateAlgorithm, a.Name())
+	}
+
+	byName[a.Name()] = a
+	return nil
+}
+
+// MustRegister - Register, паникующий при ошибке
+func MustRegister(a Algorithm) {
+	if err := Register(a); err != nil {
+		panic(err)
+	}
+}
+
+// Get возвращает алгоритм по имени; пустое имя означает Default
+func Get(name string) (Algorithm, error) {
+	if name == "" {
+		return Default(), nil
+	}
+
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+
+	a, ok := byName[name]
+	if !ok {
+		return nil, fmt.Errorf("%w: %q", ErrUnknownAlgorithm, name)
+	}
+	return a, nil
+}
+
+// Names возвращает отсортированные имена зарегистрированных алгоритмов
+func Names() []string {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+
+	names := make([]string, 0, len(byName))
+	for name := range byName {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Default - алгоритм сетей, в параметрах которых он не указан
+func Default() Algorithm {
+	return SHA256{}
+}
@@ -0,0 +1,53 @@
+package powalgo
+
+import (
+	"crypto/sha256"
+
+	"github.com/Alex1997377/weave/internal/core/block/interfaces"
+	"github.com/Alex1997377/weave/internal/crypto/hash"
+)
+
+// SHA256 - одинарный SHA-256 заголовка, совпадает с идентификатором блока
+type SHA256 struct {
+	hash.HashCalculatorImpl
+}
+
+func (SHA256) Name() string { return NameSHA256 }
+
+// DoubleSHA256 - SHA-256 от SHA-256 заголовка
+type DoubleSHA256 struct{}
+
+func (DoubleSHA256) Name() string { return NameDoubleSHA256 }
+
+func (DoubleSHA256) Hash(data []byte) interfaces.Hash {
+	first := sha256.Sum256(data)
+	return hash.HashBytes(first[:])
+}
+
+// Midstate кеширует внутренний SHA-256 префикса; внешний хеш занимает
+// один блок сжатия и считается для каждого nonce
+func (DoubleSHA256) Midstate(prefix []byte) (interfaces.MidstateHasher, error) {
+	inner, err := hash.HashCalculatorImpl{}.Midstate(prefix)
+	if err != nil {
+		return nil, err
+	}
+	return &doubleMidstate{inner: inner}, nil
+}
+
+type doubleMidstate struct {
+	inner interfaces.MidstateHasher
+	out   hash.Hash
+	sum   [sha256.Size]byte
+}
+
+func (d *doubleMidstate) HashSuffix(suffix []byte) interfaces.Hash {
+	// внутренний хешер отдаёт указатель на свой буфер - читаем без копирования
+	first := d.inner.HashSuffix(suffix)
+	if h, ok := first.(*hash.Hash); ok {
+		d.sum = sha256.Sum256(*h)
+	} else {
+		d.sum = sha256.Sum256(first.Bytes())
+	}
+	d.out = d.sum[:]
+	return &d.out
+}
@@ -0,0 +1,102 @@
+package tests
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"errors"
+	"testing"
+
+	"github.com/Alex1997377/weave/internal/core/block/interfaces"
+	"github.com/Alex1997377/weave/internal/crypto/powalgo"
+)
+
+func TestRegistry_Builtins(t *testing.T) {
+	want := []string{powalgo.NameMemoryHard, powalgo.NameSHA256, powalgo.NameDoubleSHA256}
+	names := powalgo.Names()
+	if len(names) < len(want) {
+		t.Fatalf("got names %v, want at least %v", names, want)
+	}
+	for i, name := range want {
+		if names[i] != name {
+			t.Errorf("names[%d] = %q, want %q", i, names[i], name)
+		}
+
+		a, err := powalgo.Get(name)
+		if err != nil {
+			t.Fatalf("Get(%q) error: %v", name, err)
+		}
+		if a.Name() != name {
+			t.Errorf("Get(%q) returned %q", name, a.Name())
+		}
+	}
+
+	def, err := powalgo.Get("")
+	if err != nil || def.Name() != powalgo.NameSHA256 {
+		t.Errorf("empty name must resolve to sha256, got %v, %v", def, err)
+	}
+}
+
+func TestRegistry_Errors(t *testing.T) {
+	if _, err := powalgo.Get("scrypt"); !errors.Is(err, powalgo.ErrUnknownAlgorithm) {
+		t.Errorf("expected ErrUnknownAlgorithm, got %v", err)
+	}
+	if err := powalgo.Register(powalgo.SHA256{}); !errors.Is(err, powalgo.ErrDuplicateAlgorithm) {
+		t.Errorf("expected ErrDuplicateAlgorithm, got %v", err)
+	}
+	if err := powalgo.Register(nil); err == nil {
+		t.Error("expected error for nil algorithm")
+	}
+}
+
+func TestAlgorithms_Hash(t *testing.T) {
+	data := bytes.Repeat([]byte{0x5a}, 96)
+
+	single := sha256.Sum256(data)
+	double := sha256.Sum256(single[:])
+
+	if got := (powalgo.SHA256{}).Hash(data).Bytes(); !bytes.Equal(got, single[:]) {
+		t.Errorf("sha256 = %x, want %x", got, single)
+	}
+	if got := (powalgo.DoubleSHA256{}).Hash(data).Bytes(); !bytes.Equal(got, double[:]) {
+		t.Errorf("sha256d = %x, want %x", got, double)
+	}
+
+	memhard := powalgo.MemoryHard{}.Hash(data).Bytes()
+	if len(memhard) != sha256.Size {
+		t.Fatalf("memhard hash has %d bytes", len(memhard))
+	}
+	if !bytes.Equal(memhard, powalgo.MemoryHard{}.Hash(data).Bytes()) {
+		t.Error("memhard is not deterministic")
+	}
+	if bytes.Equal(memhard, single[:]) || bytes.Equal(memhard, double[:]) {
+		t.Error("memhard must differ from sha256 variants")
+	}
+	if bytes.Equal(memhard, powalgo.MemoryHard{Blocks: 64}.Hash(data).Bytes()) {
+		t.Error("memory size must change the hash")
+	}
+}
+
+func TestAlgorithms_Midstate(t *testing.T) {
+	data := bytes.Repeat([]byte{0xa5}, 96)
+
+	for _, a := range []powalgo.Algorithm{powalgo.SHA256{}, powalgo.DoubleSHA256{}} {
+		t.Run(a.Name(), func(t *testing.T) {
+			mc, ok := a.(interfaces.MidstateHashCalculator)
+			if !ok {
+				t.Fatal("algorithm must support midstate")
+			}
+			ms, err := mc.Midstate(data[:88])
+			if err != nil {
+				t.Fatalf("Midstate error: %v", err)
+			}
+
+			for i := 0; i < 3; i++ {
+				data[90] = byte(i)
+				want := a.Hash(data).Bytes()
+				if got := ms.HashSuffix(data[88:]).Bytes(); !bytes.Equal(got, want) {
+					t.Errorf("suffix %d: midstate %x, want %x", i, got, want)
+				}
+			}
+		})
+	}
+}
@@ -1,12 +1,16 @@
 package serialize
 
 import (
-	"bytes"
-	"encoding/binary"
 	"errors"
 	"fmt"
+
+	"github.com/Alex1997377/weave/internal/core/header"
 )
 
+// SerializeHeader проверяет поля и возвращает каноническое представление
+// заголовка версии header.Version1 (сложность числом ведущих нулей, без Bits).
+// Вспомогательная функция старого формата: заголовки текущей версии
+// сериализуются header.Header.Serialize.
 func SerializeHeader(index int, timestamp int64, prevHash, merkleRoot []byte, nonce int, difficulty int) ([]byte, error) {
 	if index < 0 {
 		return nil, &HeaderValidationError{
@@ -68,75 +72,25 @@ func SerializeHeader(index int, timestamp int64, prevHash, merkleRoot []byte, no
 		}
 	}
 
-	buf := new(bytes.Buffer)
-
-	if err := binary.Write(buf, binary.LittleEndian, int64(index)); err != nil {
-		return nil, &SerializeHeaderError{
-			Field: "index",
-			Value: index,
-			Err:   fmt.Errorf("binary write failed: %w", err),
-		}
-	}
-
-	if err := binary.Write(buf, binary.LittleEndian, timestamp); err != nil {
-		return nil, &SerializeHeaderError{
-			Field: "timestamp",
-			Value: timestamp,
-			Err:   fmt.Errorf("binary write failed: %w", err),
-		}
+	h := header.Header{
+		Version:      header.Version1,
+		Index:        index,
+		Timestamp:    timestamp,
+		PreviousHash: prevHash,
+		MerkleRoot:   merkleRoot,
+		Nonce:        uint64(nonce),
+		Difficulty:   difficulty,
 	}
 
-	n, err := buf.Write(prevHash)
+	result, err := h.Serialize()
 	if err != nil {
 		return nil, &SerializeHeaderError{
-			Field: "prevHash",
-			Value: fmt.Sprintf("length: %d", len(prevHash)),
-			Err:   fmt.Errorf("buffer write failed: %w", err),
-		}
-	}
-	if n != len(prevHash) {
-		return nil, &SerializeHeaderError{
-			Field: "prevHash",
-			Value: fmt.Sprintf("written: %d, expected: %d", n, len(prevHash)),
-			Err:   errors.New("incomplete write to buffer"),
-		}
-	}
-
-	if merkleRoot != nil {
-		n, err = buf.Write(merkleRoot)
-		if err != nil {
-			return nil, &SerializeHeaderError{
-				Field: "merkleRoot",
-				Value: fmt.Sprintf("length: %d", len(merkleRoot)),
-				Err:   fmt.Errorf("buffer write failed: %w", err),
-			}
-		}
-		if n != len(merkleRoot) {
-			return nil, &SerializeHeaderError{
-				Field: "merkleRoot",
-				Value: fmt.Sprintf("written: %d, expected: %d", n, len(merkleRoot)),
-				Err:   errors.New("incomplete write to buffer"),
-			}
-		}
-	}
-
-	if err := binary.Write(buf, binary.LittleEndian, int64(nonce)); err != nil {
-		return nil, &SerializeHeaderError{
-			Field: "nonce",
-			Value: nonce,
-			Err:   fmt.Errorf("binary write failed: %w", err),
-		}
-	}
-
-	if err := binary.Write(buf, binary.LittleEndian, int64(difficulty)); err != nil {
-		return nil, &SerializeHeaderError{
-			Field: "difficulty",
-			Value: difficulty,
-			Err:   fmt.Errorf("binary write failed: %w", err),
+			Field: "header",
+			Value: nil,
+			Err:   fmt.Errorf("canonical encoding failed: %w", err),
 		}
 	}
 
-	result := buf.Bytes()
 	if len(result) == 0 {
 		return nil, &SerializeHeaderError{
 			Field: "result",
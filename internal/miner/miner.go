@@ -0,0 +1,354 @@
+package miner
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/Alex1997377/weave/internal/clock"
+	"github.com/Alex1997377/weave/internal/core/block"
+	"github.com/Alex1997377/weave/internal/core/consensus"
+	"github.com/Alex1997377/weave/internal/core/transaction"
+)
+
+// Значения Config по умолчанию
+const (
+	DefaultTipPollInterval = 500 * time.Millisecond
+	DefaultRetryInterval   = time.Second
+)
+
+var (
+	ErrAlreadyRunning = errors.New("miner is already running")
+	ErrNotRunning     = errors.New("miner is not running")
+)
+
+// Chain - цепочка, на вершину которой майнит Miner
+type Chain interface {
+	Params() *consensus.Params
+	TipHash() []byte
+	BlockTemplate(ctx context.Context, recipient []byte, transactions []transaction.Transaction) (*block.Block, error)
+	SubmitBlock(ctx context.Context, b *block.Block) error
+}
+
+// TxSource - источник неподтверждённых транзакций (например, mempool.Mempool)
+type TxSource interface {
+	Pending(max int) []transaction.Transaction
+	Remove(txs []transaction.Transaction)
+}
+
+// Config - настройки майнера
+type Config struct {
+	// RewardAddress - получатель coinbase-награды
+	RewardAddress []byte
+	// NumWorkers - число воркеров перебора; 0 - по числу CPU
+	NumWorkers int
+	// MaxTransactions - сколько транзакций брать из TxSource в шаблон; 0 - без ограничения
+	MaxTransactions int
+	// TipPollInterval - как часто проверять смену вершины во время перебора
+	TipPollInterval time.Duration
+	// RetryInterval - пауза перед повтором после ошибки построения шаблона
+	RetryInterval time.Duration
+
+	// OnBlock вызывается после принятия цепочкой каждого добытого блока
+	OnBlock func(b *block.Block)
+	// OnProgress передаётся в block.MineConfig
+	OnProgress block.ProgressFunc
+	// Clock передаётся в block.MineConfig; время шаблона задают часы цепочки
+	Clock clock.Clock
+}
+
+// Stats - счётчики работы майнера
+type Stats struct {
+	State     State
+	Mined     int
+	Rejected  int
+	LastError error
+}
+
+// Miner в фоне строит шаблон на вершине цепочки, майнит его и отправляет
+// найденный блок в цепочку. Перебор прерывается, когда вершина меняется
+// (NotifyNewTip или опрос TipHash), при паузе и остановке.
+type Miner struct {
+	chain  Chain
+	txs    TxSource
+	config Config
+
+	mu        sync.Mutex
+	state     State
+	resume    chan struct{}
+	cancelJob context.CancelFunc
+	cancel    context.CancelFunc
+	done      chan struct{}
+	stats     Stats
+}
+
+// New создаёт остановленный майнер; txs может быть nil - тогда блоки
+// содержат только coinbase
+func New(chain Chain, txs TxSource, config Config) (*Miner, error) {
+	if chain == nil {
+		return nil, errors.New("chain cannot be nil")
+	}
+	if len(config.RewardAddress) == 0 {
+		return nil, errors.New("reward address cannot be empty")
+	}
+	if err := transaction.NewCoinbase(0, config.RewardAddress, 0).TransactionValidate(); err != nil {
+		return nil, fmt.Errorf("invalid reward address: %w", err)
+	}
+	if config.TipPollInterval <= 0 {
+		config.TipPollInterval = DefaultTipPollInterval
+	}
+	if config.RetryInterval <= 0 {
+		config.RetryInterval = DefaultRetryInterval
+	}
+	config.RewardAddress = append([]byte(nil), config.RewardAddress...)
+
+	return &Miner{chain: chain, txs: txs, config: config}, nil
+}
+
+// Start запускает фоновый цикл майнинга
+func (m *Miner) Start() error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.state != StateStopped {
+		return ErrAlreadyRunning
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	m.cancel = cancel
+	m.done = make(chan struct{})
+	m.state = StateRunning
+	m.stats.State = StateRunning
+
+	go m.loop(ctx, m.done)
+	return nil
+}
+
+// Pause прерывает текущий перебор и приостанавливает майнинг до Resume
+func (m *Miner) Pause() error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	switch m.state {
+	case StateStopped:
+		return ErrNotRunning
+	case StatePaused:
+		return nil
+	}
+
+	m.state = StatePaused
+	m.stats.State = StatePaused
+	m.resume = make(chan struct{})
+	if m.cancelJob != nil {
+		m.cancelJob()
+	}
+	return nil
+}
+
+// Resume продолжает майнинг после Pause
+func (m *Miner) Resume() error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	switch m.state {
+	case StateStopped:
+		return ErrNotRunning
+	case StateRunning:
+		return nil
+	}
+
+	m.state = StateRunning
+	m.stats.State = StateRunning
+	close(m.resume)
+	m.resume = nil
+	return nil
+}
+
+// Stop останавливает майнинг и ждёт завершения цикла. Повторный вызов безопасен.
+func (m *Miner) Stop() {
+	m.mu.Lock()
+	if m.state == StateStopped {
+		m.mu.Unlock()
+		return
+	}
+	m.cancel()
+	done := m.done
+	m.mu.Unlock()
+
+	<-done
+
+	m.mu.Lock()
+	m.state = StateStopped
+	m.stats.State = StateStopped
+	m.resume = nil
+	m.mu.Unlock()
+}
+
+// NotifyNewTip прерывает текущий перебор, чтобы следующий шаблон строился
+// на новой вершине. Не дожидается опроса TipHash.
+func (m *Miner) NotifyNewTip() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.cancelJob != nil {
+		m.cancelJob()
+	}
+}
+
+// Stats возвращает снимок счётчиков
+func (m *Miner) Stats() Stats {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return m.stats
+}
+
+func (m *Miner) loop(ctx context.Context, done chan struct{}) {
+	defer close(done)
+
+	for {
+		if !m.waitRunning(ctx) {
+			return
+		}
+
+		if err := m.mineOnce(ctx); err != nil {
+			m.setError(err)
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(m.config.RetryInterval):
+			}
+		}
+	}
+}
+
+// waitRunning блокируется на паузе; false - майнер остановлен
+func (m *Miner) waitRunning(ctx context.Context) bool {
+	for {
+		m.mu.Lock()
+		resume := m.resume
+		m.mu.Unlock()
+
+		if resume == nil {
+			return ctx.Err() == nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return false
+		case <-resume:
+		}
+	}
+}
+
+// mineOnce строит шаблон, майнит его до находки или смены вершины и
+// отправляет результат в цепочку
+func (m *Miner) mineOnce(ctx context.Context) error {
+	var pending []transaction.Transaction
+	if m.txs != nil {
+		pending = m.txs.Pending(m.config.MaxTransactions)
+	}
+
+	tpl, err := m.chain.BlockTemplate(ctx, m.config.RewardAddress, pending)
+	if err != nil {
+		return fmt.Errorf("failed to build block template: %w", err)
+	}
+
+	algo, err := m.chain.Params().Algorithm()
+	if err != nil {
+		return fmt.Errorf("invalid consensus params: %w", err)
+	}
+
+	jobCtx, cancelJob := context.WithCancel(ctx)
+	defer cancelJob()
+
+	m.mu.Lock()
+	paused := m.resume != nil
+	m.cancelJob = cancelJob
+	m.mu.Unlock()
+	defer m.clearJob()
+
+	// пауза могла начаться между waitRunning и регистрацией задания
+	if paused {
+		return nil
+	}
+
+	watchDone := make(chan struct{})
+	go m.watchTip(jobCtx, tpl.Header.PreviousHash, cancelJob, watchDone)
+
+	err = tpl.Mine(jobCtx, block.MineConfig{
+		NumWorkers: m.config.NumWorkers,
+		Algorithm:  algo,
+		OnProgress: m.config.OnProgress,
+		Clock:      m.config.Clock,
+	})
+	cancelJob()
+	<-watchDone
+
+	if err != nil {
+		// прерывание по новой вершине, паузе или остановке - не ошибка
+		if errors.Is(err, context.Canceled) {
+			return nil
+		}
+		return fmt.Errorf("failed to mine block: %w", err)
+	}
+
+	if err := m.chain.SubmitBlock(ctx, tpl); err != nil {
+		if ctx.Err() != nil {
+			return nil
+		}
+		m.mu.Lock()
+		m.stats.Rejected++
+		m.mu.Unlock()
+		return fmt.Errorf("block %d rejected: %w", tpl.Header.Index, err)
+	}
+
+	if m.txs != nil && len(tpl.Transaction) > 1 {
+		m.txs.Remove(tpl.Transaction[1:])
+	}
+
+	m.mu.Lock()
+	m.stats.Mined++
+	m.stats.LastError = nil
+	m.mu.Unlock()
+
+	if m.config.OnBlock != nil {
+		m.config.OnBlock(tpl)
+	}
+	return nil
+}
+
+// watchTip отменяет задание, когда вершина цепочки перестаёт совпадать с parent
+func (m *Miner) watchTip(ctx context.Context, parent []byte, cancel context.CancelFunc, done chan struct{}) {
+	defer close(done)
+
+	ticker := time.NewTicker(m.config.TipPollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if !bytes.Equal(m.chain.TipHash(), parent) {
+				cancel()
+				return
+			}
+		}
+	}
+}
+
+func (m *Miner) clearJob() {
+	m.mu.Lock()
+	m.cancelJob = nil
+	m.mu.Unlock()
+}
+
+func (m *Miner) setError(err error) {
+	m.mu.Lock()
+	m.stats.LastError = err
+	m.mu.Unlock()
+}
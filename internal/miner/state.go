@@ -0,0 +1,22 @@
+package miner
+
+// State - состояние майнера
+type State int
+
+const (
+	StateStopped State = iota
+	StateRunning
+	StatePaused
+)
+
+func (s State) String() string {
+	switch s {
+	case StateStopped:
+		return "stopped"
+	case StateRunning:
+		return "running"
+	case StatePaused:
+		return "paused"
+	}
+	return "unknown"
+}
@@ -0,0 +1,147 @@
+package tests
+
+import (
+	"bytes"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/Alex1997377/weave/internal/core/block"
+	"github.com/Alex1997377/weave/internal/core/mempool"
+	"github.com/Alex1997377/weave/internal/core/transaction"
+	"github.com/Alex1997377/weave/internal/miner"
+	"github.com/Alex1997377/weave/internal/tests/helpers"
+)
+
+var rewardAddress = bytes.Repeat([]byte{0x42}, 32)
+
+func TestMiner_MinesAndSubmitsBlocks(t *testing.T) {
+	bc := helpers.NewTestChain(t)
+	pool := mempool.New(0)
+	if err := pool.Add(helpers.BankTransaction(7)); err != nil {
+		t.Fatalf("mempool Add error: %v", err)
+	}
+
+	var mined atomic.Int32
+	m, err := miner.New(bc, pool, miner.Config{
+		RewardAddress: rewardAddress,
+		NumWorkers:    2,
+		OnBlock:       func(*block.Block) { mined.Add(1) },
+	})
+	if err != nil {
+		t.Fatalf("New error: %v", err)
+	}
+
+	if err := m.Start(); err != nil {
+		t.Fatalf("Start error: %v", err)
+	}
+	if err := m.Start(); err != miner.ErrAlreadyRunning {
+		t.Errorf("second Start: got %v, want ErrAlreadyRunning", err)
+	}
+
+	helpers.WaitFor(t, 5*time.Second, func() bool { return mined.Load() >= 3 })
+	m.Stop()
+	m.Stop()
+
+	stats := m.Stats()
+	if stats.State != miner.StateStopped || stats.Mined != int(mined.Load()) {
+		t.Errorf("unexpected stats: %+v", stats)
+	}
+	if pool.Len() != 0 {
+		t.Errorf("mined transaction is still in mempool")
+	}
+
+	if bc.Height() != stats.Mined {
+		t.Fatalf("chain height %d, mined %d", bc.Height(), stats.Mined)
+	}
+	if err := bc.IsValid(t.Context()); err != nil {
+		t.Fatalf("chain is invalid: %v", err)
+	}
+
+	first, err := bc.GetBlockByIndex(t.Context(), 1)
+	if err != nil {
+		t.Fatalf("GetBlockByIndex error: %v", err)
+	}
+	if !transaction.IsCoinbase(first.Transaction[0]) || len(first.Transaction) != 2 {
+		t.Errorf("first mined block must contain coinbase and the pending transaction")
+	}
+
+	var want float64
+	for h := 1; h <= bc.Height(); h++ {
+		want += bc.Params().BlockReward(h)
+	}
+	if balance, _ := bc.GetBalance(t.Context(), rewardAddress); balance != want {
+		t.Errorf("reward balance %v, want %v", balance, want)
+	}
+}
+
+func TestMiner_InvalidConfig(t *testing.T) {
+	bc := helpers.NewTestChain(t)
+
+	if _, err := miner.New(bc, nil, miner.Config{}); err == nil {
+		t.Error("expected error for empty reward address")
+	}
+	if _, err := miner.New(bc, nil, miner.Config{RewardAddress: []byte{1, 2, 3}}); err == nil {
+		t.Error("expected error for short reward address")
+	}
+	if _, err := miner.New(nil, nil, miner.Config{RewardAddress: rewardAddress}); err == nil {
+		t.Error("expected error for nil chain")
+	}
+}
+
+func TestMiner_RestartsOnNewTipAndPauses(t *testing.T) {
+	stub := helpers.NewStubChain(bytes.Repeat([]byte{0xAA}, 32), 200)
+	m, err := miner.New(stub, nil, miner.Config{
+		RewardAddress:   rewardAddress,
+		NumWorkers:      1,
+		TipPollInterval: 5 * time.Millisecond,
+	})
+	if err != nil {
+		t.Fatalf("New error: %v", err)
+	}
+	if err := m.Pause(); err != miner.ErrNotRunning {
+		t.Errorf("Pause before Start: got %v, want ErrNotRunning", err)
+	}
+
+	if err := m.Start(); err != nil {
+		t.Fatalf("Start error: %v", err)
+	}
+	defer m.Stop()
+
+	helpers.WaitFor(t, time.Second, func() bool { return stub.Templates() == 1 })
+
+	// смена вершины обнаруживается опросом TipHash
+	stub.SetTip(bytes.Repeat([]byte{0xBB}, 32))
+	helpers.WaitFor(t, time.Second, func() bool { return stub.Templates() == 2 })
+
+	// явное уведомление прерывает перебор сразу
+	m.NotifyNewTip()
+	helpers.WaitFor(t, time.Second, func() bool { return stub.Templates() == 3 })
+
+	if err := m.Pause(); err != nil {
+		t.Fatalf("Pause error: %v", err)
+	}
+	time.Sleep(50 * time.Millisecond)
+	if got := stub.Templates(); got != 3 {
+		t.Errorf("paused miner built %d templates, want 3", got)
+	}
+	if m.Stats().State != miner.StatePaused {
+		t.Errorf("got state %v, want paused", m.Stats().State)
+	}
+
+	if err := m.Resume(); err != nil {
+		t.Fatalf("Resume error: %v", err)
+	}
+	helpers.WaitFor(t, time.Second, func() bool { return stub.Templates() == 4 })
+
+	stopped := make(chan struct{})
+	go func() {
+		m.Stop()
+		close(stopped)
+	}()
+	select {
+	case <-stopped:
+	case <-time.After(time.Second):
+		t.Fatal("Stop didn`t interrupt mining")
+	}
+}
@@ -0,0 +1,94 @@
+package pool
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"sort"
+)
+
+// Scheme - схема деления награды между майнерами
+type Scheme int
+
+const (
+	// SchemePPLNS делит награду по последним WindowSize долям пула,
+	// независимо от того, к какому блоку они относились
+	SchemePPLNS Scheme = iota
+	// SchemeProportional делит награду по долям с предыдущего найденного блока
+	SchemeProportional
+)
+
+func (s Scheme) String() string {
+	switch s {
+	case SchemePPLNS:
+		return "pplns"
+	case SchemeProportional:
+		return "proportional"
+	default:
+		return "unknown"
+	}
+}
+
+// Credit - начисление майнеру за один блок
+type Credit struct {
+	Miner  []byte
+	Weight float64
+	Amount float64
+}
+
+// Round - раздел награды одного блока. Сумма Credits и Fee равна Reward.
+type Round struct {
+	Height int
+	Reward float64
+	Fee    float64
+	// Weight - суммарный вес учтённых долей
+	Weight  float64
+	Credits []Credit
+}
+
+// splitReward делит reward за вычетом комиссии пропорционально весу долей.
+// Начисления упорядочены по адресу, остаток округления уходит в комиссию.
+func splitReward(height int, reward, fee float64, shares []share) Round {
+	round := Round{Height: height, Reward: reward}
+
+	weights := make(map[string]float64)
+	for _, s := range shares {
+		weights[s.miner] += s.weight
+		round.Weight += s.weight
+	}
+	if round.Weight == 0 {
+		round.Fee = reward
+		return round
+	}
+
+	miners := make([]string, 0, len(weights))
+	for m := range weights {
+		miners = append(miners, m)
+	}
+	sort.Strings(miners)
+
+	distributable := reward * (1 - fee)
+	var paid float64
+	for _, m := range miners {
+		amount := distributable * weights[m] / round.Weight
+		round.Credits = append(round.Credits, Credit{
+			Miner:  []byte(m),
+			Weight: weights[m],
+			Amount: amount,
+		})
+		paid += amount
+	}
+	round.Fee = reward - paid
+	return round
+}
+
+// payoutID - идентификатор выплаты майнеру за блок height
+func payoutID(height int, minerAddr []byte) []byte {
+	var buf [8]byte
+	binary.LittleEndian.PutUint64(buf[:], uint64(height))
+
+	h := sha256.New()
+	h.Write([]byte("pool-payout"))
+	h.Write(buf[:])
+	h.Write(minerAddr)
+	return h.Sum(nil)
+}
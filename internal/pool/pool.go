@@ -0,0 +1,267 @@
+package pool
+
+import (
+	"bytes"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+	"sync"
+
+	"github.com/Alex1997377/weave/internal/core/block"
+	"github.com/Alex1997377/weave/internal/core/transaction"
+	"github.com/Alex1997377/weave/internal/miner"
+	"github.com/Alex1997377/weave/internal/stratum"
+)
+
+// AddressSize - длина адреса майнера и пула
+const AddressSize = 32
+
+// DefaultWindowSize - окно PPLNS по умолчанию, в долях
+const DefaultWindowSize = 1000
+
+var (
+	ErrInvalidWorker = errors.New("invalid worker name")
+	ErrInvalidShare  = errors.New("invalid share")
+)
+
+// Config - настройки пула
+type Config struct {
+	// Address - адрес пула: на него приходит coinbase, с него идут выплаты.
+	// Его же нужно передать серверу как RewardAddress.
+	Address []byte
+	Scheme  Scheme
+	// WindowSize - число последних долей, по которым делится награда в PPLNS
+	WindowSize int
+	// Fee - доля награды, остающаяся пулу, от 0 до 1
+	Fee float64
+	// MinPayout - минимальная сумма выплаты; меньшие начисления копятся
+	MinPayout float64
+}
+
+// Pool ведёт учёт долей майнеров, делит награду найденных блоков и
+// выдаёт выплаты транзакциями для следующего блока. Реализует
+// miner.TxSource: выплаты идут раньше транзакций вложенного источника.
+// Безопасен для конкурентного использования.
+type Pool struct {
+	config Config
+	inner  miner.TxSource
+
+	mu sync.Mutex
+	// window - доли PPLNS-окна или текущего раунда пропорциональной схемы
+	window []share
+	// owed - начисленные, но ещё не выплаченные суммы по адресам
+	owed    map[string]float64
+	payouts []*transaction.BankTransaction
+	rounds  []Round
+}
+
+// share - учтённая доля майнера
+type share struct {
+	miner  string
+	weight float64
+}
+
+// New создаёт пул; inner может быть nil
+func New(config Config, inner miner.TxSource) (*Pool, error) {
+	if len(config.Address) != AddressSize {
+		return nil, fmt.Errorf("invalid pool address length: expected %d, got %d", AddressSize, len(config.Address))
+	}
+	if config.Scheme != SchemePPLNS && config.Scheme != SchemeProportional {
+		return nil, fmt.Errorf("unknown payout scheme: %d", config.Scheme)
+	}
+	if config.Fee < 0 || config.Fee > 1 || math.IsNaN(config.Fee) {
+		return nil, fmt.Errorf("invalid pool fee: %v", config.Fee)
+	}
+	if config.MinPayout < 0 || math.IsNaN(config.MinPayout) {
+		return nil, fmt.Errorf("invalid minimum payout: %v", config.MinPayout)
+	}
+	if config.WindowSize <= 0 {
+		config.WindowSize = DefaultWindowSize
+	}
+	config.Address = append([]byte(nil), config.Address...)
+
+	return &Pool{
+		config: config,
+		inner:  inner,
+		owed:   make(map[string]float64),
+	}, nil
+}
+
+// ParseWorker извлекает адрес майнера из имени воркера вида
+// "<hex-адрес>" или "<hex-адрес>.<имя рига>"
+func ParseWorker(worker string) ([]byte, error) {
+	addrHex, _, _ := strings.Cut(worker, ".")
+	addr, err := hex.DecodeString(addrHex)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %q: %v", ErrInvalidWorker, worker, err)
+	}
+	if len(addr) != AddressSize {
+		return nil, fmt.Errorf("%w: %q: address must be %d bytes", ErrInvalidWorker, worker, AddressSize)
+	}
+	return addr, nil
+}
+
+// Authorize проверяет имя воркера; подходит для stratum.ServerConfig.Authorize
+func (p *Pool) Authorize(worker string) error {
+	_, err := ParseWorker(worker)
+	return err
+}
+
+// AddShare учитывает долю майнера сложности difficulty. Вес доли
+// пропорционален её работе, поэтому доли разной сложности сравнимы.
+func (p *Pool) AddShare(minerAddr []byte, difficulty int) error {
+	if len(minerAddr) != AddressSize {
+		return fmt.Errorf("%w: miner address must be %d bytes", ErrInvalidShare, AddressSize)
+	}
+	if difficulty < 0 {
+		return fmt.Errorf("%w: negative difficulty %d", ErrInvalidShare, difficulty)
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.window = append(p.window, share{miner: string(minerAddr), weight: math.Ldexp(1, difficulty)})
+	if p.config.Scheme == SchemePPLNS && len(p.window) > p.config.WindowSize {
+		n := copy(p.window, p.window[len(p.window)-p.config.WindowSize:])
+		p.window = p.window[:n]
+	}
+	return nil
+}
+
+// HandleShare учитывает долю сервера; подходит для stratum.ServerConfig.OnShare
+func (p *Pool) HandleShare(s stratum.Share) {
+	addr, err := ParseWorker(s.Worker)
+	if err != nil {
+		return
+	}
+	_ = p.AddShare(addr, s.Difficulty)
+}
+
+// HandleBlock делит награду принятого блока; подходит для stratum.ServerConfig.OnBlock.
+// Блоки, coinbase которых платит не пулу, пропускаются.
+func (p *Pool) HandleBlock(b *block.Block) {
+	if b == nil || len(b.Transaction) == 0 {
+		return
+	}
+	cb, ok := b.Transaction[0].(*transaction.CoinbaseTransaction)
+	if !ok || !bytes.Equal(cb.Recipient, p.config.Address) {
+		return
+	}
+	_, _ = p.BlockFound(b.Header.Index, cb.Amount)
+}
+
+// BlockFound делит награду reward блока на высоте height по схеме пула и
+// ставит выплаты в очередь. Начисления меньше MinPayout копятся до
+// следующих блоков. Если долей нет, вся награда остаётся пулу.
+func (p *Pool) BlockFound(height int, reward float64) (Round, error) {
+	if reward < 0 || math.IsNaN(reward) || math.IsInf(reward, 0) {
+		return Round{}, fmt.Errorf("invalid block reward: %v", reward)
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	round := splitReward(height, reward, p.config.Fee, p.window)
+	if p.config.Scheme == SchemeProportional {
+		p.window = nil
+	}
+
+	for _, credit := range round.Credits {
+		p.owed[string(credit.Miner)] += credit.Amount
+	}
+	for _, credit := range round.Credits {
+		key := string(credit.Miner)
+		amount := p.owed[key]
+		if amount <= 0 || amount < p.config.MinPayout {
+			continue
+		}
+		delete(p.owed, key)
+		p.payouts = append(p.payouts, p.payoutTransaction(height, credit.Miner, amount))
+	}
+
+	p.rounds = append(p.rounds, round)
+	return round, nil
+}
+
+// payoutTransaction - перевод с адреса пула; ID однозначно задан блоком и получателем
+func (p *Pool) payoutTransaction(height int, minerAddr []byte, amount float64) *transaction.BankTransaction {
+	return &transaction.BankTransaction{
+		ID:        payoutID(height, minerAddr),
+		Sender:    append([]byte(nil), p.config.Address...),
+		Recipient: append([]byte(nil), minerAddr...),
+		Amount:    amount,
+	}
+}
+
+// Pending возвращает до max транзакций (0 - все): сначала выплаты, затем вложенный источник
+func (p *Pool) Pending(max int) []transaction.Transaction {
+	p.mu.Lock()
+	n := len(p.payouts)
+	if max > 0 && n > max {
+		n = max
+	}
+	txs := make([]transaction.Transaction, 0, n)
+	for _, tx := range p.payouts[:n] {
+		txs = append(txs, tx)
+	}
+	p.mu.Unlock()
+
+	if p.inner != nil && (max <= 0 || len(txs) < max) {
+		rest := 0
+		if max > 0 {
+			rest = max - len(txs)
+		}
+		txs = append(txs, p.inner.Pending(rest)...)
+	}
+	return txs
+}
+
+// Remove убирает попавшие в блок выплаты и передаёт txs вложенному источнику
+func (p *Pool) Remove(txs []transaction.Transaction) {
+	included := make(map[string]struct{}, len(txs))
+	for _, tx := range txs {
+		if tx != nil {
+			included[string(tx.TransactionGetID())] = struct{}{}
+		}
+	}
+
+	p.mu.Lock()
+	kept := p.payouts[:0]
+	for _, tx := range p.payouts {
+		if _, ok := included[string(tx.ID)]; !ok {
+			kept = append(kept, tx)
+		}
+	}
+	p.payouts = kept
+	p.mu.Unlock()
+
+	if p.inner != nil {
+		p.inner.Remove(txs)
+	}
+}
+
+// Owed возвращает начисленную, но ещё не выплаченную сумму майнеру
+func (p *Pool) Owed(minerAddr []byte) float64 {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.owed[string(minerAddr)]
+}
+
+// PendingPayouts возвращает число выплат, ещё не попавших в блок
+func (p *Pool) PendingPayouts() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return len(p.payouts)
+}
+
+// Rounds возвращает историю разделов награды по найденным блокам
+func (p *Pool) Rounds() []Round {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	rounds := make([]Round, len(p.rounds))
+	copy(rounds, p.rounds)
+	return rounds
+}
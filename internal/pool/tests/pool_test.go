@@ -0,0 +1,302 @@
+package tests
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"errors"
+	"math"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/Alex1997377/weave/internal/core/mempool"
+	"github.com/Alex1997377/weave/internal/core/transaction"
+	"github.com/Alex1997377/weave/internal/miner"
+	"github.com/Alex1997377/weave/internal/pool"
+	"github.com/Alex1997377/weave/internal/stratum"
+	"github.com/Alex1997377/weave/internal/tests/helpers"
+)
+
+var (
+	poolAddress = bytes.Repeat([]byte{0x50}, 32)
+	alice       = bytes.Repeat([]byte{0xA1}, 32)
+	bob         = bytes.Repeat([]byte{0xB0}, 32)
+)
+
+func newPool(t *testing.T, config pool.Config, inner miner.TxSource) *pool.Pool {
+	t.Helper()
+
+	config.Address = poolAddress
+	p, err := pool.New(config, inner)
+	if err != nil {
+		t.Fatalf("pool.New error: %v", err)
+	}
+	return p
+}
+
+func addShares(t *testing.T, p *pool.Pool, addr []byte, n, difficulty int) {
+	t.Helper()
+	for i := 0; i < n; i++ {
+		if err := p.AddShare(addr, difficulty); err != nil {
+			t.Fatalf("AddShare error: %v", err)
+		}
+	}
+}
+
+func almostEqual(a, b float64) bool {
+	return math.Abs(a-b) < 1e-9
+}
+
+// checkRound проверяет, что раздел награды сходится до копейки
+func checkRound(t *testing.T, r pool.Round) {
+	t.Helper()
+
+	total := r.Fee
+	for _, c := range r.Credits {
+		total += c.Amount
+	}
+	if !almostEqual(total, r.Reward) {
+		t.Errorf("round %d: credits+fee = %v, want reward %v", r.Height, total, r.Reward)
+	}
+}
+
+func creditOf(r pool.Round, addr []byte) float64 {
+	for _, c := range r.Credits {
+		if bytes.Equal(c.Miner, addr) {
+			return c.Amount
+		}
+	}
+	return 0
+}
+
+func TestPool_Proportional(t *testing.T) {
+	p := newPool(t, pool.Config{Scheme: pool.SchemeProportional, Fee: 0.1}, nil)
+
+	addShares(t, p, alice, 3, 4)
+	addShares(t, p, bob, 1, 4)
+
+	round, err := p.BlockFound(1, 100)
+	if err != nil {
+		t.Fatalf("BlockFound error: %v", err)
+	}
+	checkRound(t, round)
+	if !almostEqual(creditOf(round, alice), 67.5) || !almostEqual(creditOf(round, bob), 22.5) {
+		t.Errorf("unexpected credits: %+v", round.Credits)
+	}
+	if !almostEqual(round.Fee, 10) {
+		t.Errorf("fee = %v, want 10", round.Fee)
+	}
+
+	// пропорциональная схема начинает раунд заново
+	addShares(t, p, bob, 1, 4)
+	round, _ = p.BlockFound(2, 100)
+	if creditOf(round, alice) != 0 || !almostEqual(creditOf(round, bob), 90) {
+		t.Errorf("second round should pay only bob: %+v", round.Credits)
+	}
+}
+
+func TestPool_PPLNSWindowAndWeights(t *testing.T) {
+	p := newPool(t, pool.Config{Scheme: pool.SchemePPLNS, WindowSize: 4}, nil)
+
+	// первые доли alice вытесняются из окна долями bob
+	addShares(t, p, alice, 3, 4)
+	addShares(t, p, bob, 3, 4)
+
+	round, _ := p.BlockFound(1, 40)
+	checkRound(t, round)
+	if !almostEqual(creditOf(round, alice), 10) || !almostEqual(creditOf(round, bob), 30) {
+		t.Errorf("unexpected PPLNS credits: %+v", round.Credits)
+	}
+
+	// окно сохраняется между блоками, а вес доли растёт с её сложностью
+	addShares(t, p, alice, 1, 6)
+	round, _ = p.BlockFound(2, 70)
+	checkRound(t, round)
+	// окно: bob x3 (вес 16), alice x1 (вес 64)
+	if !almostEqual(creditOf(round, alice), 40) || !almostEqual(creditOf(round, bob), 30) {
+		t.Errorf("unexpected weighted credits: %+v", round.Credits)
+	}
+}
+
+func TestPool_NoSharesKeepsReward(t *testing.T) {
+	p := newPool(t, pool.Config{}, nil)
+
+	round, err := p.BlockFound(1, 50)
+	if err != nil {
+		t.Fatalf("BlockFound error: %v", err)
+	}
+	if round.Fee != 50 || len(round.Credits) != 0 || p.PendingPayouts() != 0 {
+		t.Errorf("empty round should keep reward in pool: %+v", round)
+	}
+	if _, err := p.BlockFound(2, math.NaN()); err == nil {
+		t.Error("expected error for NaN reward")
+	}
+}
+
+func TestPool_MinPayoutAccumulates(t *testing.T) {
+	p := newPool(t, pool.Config{Scheme: pool.SchemeProportional, MinPayout: 15}, nil)
+
+	addShares(t, p, alice, 1, 1)
+	p.BlockFound(1, 10)
+	if p.PendingPayouts() != 0 || !almostEqual(p.Owed(alice), 10) {
+		t.Fatalf("payout below minimum must accumulate: owed=%v pending=%d", p.Owed(alice), p.PendingPayouts())
+	}
+
+	addShares(t, p, alice, 1, 1)
+	p.BlockFound(2, 10)
+
+	txs := p.Pending(0)
+	if len(txs) != 1 || p.Owed(alice) != 0 {
+		t.Fatalf("expected one payout after minimum reached, got %d (owed %v)", len(txs), p.Owed(alice))
+	}
+	tx := txs[0]
+	if !bytes.Equal(tx.TransactionGetSender(), poolAddress) || !bytes.Equal(tx.TransactionGetRecipient(), alice) || !almostEqual(tx.TransactionGetAmount(), 20) {
+		t.Errorf("unexpected payout: %+v", tx)
+	}
+	if _, err := tx.TransactionSerialize(); err != nil {
+		t.Errorf("payout cannot be serialized: %v", err)
+	}
+}
+
+func TestPool_PendingAndRemove(t *testing.T) {
+	inner := mempool.New(0)
+	userTx := &transaction.BankTransaction{
+		ID:        bytes.Repeat([]byte{0x07}, 32),
+		Sender:    bytes.Repeat([]byte{0x01}, 32),
+		Recipient: bytes.Repeat([]byte{0x02}, 32),
+		Amount:    1,
+	}
+	if err := inner.Add(userTx); err != nil {
+		t.Fatalf("mempool Add error: %v", err)
+	}
+
+	p := newPool(t, pool.Config{Scheme: pool.SchemeProportional}, inner)
+	addShares(t, p, alice, 1, 1)
+	addShares(t, p, bob, 1, 1)
+	p.BlockFound(1, 10)
+
+	txs := p.Pending(0)
+	if len(txs) != 3 || !bytes.Equal(txs[2].TransactionGetID(), userTx.ID) {
+		t.Fatalf("payouts must come before mempool transactions: %d", len(txs))
+	}
+	if got := p.Pending(2); len(got) != 2 || bytes.Equal(got[1].TransactionGetID(), userTx.ID) {
+		t.Errorf("Pending(2) must respect limit and order")
+	}
+
+	p.Remove(txs[:1])
+	if p.PendingPayouts() != 1 {
+		t.Errorf("pending payouts = %d, want 1", p.PendingPayouts())
+	}
+	p.Remove(txs[1:])
+	if p.PendingPayouts() != 0 || inner.Len() != 0 {
+		t.Errorf("Remove must clear payouts and mempool: payouts=%d mempool=%d", p.PendingPayouts(), inner.Len())
+	}
+}
+
+func TestParseWorker(t *testing.T) {
+	addr, err := pool.ParseWorker(hex.EncodeToString(alice) + ".rig-1")
+	if err != nil || !bytes.Equal(addr, alice) {
+		t.Fatalf("ParseWorker = %x, %v", addr, err)
+	}
+	for _, worker := range []string{"", "rig", "abcd", hex.EncodeToString(alice) + "00"} {
+		if _, err := pool.ParseWorker(worker); !errors.Is(err, pool.ErrInvalidWorker) {
+			t.Errorf("ParseWorker(%q) error = %v, want ErrInvalidWorker", worker, err)
+		}
+	}
+}
+
+func TestNew_Errors(t *testing.T) {
+	cases := []pool.Config{
+		{Address: []byte{1}},
+		{Address: poolAddress, Fee: 1.5},
+		{Address: poolAddress, MinPayout: -1},
+		{Address: poolAddress, Scheme: pool.Scheme(9)},
+	}
+	for _, config := range cases {
+		if _, err := pool.New(config, nil); err == nil {
+			t.Errorf("expected error for config %+v", config)
+		}
+	}
+}
+
+func TestPool_StratumPayoutsOnChain(t *testing.T) {
+	bc := helpers.NewTestChain(t)
+
+	p := newPool(t, pool.Config{Scheme: pool.SchemePPLNS, Fee: 0.02}, mempool.New(0))
+	srv, err := stratum.NewServer(bc, p, stratum.ServerConfig{
+		RewardAddress:   poolAddress,
+		ShareDifficulty: 4,
+		NonceRangeSize:  1 << 12,
+		TipPollInterval: 10 * time.Millisecond,
+		Authorize:       p.Authorize,
+		OnShare:         p.HandleShare,
+		OnBlock:         p.HandleBlock,
+	})
+	if err != nil {
+		t.Fatalf("NewServer error: %v", err)
+	}
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen error: %v", err)
+	}
+	served := make(chan error, 1)
+	go func() { served <- srv.Serve(l) }()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	if _, err := stratum.Dial(ctx, l.Addr().String(), "not-an-address"); !errors.Is(err, stratum.ErrUnauthorized) {
+		t.Errorf("Dial with invalid worker: %v, want ErrUnauthorized", err)
+	}
+
+	mined := make(chan error, 2)
+	for _, addr := range [][]byte{alice, bob} {
+		client, err := stratum.Dial(ctx, l.Addr().String(), hex.EncodeToString(addr)+".rig")
+		if err != nil {
+			t.Fatalf("Dial error: %v", err)
+		}
+		defer client.Close()
+		go func() { mined <- client.Mine(ctx, 1) }()
+	}
+
+	// выплаты за блок попадают в один из следующих блоков
+	helpers.WaitFor(t, 10*time.Second, func() bool { return len(p.Rounds()) >= 6 })
+	cancel()
+	<-mined
+	<-mined
+	srv.Close()
+	<-served
+
+	if err := bc.IsValid(t.Context()); err != nil {
+		t.Fatalf("chain is invalid: %v", err)
+	}
+
+	var credited, paidOnChain float64
+	for _, r := range p.Rounds() {
+		checkRound(t, r)
+		for _, c := range r.Credits {
+			credited += c.Amount
+		}
+	}
+	for _, addr := range [][]byte{alice, bob} {
+		balance, err := bc.GetBalance(t.Context(), addr)
+		if err != nil {
+			t.Fatalf("GetBalance error: %v", err)
+		}
+		paidOnChain += balance
+	}
+	if paidOnChain <= 0 {
+		t.Fatal("no payouts reached the chain")
+	}
+
+	// всё начисленное либо в цепочке, либо ещё ждёт блока
+	var queued float64
+	for _, tx := range p.Pending(0) {
+		queued += tx.TransactionGetAmount()
+	}
+	if !almostEqual(paidOnChain+queued, credited) {
+		t.Errorf("on chain %v + queued %v != credited %v", paidOnChain, queued, credited)
+	}
+}
@@ -1,34 +1,35 @@
 package store
 
 import (
+	"bytes"
+	"context"
+	"encoding/binary"
 	"errors"
 	"fmt"
+	"math/big"
 
+	"github.com/Alex1997377/weave/internal/codec"
 	"github.com/Alex1997377/weave/internal/core/block"
+	"github.com/Alex1997377/weave/internal/core/header"
 	"github.com/dgraph-io/badger/v4"
 )
 
-// SaveBlock сохраняет блок в БД (реализация block.BlockStore)
-func (r *Repository) SaveBlock(b *block.Block) error {
-	if b == nil {
-		return ErrNilBlock
+// SaveBlock сохраняет блок в БД и делает его последним (реализация block.BlockStore)
+func (r *Repository) SaveBlock(ctx context.Context, b *block.Block) error {
+	if err := checkBlock(b); err != nil {
+		return err
 	}
-
-	if b.Hash == nil {
-		return errors.New("block hash is nil")
+	if err := ctx.Err(); err != nil {
+		return err
 	}
 
 	return r.db.Update(func(txn *badger.Txn) error {
-		// Сериализуем блок
-		blockData, err := b.Serialize()
-		if err != nil {
-			return fmt.Errorf("failed to serialize block: %w", err)
+		if err := r.putBlock(txn, b); err != nil {
+			return err
 		}
 
-		// Сохраняем блок по ключу b + hash
-		key := append([]byte("b"), b.Hash...)
-		if err := txn.Set(key, blockData); err != nil {
-			return fmt.Errorf("failed to set block data: %w", err)
+		if err := txn.Set(heightKey(b.Header.Index), b.Hash); err != nil {
+			return fmt.Errorf("failed to update height index: %w", err)
 		}
 
 		// Обновляем указатель на последний блок
@@ -40,39 +41,284 @@ func (r *Repository) SaveBlock(b *block.Block) error {
 	})
 }
 
+// PutBlock сохраняет блок, не меняя последний хеш: так хранятся блоки
+// боковых веток (реализация block.BlockStore)
+func (r *Repository) PutBlock(ctx context.Context, b *block.Block) error {
+	if err := checkBlock(b); err != nil {
+		return err
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	return r.db.Update(func(txn *badger.Txn) error {
+		return r.putBlock(txn, b)
+	})
+}
+
+// SetLastHash переносит указатель на последний блок и перестраивает индекс
+// высот основной цепочки: от hash вниз до первой совпадающей высоты, записи
+// выше новой вершины удаляются. Всё делается одной транзакцией; отмена ctx
+// во время обхода её откатывает (реализация block.BlockStore).
+func (r *Repository) SetLastHash(ctx context.Context, hash []byte) error {
+	if hash == nil {
+		return ErrNilHash
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	return r.db.Update(func(txn *badger.Txn) error {
+		tip, err := getHeader(txn, hash)
+		if err != nil {
+			return err
+		}
+
+		// старая ветка могла быть выше новой
+		for height := tip.Header.Index + 1; ; height++ {
+			key := heightKey(height)
+			if _, err := txn.Get(key); err == badger.ErrKeyNotFound {
+				break
+			} else if err != nil {
+				return fmt.Errorf("failed to read height index: %w", err)
+			}
+			if err := txn.Delete(key); err != nil {
+				return fmt.Errorf("failed to update height index: %w", err)
+			}
+		}
+
+		for h := tip; ; {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+			indexed, err := getHashByHeight(txn, h.Header.Index)
+			if err != nil && err != ErrBlockNotFound {
+				return err
+			}
+			if bytes.Equal(indexed, h.Hash) {
+				break
+			}
+			if err := txn.Set(heightKey(h.Header.Index), h.Hash); err != nil {
+				return fmt.Errorf("failed to update height index: %w", err)
+			}
+			if h.Header.Index == 0 {
+				break
+			}
+			prev := h.Header.PreviousHash
+			if h, err = getHeader(txn, prev); err != nil {
+				return fmt.Errorf("failed to walk to block %x: %w", prev, err)
+			}
+		}
+
+		if err := txn.Set([]byte("l"), hash); err != nil {
+			return fmt.Errorf("failed to update last hash: %w", err)
+		}
+		return nil
+	})
+}
+
+// GetHashByHeight возвращает хеш блока основной цепочки на высоте height
+// (реализация block.BlockStore)
+func (r *Repository) GetHashByHeight(ctx context.Context, height int) ([]byte, error) {
+	if height < 0 {
+		return nil, fmt.Errorf("block height cannot be negative: %d", height)
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	var hash []byte
+	err := r.db.View(func(txn *badger.Txn) error {
+		var err error
+		hash, err = getHashByHeight(txn, height)
+		return err
+	})
+	return hash, err
+}
+
+func checkBlock(b *block.Block) error {
+	if b == nil {
+		return ErrNilBlock
+	}
+	if b.Hash == nil {
+		return errors.New("block hash is nil")
+	}
+	return nil
+}
+
+// putBlock записывает блок, его заголовок и накопленную работу в транзакции txn
+func (r *Repository) putBlock(txn *badger.Txn, b *block.Block) error {
+	// Сериализуем блок кодеком хранилища
+	blockData, err := encodeValue(r.codec, b)
+	if err != nil {
+		return fmt.Errorf("failed to serialize block: %w", err)
+	}
+
+	// Сохраняем блок по ключу b + hash
+	key := append([]byte("b"), b.Hash...)
+	if err := txn.Set(key, blockData); err != nil {
+		return fmt.Errorf("failed to set block data: %w", err)
+	}
+
+	// Заголовок дублируем отдельной записью: дерево заголовков строится без тел
+	if err := putHeader(txn, &b.Header, b.Hash); err != nil {
+		return err
+	}
+
+	// Накопленную работу храним отдельно: она не входит в формат блока
+	if b.ChainWork != nil {
+		if err := txn.Set(chainWorkKey(b.Hash), b.ChainWork.Bytes()); err != nil {
+			return fmt.Errorf("failed to set chain work: %w", err)
+		}
+	}
+	return nil
+}
+
 // GetBlock получает блок по хешу (реализация block.BlockStore)
-func (r *Repository) GetBlock(hash []byte) (*block.Block, error) {
+func (r *Repository) GetBlock(ctx context.Context, hash []byte) (*block.Block, error) {
 	if hash == nil {
 		return nil, ErrNilHash
 	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
 
 	var resultBlock *block.Block
+	err := r.db.View(func(txn *badger.Txn) error {
+		var err error
+		resultBlock, err = getBlock(txn, hash)
+		return err
+	})
+	return resultBlock, err
+}
 
+// getBlock читает блок и его накопленную работу в транзакции txn
+func getBlock(txn *badger.Txn, hash []byte) (*block.Block, error) {
+	item, err := txn.Get(append([]byte("b"), hash...))
+	if err != nil {
+		if err == badger.ErrKeyNotFound {
+			return nil, ErrBlockNotFound
+		}
+		return nil, fmt.Errorf("failed to get block from db: %w", err)
+	}
+
+	var resultBlock *block.Block
+	err = item.Value(func(val []byte) error {
+		var err error
+		resultBlock, err = decodeValue(val)
+		if err != nil {
+			return fmt.Errorf("failed to deserialize block: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	resultBlock.ChainWork, err = getChainWork(txn, hash)
+	if err != nil {
+		return nil, err
+	}
+	return resultBlock, nil
+}
+
+// GetHeader получает заголовок блока по хешу, не читая тела
+// (реализация block.BlockStore)
+func (r *Repository) GetHeader(ctx context.Context, hash []byte) (*block.StoredHeader, error) {
+	if hash == nil {
+		return nil, ErrNilHash
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	var result *block.StoredHeader
 	err := r.db.View(func(txn *badger.Txn) error {
-		key := append([]byte("b"), hash...)
-		item, err := txn.Get(key)
+		var err error
+		result, err = getHeader(txn, hash)
+		return err
+	})
+	return result, err
+}
+
+// getHeader читает запись заголовка и накопленную работу в транзакции txn
+func getHeader(txn *badger.Txn, hash []byte) (*block.StoredHeader, error) {
+	item, err := txn.Get(headerKey(hash))
+	if err != nil {
+		if err == badger.ErrKeyNotFound {
+			return nil, ErrBlockNotFound
+		}
+		return nil, fmt.Errorf("failed to get header from db: %w", err)
+	}
+
+	result := &block.StoredHeader{Hash: append([]byte(nil), hash...)}
+	err = item.Value(func(val []byte) error {
+		h, err := header.DeserializeHeader(bytes.NewReader(val))
 		if err != nil {
-			if err == badger.ErrKeyNotFound {
-				return ErrBlockNotFound
-			}
-			return fmt.Errorf("failed to get block from db: %w", err)
+			return fmt.Errorf("failed to deserialize header: %w", err)
 		}
+		result.Header = *h
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
 
-		return item.Value(func(val []byte) error {
-			var err error
-			resultBlock, err = block.DeserializeBlock(val)
-			if err != nil {
-				return fmt.Errorf("failed to deserialize block: %w", err)
-			}
-			return nil
-		})
+	result.ChainWork, err = getChainWork(txn, hash)
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// putHeader записывает отдельную запись заголовка блока hash
+func putHeader(txn *badger.Txn, h *header.Header, hash []byte) error {
+	data, err := h.Serialize()
+	if err != nil {
+		return fmt.Errorf("failed to serialize header: %w", err)
+	}
+	if err := txn.Set(headerKey(hash), data); err != nil {
+		return fmt.Errorf("failed to set header data: %w", err)
+	}
+	return nil
+}
+
+// getChainWork читает накопленную работу блока; nil - блок сохранён без неё
+func getChainWork(txn *badger.Txn, hash []byte) (*big.Int, error) {
+	item, err := txn.Get(chainWorkKey(hash))
+	if err == badger.ErrKeyNotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get chain work: %w", err)
+	}
+
+	var work *big.Int
+	err = item.Value(func(val []byte) error {
+		work = new(big.Int).SetBytes(val)
+		return nil
 	})
+	return work, err
+}
 
-	return resultBlock, err
+// getHashByHeight читает индекс высот в транзакции txn
+func getHashByHeight(txn *badger.Txn, height int) ([]byte, error) {
+	item, err := txn.Get(heightKey(height))
+	if err != nil {
+		if err == badger.ErrKeyNotFound {
+			return nil, ErrBlockNotFound
+		}
+		return nil, fmt.Errorf("failed to read height index: %w", err)
+	}
+	return item.ValueCopy(nil)
 }
 
 // GetLastHash получает хеш последнего блока (реализация block.BlockStore)
-func (r *Repository) GetLastHash() ([]byte, error) {
+func (r *Repository) GetLastHash(ctx context.Context) ([]byte, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
 	var lastHash []byte
 	err := r.db.View(func(txn *badger.Txn) error {
 		item, err := txn.Get([]byte("l"))
@@ -104,3 +350,48 @@ func (r *Repository) Close() error {
 	}
 	return r.db.Close()
 }
+
+// chainWorkKey - ключ накопленной работы блока: w + hash
+func chainWorkKey(hash []byte) []byte {
+	return append([]byte("w"), hash...)
+}
+
+// headerKey - ключ записи заголовка: H + hash (h - индекс высот)
+func headerKey(hash []byte) []byte {
+	return append([]byte("H"), hash...)
+}
+
+// heightKey - ключ индекса высот основной цепочки: h + высота big-endian,
+// так что записи упорядочены по высоте
+func heightKey(height int) []byte {
+	key := make([]byte, 9)
+	key[0] = 'h'
+	binary.BigEndian.PutUint64(key[1:], uint64(height))
+	return key
+}
+
+// encodeValue кодирует блок и помечает значение идентификатором кодека
+func encodeValue(c codec.Codec, b *block.Block) ([]byte, error) {
+	data, err := c.EncodeBlock(b)
+	if err != nil {
+		return nil, err
+	}
+
+	value := make([]byte, 0, 1+len(data))
+	value = append(value, c.ID())
+	return append(value, data...), nil
+}
+
+// decodeValue выбирает кодек по первому байту значения
+func decodeValue(val []byte) (*block.Block, error) {
+	if len(val) == 0 {
+		return nil, ErrEmptyValue
+	}
+
+	c, err := codec.ByID(val[0])
+	if err != nil {
+		return nil, err
+	}
+
+	return c.DecodeBlock(val[1:])
+}
@@ -3,21 +3,42 @@ package store
 import (
 	"errors"
 
+	"github.com/Alex1997377/weave/internal/codec"
+	"github.com/Alex1997377/weave/internal/core/block"
 	"github.com/dgraph-io/badger/v4"
 )
 
 var (
-	ErrBlockNotFound = errors.New("block not found")
+	ErrBlockNotFound = block.ErrBlockNotFound
 	ErrNilBlock      = errors.New("block is nil")
 	ErrNilHash       = errors.New("hash is nil")
+	ErrEmptyValue    = errors.New("stored value is empty")
 )
 
+// Repository хранит блоки в badger. Каждое значение начинается с байта
+// codec.Codec.ID, поэтому смена кодека не ломает чтение уже записанных блоков.
 type Repository struct {
-	db *badger.DB
+	db    *badger.DB
+	codec codec.Codec
 }
 
 func NewRepository(db *badger.DB) *Repository {
+	return NewRepositoryWithCodec(db, codec.Default())
+}
+
+// NewRepositoryWithCodec создаёт хранилище, записывающее блоки кодеком c
+func NewRepositoryWithCodec(db *badger.DB, c codec.Codec) *Repository {
+	if c == nil {
+		c = codec.Default()
+	}
+
 	return &Repository{
-		db: db,
+		db:    db,
+		codec: c,
 	}
 }
+
+// Codec возвращает кодек, которым хранилище записывает новые блоки
+func (r *Repository) Codec() codec.Codec {
+	return r.codec
+}
@@ -0,0 +1,358 @@
+package stratum
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"github.com/Alex1997377/weave/internal/core/block"
+	"github.com/Alex1997377/weave/internal/crypto/pow"
+	"github.com/Alex1997377/weave/internal/crypto/powalgo"
+)
+
+var ErrClientClosed = errors.New("stratum client closed")
+
+// ClientStats - итоги отправки долей
+type ClientStats struct {
+	Accepted uint64
+	Rejected uint64
+	Blocks   uint64
+}
+
+// Client - внешний майнер: получает задания сервера и перебирает
+// выделенные ему отрезки nonce воркерами block.SearchNonce
+type Client struct {
+	conn    net.Conn
+	session SubscribeResult
+
+	writeMu sync.Mutex
+
+	mu      sync.Mutex
+	nextID  uint64
+	pending map[uint64]chan Message
+	err     error
+
+	// jobs хранит только последнее уведомление: старые задания бесполезны
+	jobs chan Job
+	done chan struct{}
+
+	accepted atomic.Uint64
+	rejected atomic.Uint64
+	blocks   atomic.Uint64
+}
+
+// Dial подключается к серверу и подписывается на задания от имени worker
+func Dial(ctx context.Context, addr, worker string) (*Client, error) {
+	var d net.Dialer
+	conn, err := d.DialContext(ctx, "tcp", addr)
+	if err != nil {
+		return nil, err
+	}
+
+	c := &Client{
+		conn:    conn,
+		pending: make(map[uint64]chan Message),
+		jobs:    make(chan Job, 1),
+		done:    make(chan struct{}),
+	}
+	go c.readLoop()
+
+	if err := c.call(ctx, MethodSubscribe, SubscribeParams{Worker: worker}, &c.session); err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("subscribe failed: %w", err)
+	}
+	return c, nil
+}
+
+// Session возвращает ответ сервера на подписку
+func (c *Client) Session() SubscribeResult {
+	return c.session
+}
+
+// Stats возвращает счётчики отправленных долей
+func (c *Client) Stats() ClientStats {
+	return ClientStats{
+		Accepted: c.accepted.Load(),
+		Rejected: c.rejected.Load(),
+		Blocks:   c.blocks.Load(),
+	}
+}
+
+// Jobs возвращает канал уведомлений mining.notify
+func (c *Client) Jobs() <-chan Job {
+	return c.jobs
+}
+
+// GetJob запрашивает у сервера следующий отрезок nonce текущего шаблона
+func (c *Client) GetJob(ctx context.Context) (Job, error) {
+	var job Job
+	err := c.call(ctx, MethodGetJob, nil, &job)
+	return job, err
+}
+
+// Submit отправляет долю; отказ сервера возвращается ошибкой
+func (c *Client) Submit(ctx context.Context, jobID string, nonce uint64) (SubmitResult, error) {
+	var result SubmitResult
+	err := c.call(ctx, MethodSubmit, SubmitParams{JobID: jobID, Nonce: nonce}, &result)
+	return result, err
+}
+
+// Close закрывает соединение
+func (c *Client) Close() error {
+	return c.conn.Close()
+}
+
+// Mine перебирает задания сервера до отмены ctx или обрыва соединения.
+// Ищутся хеши, проходящие более лёгкую из целей доли и блока; каждая
+// находка отправляется серверу, после чего перебор продолжается со
+// следующего nonce. Исчерпав отрезок, клиент запрашивает новый, а
+// уведомление с Clean прерывает текущий перебор.
+func (c *Client) Mine(ctx context.Context, numWorkers int) error {
+	var job Job
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-c.done:
+		return c.closedErr()
+	case job = <-c.jobs:
+	}
+	from := job.NonceStart
+
+	for {
+		search, err := jobSearch(job, numWorkers)
+		if err != nil {
+			return err
+		}
+		search.First = from
+
+		jobCtx, cancel := context.WithCancel(ctx)
+		type result struct {
+			nonce uint64
+			found bool
+		}
+		resultCh := make(chan result, 1)
+		go func() {
+			nonce, _, found, _ := block.SearchNonce(jobCtx, search)
+			resultCh <- result{nonce: nonce, found: found}
+		}()
+
+		select {
+		case <-ctx.Done():
+			cancel()
+			<-resultCh
+			return ctx.Err()
+
+		case <-c.done:
+			cancel()
+			<-resultCh
+			return c.closedErr()
+
+		case next := <-c.jobs:
+			cancel()
+			r := <-resultCh
+			// находку прерванного задания ещё можно сдать, если шаблон не сменился
+			if r.found && !next.Clean {
+				c.submitShare(ctx, job.ID, r.nonce)
+			}
+			job, from = next, next.NonceStart
+
+		case r := <-resultCh:
+			cancel()
+			if r.found {
+				c.submitShare(ctx, job.ID, r.nonce)
+				if r.nonce < job.NonceEnd {
+					from = r.nonce + 1
+					continue
+				}
+			}
+
+			next, err := c.GetJob(ctx)
+			if err != nil {
+				if ctx.Err() != nil {
+					return ctx.Err()
+				}
+				return fmt.Errorf("failed to get job: %w", err)
+			}
+			job, from = next, next.NonceStart
+		}
+	}
+}
+
+// jobSearch готовит перебор задания без начала отрезка
+func jobSearch(job Job, numWorkers int) (block.NonceSearch, error) {
+	header, err := job.HeaderBytes()
+	if err != nil {
+		return block.NonceSearch{}, err
+	}
+	target, err := job.BlockTarget()
+	if err != nil {
+		return block.NonceSearch{}, err
+	}
+	algo, err := powalgo.Get(job.Algorithm)
+	if err != nil {
+		return block.NonceSearch{}, err
+	}
+
+	if shareTarget := pow.DifficultyToTarget(job.ShareDifficulty); shareTarget.Cmp(target) > 0 {
+		target = shareTarget
+	}
+
+	return block.NonceSearch{
+		Header:      header,
+		NonceOffset: job.NonceOffset,
+		Last:        job.NonceEnd,
+		Target:      target,
+		Hasher:      algo,
+		NumWorkers:  numWorkers,
+	}, nil
+}
+
+// submitShare отправляет долю и учитывает результат; отказ не прерывает майнинг
+func (c *Client) submitShare(ctx context.Context, jobID string, nonce uint64) {
+	result, err := c.Submit(ctx, jobID, nonce)
+	if err != nil {
+		c.rejected.Add(1)
+		return
+	}
+	c.accepted.Add(1)
+	if result.Block {
+		c.blocks.Add(1)
+	}
+}
+
+// call отправляет запрос и ждёт ответа с тем же id
+func (c *Client) call(ctx context.Context, method string, params, result interface{}) error {
+	var raw json.RawMessage
+	if params != nil {
+		data, err := json.Marshal(params)
+		if err != nil {
+			return err
+		}
+		raw = data
+	}
+
+	c.mu.Lock()
+	if c.err != nil {
+		c.mu.Unlock()
+		return c.err
+	}
+	c.nextID++
+	id := c.nextID
+	replyCh := make(chan Message, 1)
+	c.pending[id] = replyCh
+	c.mu.Unlock()
+
+	defer func() {
+		c.mu.Lock()
+		delete(c.pending, id)
+		c.mu.Unlock()
+	}()
+
+	if err := c.write(Message{ID: &id, Method: method, Params: raw}); err != nil {
+		return err
+	}
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-c.done:
+		return c.closedErr()
+	case reply := <-replyCh:
+		if reply.Error != "" {
+			return replyError(reply.Error)
+		}
+		if result != nil && len(reply.Result) > 0 {
+			return json.Unmarshal(reply.Result, result)
+		}
+		return nil
+	}
+}
+
+func (c *Client) write(msg Message) error {
+	data, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+	data = append(data, '\n')
+
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+
+	_, err = c.conn.Write(data)
+	return err
+}
+
+func (c *Client) readLoop() {
+	scanner := bufio.NewScanner(c.conn)
+	scanner.Buffer(make([]byte, 0, 4096), MaxLineSize)
+
+	for scanner.Scan() {
+		var msg Message
+		if err := json.Unmarshal(scanner.Bytes(), &msg); err != nil {
+			continue
+		}
+
+		if msg.ID != nil {
+			c.mu.Lock()
+			replyCh := c.pending[*msg.ID]
+			c.mu.Unlock()
+			if replyCh != nil {
+				replyCh <- msg
+			}
+			continue
+		}
+
+		if msg.Method == MethodNotify {
+			var job Job
+			if err := json.Unmarshal(msg.Params, &job); err != nil {
+				continue
+			}
+			c.pushJob(job)
+		}
+	}
+
+	err := scanner.Err()
+	if err == nil {
+		err = ErrClientClosed
+	}
+	c.mu.Lock()
+	c.err = err
+	c.mu.Unlock()
+	close(c.done)
+}
+
+// pushJob заменяет непрочитанное задание новым; Clean не теряется,
+// если его перекрывает задание того же шаблона
+func (c *Client) pushJob(job Job) {
+	select {
+	case old := <-c.jobs:
+		job.Clean = job.Clean || old.Clean
+	default:
+	}
+	c.jobs <- job
+}
+
+func (c *Client) closedErr() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.err
+}
+
+// replyError восстанавливает известные ошибки протокола, чтобы их можно было проверить errors.Is
+func replyError(text string) error {
+	for _, err := range []error{
+		ErrUnknownMethod, ErrNotSubscribed, ErrUnauthorized, ErrStaleJob,
+		ErrNonceOutOfRange, ErrDuplicateShare, ErrLowDifficulty,
+	} {
+		if text == err.Error() || strings.HasPrefix(text, err.Error()+":") {
+			return fmt.Errorf("%w%s", err, strings.TrimPrefix(text, err.Error()))
+		}
+	}
+	return errors.New(text)
+}
@@ -0,0 +1,108 @@
+package stratum
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/Alex1997377/weave/internal/crypto/pow"
+)
+
+// Протокол - построчный JSON в стиле stratum: каждая строка содержит один
+// запрос, ответ или уведомление. Запросы клиента имеют числовой id, ответ
+// сервера повторяет его; уведомления сервера приходят без id.
+const (
+	MethodSubscribe = "mining.subscribe"
+	MethodNotify    = "mining.notify"
+	MethodGetJob    = "mining.get_job"
+	MethodSubmit    = "mining.submit"
+)
+
+// MaxLineSize - максимальная длина одной строки протокола
+const MaxLineSize = 64 << 10
+
+var (
+	ErrUnknownMethod   = errors.New("unknown method")
+	ErrNotSubscribed   = errors.New("not subscribed")
+	ErrUnauthorized    = errors.New("unauthorized worker")
+	ErrStaleJob        = errors.New("stale job")
+	ErrNonceOutOfRange = errors.New("nonce out of assigned range")
+	ErrDuplicateShare  = errors.New("duplicate share")
+	ErrLowDifficulty   = errors.New("low difficulty share")
+)
+
+// Message - любая строка протокола
+type Message struct {
+	ID     *uint64         `json:"id,omitempty"`
+	Method string          `json:"method,omitempty"`
+	Params json.RawMessage `json:"params,omitempty"`
+	Result json.RawMessage `json:"result,omitempty"`
+	Error  string          `json:"error,omitempty"`
+}
+
+// SubscribeParams - параметры mining.subscribe
+type SubscribeParams struct {
+	Worker string `json:"worker"`
+}
+
+// SubscribeResult - ответ на mining.subscribe
+type SubscribeResult struct {
+	Session         string `json:"session"`
+	ShareDifficulty int    `json:"share_difficulty"`
+}
+
+// Job - задание майнеру: заголовок с нулевым nonce и отрезок nonce,
+// выделенный только этой сессии
+type Job struct {
+	ID              string `json:"job_id"`
+	Height          int    `json:"height"`
+	Header          string `json:"header"`
+	NonceOffset     int    `json:"nonce_offset"`
+	NonceStart      uint64 `json:"nonce_start"`
+	NonceEnd        uint64 `json:"nonce_end"`
+	Target          string `json:"target"`
+	ShareDifficulty int    `json:"share_difficulty"`
+	Algorithm       string `json:"algorithm"`
+	// Clean - предыдущие задания устарели и их перебор нужно прервать
+	Clean bool `json:"clean"`
+}
+
+// HeaderBytes декодирует заголовок задания
+func (j *Job) HeaderBytes() ([]byte, error) {
+	data, err := hex.DecodeString(j.Header)
+	if err != nil {
+		return nil, fmt.Errorf("invalid job header: %w", err)
+	}
+	if j.NonceOffset < 0 || len(data) < j.NonceOffset+8 {
+		return nil, fmt.Errorf("nonce offset %d outside of %d-byte header", j.NonceOffset, len(data))
+	}
+	return data, nil
+}
+
+// BlockTarget декодирует цель блока задания
+func (j *Job) BlockTarget() (pow.Target, error) {
+	var t pow.Target
+	data, err := hex.DecodeString(j.Target)
+	if err != nil {
+		return t, fmt.Errorf("invalid job target: %w", err)
+	}
+	if len(data) != pow.TargetSize {
+		return t, fmt.Errorf("invalid job target length: %d", len(data))
+	}
+	copy(t[:], data)
+	return t, nil
+}
+
+// SubmitParams - параметры mining.submit
+type SubmitParams struct {
+	JobID string `json:"job_id"`
+	Nonce uint64 `json:"nonce"`
+}
+
+// SubmitResult - ответ на принятую долю
+type SubmitResult struct {
+	Accepted bool `json:"accepted"`
+	// Block - доля удовлетворяет цели блока и блок принят цепочкой
+	Block bool `json:"block"`
+}
@@ -0,0 +1,531 @@
+package stratum
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/Alex1997377/weave/internal/core/block"
+	"github.com/Alex1997377/weave/internal/core/transaction"
+	"github.com/Alex1997377/weave/internal/crypto/pow"
+	"github.com/Alex1997377/weave/internal/crypto/powalgo"
+	"github.com/Alex1997377/weave/internal/miner"
+)
+
+// Значения ServerConfig по умолчанию
+const (
+	DefaultShareDifficulty = 16
+	DefaultNonceRangeSize  = 1 << 32
+	DefaultTipPollInterval = 500 * time.Millisecond
+	DefaultWriteTimeout    = 10 * time.Second
+)
+
+var ErrServerClosed = errors.New("stratum server closed")
+
+// ServerConfig - настройки сервера
+type ServerConfig struct {
+	// RewardAddress - получатель coinbase-награды найденных блоков
+	RewardAddress []byte
+	// ShareDifficulty - сложность доли в битах; должна быть проще цели блока
+	ShareDifficulty int
+	// NonceRangeSize - длина отрезка nonce в одном задании
+	NonceRangeSize uint64
+	// MaxTransactions - сколько транзакций брать из TxSource в шаблон; 0 - все
+	MaxTransactions int
+	TipPollInterval time.Duration
+	WriteTimeout    time.Duration
+
+	// Authorize, если задан, проверяет имя воркера при подписке
+	Authorize func(worker string) error
+	// OnShare вызывается для каждой принятой доли до отправки решения в цепочку
+	OnShare func(Share)
+	// OnBlock вызывается после принятия цепочкой найденного блока
+	OnBlock func(*block.Block)
+}
+
+// Share - принятая доля
+type Share struct {
+	Worker     string
+	Session    string
+	JobID      string
+	Height     int
+	Nonce      uint64
+	Hash       []byte
+	Difficulty int
+	// Block - хеш доли удовлетворяет цели блока
+	Block bool
+}
+
+// work - текущий шаблон, общий для всех сессий
+type work struct {
+	seq    uint64
+	tpl    *block.Block
+	base   []byte
+	offset int
+	target pow.Target
+	prev   []byte
+}
+
+// Server раздаёт майнерам задания на шаблон поверх вершины цепочки,
+// принимает доли и отправляет решения в цепочку. Вызовы Chain
+// сериализуются внутри сервера.
+type Server struct {
+	chain  miner.Chain
+	txs    miner.TxSource
+	config ServerConfig
+	algo   powalgo.Algorithm
+
+	// chainMu сериализует проверку вершины и отправку решения в цепочку; берётся раньше mu
+	chainMu sync.Mutex
+
+	mu         sync.Mutex
+	work       *work
+	workSeq    uint64
+	nextRange  uint64
+	sessionSeq uint64
+	sessions   map[*session]struct{}
+	listener   net.Listener
+	closed     bool
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// NewServer создаёт сервер; txs может быть nil
+func NewServer(chain miner.Chain, txs miner.TxSource, config ServerConfig) (*Server, error) {
+	if chain == nil {
+		return nil, errors.New("chain cannot be nil")
+	}
+	if err := transaction.NewCoinbase(0, config.RewardAddress, 0).TransactionValidate(); err != nil {
+		return nil, fmt.Errorf("invalid reward address: %w", err)
+	}
+
+	algo, err := chain.Params().Algorithm()
+	if err != nil {
+		return nil, fmt.Errorf("invalid consensus params: %w", err)
+	}
+
+	if config.ShareDifficulty <= 0 {
+		config.ShareDifficulty = DefaultShareDifficulty
+	}
+	if config.NonceRangeSize == 0 {
+		config.NonceRangeSize = DefaultNonceRangeSize
+	}
+	if config.TipPollInterval <= 0 {
+		config.TipPollInterval = DefaultTipPollInterval
+	}
+	if config.WriteTimeout <= 0 {
+		config.WriteTimeout = DefaultWriteTimeout
+	}
+	config.RewardAddress = append([]byte(nil), config.RewardAddress...)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	return &Server{
+		chain:    chain,
+		txs:      txs,
+		config:   config,
+		algo:     algo,
+		sessions: make(map[*session]struct{}),
+		ctx:      ctx,
+		cancel:   cancel,
+	}, nil
+}
+
+// ListenAndServe слушает TCP-адрес addr и обслуживает майнеров до Close
+func (s *Server) ListenAndServe(addr string) error {
+	l, err := net.Listen("tcp", addr)
+	if err != nil {
+		return err
+	}
+	return s.Serve(l)
+}
+
+// Serve обслуживает соединения l до Close; после Close возвращает ErrServerClosed
+func (s *Server) Serve(l net.Listener) error {
+	s.mu.Lock()
+	if s.closed {
+		s.mu.Unlock()
+		l.Close()
+		return ErrServerClosed
+	}
+	s.listener = l
+	s.mu.Unlock()
+
+	s.chainMu.Lock()
+	sessions, err := s.refreshWork()
+	s.chainMu.Unlock()
+	if err != nil {
+		l.Close()
+		return err
+	}
+	s.notifyWork(sessions)
+
+	s.wg.Add(1)
+	go s.watchTip()
+
+	for {
+		conn, err := l.Accept()
+		if err != nil {
+			if s.ctx.Err() != nil {
+				return ErrServerClosed
+			}
+			return err
+		}
+
+		s.wg.Add(1)
+		go s.serveConn(conn)
+	}
+}
+
+// Addr возвращает адрес слушателя или nil до Serve
+func (s *Server) Addr() net.Addr {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.listener == nil {
+		return nil
+	}
+	return s.listener.Addr()
+}
+
+// Close закрывает слушатель и все сессии и ждёт их завершения
+func (s *Server) Close() error {
+	s.mu.Lock()
+	if s.closed {
+		s.mu.Unlock()
+		return nil
+	}
+	s.closed = true
+	s.cancel()
+
+	var err error
+	if s.listener != nil {
+		err = s.listener.Close()
+	}
+	for sess := range s.sessions {
+		sess.conn.Close()
+	}
+	s.mu.Unlock()
+
+	s.wg.Wait()
+	return err
+}
+
+// watchTip пересобирает шаблон при смене вершины цепочки
+func (s *Server) watchTip() {
+	defer s.wg.Done()
+
+	ticker := time.NewTicker(s.config.TipPollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-s.ctx.Done():
+			return
+		case <-ticker.C:
+			s.chainMu.Lock()
+			s.mu.Lock()
+			stale := s.work == nil || !bytes.Equal(s.chain.TipHash(), s.work.prev)
+			s.mu.Unlock()
+			var sessions []*session
+			if stale {
+				// ошибка сборки шаблона повторится на следующем тике
+				sessions, _ = s.refreshWork()
+			}
+			s.chainMu.Unlock()
+			s.notifyWork(sessions)
+		}
+	}
+}
+
+// refreshWork собирает новый шаблон и делает его текущим. Возвращает
+// подписанные сессии, которым нужно разослать задания через notifyWork
+// уже после освобождения chainMu. Вызывается под chainMu.
+func (s *Server) refreshWork() ([]*session, error) {
+	var pending []transaction.Transaction
+	if s.txs != nil {
+		pending = s.txs.Pending(s.config.MaxTransactions)
+	}
+
+	tpl, err := s.chain.BlockTemplate(s.ctx, s.config.RewardAddress, pending)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build block template: %w", err)
+	}
+
+	s.mu.Lock()
+	// тот же заголовок с тем же временем дал бы уже выданные отрезки nonce
+	if s.work != nil && bytes.Equal(s.work.prev, tpl.Header.PreviousHash) && tpl.Header.Timestamp <= s.work.tpl.Header.Timestamp {
+		tpl.Header.Timestamp = s.work.tpl.Header.Timestamp + 1
+	}
+	s.mu.Unlock()
+
+	base, offset, err := tpl.Header.SerializeWithoutNonce()
+	if err != nil {
+		return nil, fmt.Errorf("failed to serialize template header: %w", err)
+	}
+	target, err := tpl.Header.Target()
+	if err != nil {
+		return nil, fmt.Errorf("invalid template target: %w", err)
+	}
+
+	s.mu.Lock()
+	s.workSeq++
+	s.work = &work{
+		seq:    s.workSeq,
+		tpl:    tpl,
+		base:   base,
+		offset: offset,
+		target: target,
+		prev:   append([]byte(nil), tpl.Header.PreviousHash...),
+	}
+	s.nextRange = 0
+	sessions := make([]*session, 0, len(s.sessions))
+	for sess := range s.sessions {
+		if sess.subscribed() {
+			sessions = append(sessions, sess)
+		}
+	}
+	s.mu.Unlock()
+	return sessions, nil
+}
+
+// notifyWork выдаёт сессиям задания текущего шаблона. Запись в медленное
+// соединение ждёт до WriteTimeout, поэтому chainMu при рассылке не держится.
+func (s *Server) notifyWork(sessions []*session) {
+	for _, sess := range sessions {
+		if job, err := s.issueJob(sess, true); err == nil {
+			sess.notify(job)
+		}
+	}
+}
+
+// issueJob выделяет сессии новый отрезок nonce текущего шаблона
+func (s *Server) issueJob(sess *session, clean bool) (Job, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	w := s.work
+	if w == nil {
+		return Job{}, errors.New("no work available")
+	}
+
+	size := s.config.NonceRangeSize
+	if s.nextRange > (math.MaxUint64-size+1)/size {
+		return Job{}, errors.New("nonce space of current template exhausted")
+	}
+	first := s.nextRange * size
+	last := first + size - 1
+	s.nextRange++
+
+	id := fmt.Sprintf("%x-%x", w.seq, s.nextRange)
+	sess.addJob(id, assignedJob{work: w, first: first, last: last}, clean)
+
+	return Job{
+		ID:              id,
+		Height:          w.tpl.Header.Index,
+		Header:          hex.EncodeToString(w.base),
+		NonceOffset:     w.offset,
+		NonceStart:      first,
+		NonceEnd:        last,
+		Target:          hex.EncodeToString(w.target[:]),
+		ShareDifficulty: s.config.ShareDifficulty,
+		Algorithm:       s.algo.Name(),
+		Clean:           clean,
+	}, nil
+}
+
+func (s *Server) currentWork() *work {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.work
+}
+
+// submit проверяет долю сессии и, если она решает блок, отправляет его в цепочку
+func (s *Server) submit(sess *session, p SubmitParams) (SubmitResult, error) {
+	job, ok := sess.job(p.JobID)
+	if !ok || job.work != s.currentWork() {
+		return SubmitResult{}, ErrStaleJob
+	}
+	if p.Nonce < job.first || p.Nonce > job.last {
+		return SubmitResult{}, ErrNonceOutOfRange
+	}
+
+	w := job.work
+	data := append([]byte(nil), w.base...)
+	binary.LittleEndian.PutUint64(data[w.offset:], p.Nonce)
+	powHash := s.algo.Hash(data)
+
+	isBlock := powHash.MeetsTarget(w.target)
+	if !isBlock && !powHash.IsValidForDifficulty(s.config.ShareDifficulty) {
+		return SubmitResult{}, ErrLowDifficulty
+	}
+	if !sess.markSubmitted(w, p.Nonce) {
+		return SubmitResult{}, ErrDuplicateShare
+	}
+
+	share := Share{
+		Worker:     sess.worker,
+		Session:    sess.id,
+		JobID:      p.JobID,
+		Height:     w.tpl.Header.Index,
+		Nonce:      p.Nonce,
+		Hash:       powHash.Bytes(),
+		Difficulty: s.config.ShareDifficulty,
+		Block:      isBlock,
+	}
+	// доля учитывается раньше блока, чтобы попасть в его выплаты
+	if s.config.OnShare != nil {
+		s.config.OnShare(share)
+	}
+
+	result := SubmitResult{Accepted: true}
+	if isBlock {
+		result.Block = s.submitBlock(w, p.Nonce)
+	}
+	return result, nil
+}
+
+// submitBlock отправляет решение в цепочку и раздаёт новый шаблон
+func (s *Server) submitBlock(w *work, nonce uint64) bool {
+	sessions, ok := s.acceptBlock(w, nonce)
+	s.notifyWork(sessions)
+	return ok
+}
+
+// acceptBlock отправляет решение в цепочку под chainMu и собирает новый
+// шаблон; возвращает сессии, которым нужно разослать задания
+func (s *Server) acceptBlock(w *work, nonce uint64) ([]*session, bool) {
+	s.chainMu.Lock()
+	defer s.chainMu.Unlock()
+
+	// пока ждали chainMu, решение мог найти другой майнер
+	if w != s.currentWork() {
+		return nil, false
+	}
+
+	// шаблон общий для всех сессий, поэтому решение собирается в копии
+	b := w.tpl.Clone()
+	b.Header.Nonce = nonce
+	blockHash, err := b.CalculateHash()
+	if err != nil {
+		return nil, false
+	}
+	b.Hash = blockHash
+
+	if err := s.chain.SubmitBlock(s.ctx, b); err != nil {
+		return nil, false
+	}
+
+	// решённый шаблон больше не принимает доли, даже если новый не соберётся
+	s.mu.Lock()
+	if s.work == w {
+		s.work = nil
+	}
+	s.mu.Unlock()
+
+	if s.txs != nil && len(b.Transaction) > 1 {
+		s.txs.Remove(b.Transaction[1:])
+	}
+	if s.config.OnBlock != nil {
+		s.config.OnBlock(b)
+	}
+
+	// ошибка сборки шаблона повторится при следующем опросе вершины
+	sessions, _ := s.refreshWork()
+	return sessions, true
+}
+
+func (s *Server) serveConn(conn net.Conn) {
+	defer s.wg.Done()
+
+	s.mu.Lock()
+	if s.closed {
+		s.mu.Unlock()
+		conn.Close()
+		return
+	}
+	s.sessionSeq++
+	sess := newSession(fmt.Sprintf("%08x", s.sessionSeq), conn, s.config.WriteTimeout)
+	s.sessions[sess] = struct{}{}
+	s.mu.Unlock()
+
+	defer func() {
+		s.mu.Lock()
+		delete(s.sessions, sess)
+		s.mu.Unlock()
+		conn.Close()
+	}()
+
+	scanner := bufio.NewScanner(conn)
+	scanner.Buffer(make([]byte, 0, 4096), MaxLineSize)
+
+	for scanner.Scan() {
+		var msg Message
+		if err := json.Unmarshal(scanner.Bytes(), &msg); err != nil {
+			sess.reply(nil, nil, fmt.Errorf("invalid message: %w", err))
+			continue
+		}
+		s.dispatch(sess, msg)
+	}
+}
+
+func (s *Server) dispatch(sess *session, msg Message) {
+	switch msg.Method {
+	case MethodSubscribe:
+		var p SubscribeParams
+		if len(msg.Params) > 0 {
+			if err := json.Unmarshal(msg.Params, &p); err != nil {
+				sess.reply(msg.ID, nil, fmt.Errorf("invalid params: %w", err))
+				return
+			}
+		}
+		if s.config.Authorize != nil {
+			if err := s.config.Authorize(p.Worker); err != nil {
+				sess.reply(msg.ID, nil, fmt.Errorf("%w: %v", ErrUnauthorized, err))
+				return
+			}
+		}
+		sess.subscribe(p.Worker)
+		sess.reply(msg.ID, SubscribeResult{Session: sess.id, ShareDifficulty: s.config.ShareDifficulty}, nil)
+
+		job, err := s.issueJob(sess, true)
+		if err != nil {
+			return
+		}
+		sess.notify(job)
+
+	case MethodGetJob:
+		if !sess.subscribed() {
+			sess.reply(msg.ID, nil, ErrNotSubscribed)
+			return
+		}
+		job, err := s.issueJob(sess, false)
+		sess.reply(msg.ID, job, err)
+
+	case MethodSubmit:
+		if !sess.subscribed() {
+			sess.reply(msg.ID, nil, ErrNotSubscribed)
+			return
+		}
+		var p SubmitParams
+		if err := json.Unmarshal(msg.Params, &p); err != nil {
+			sess.reply(msg.ID, nil, fmt.Errorf("invalid params: %w", err))
+			return
+		}
+		result, err := s.submit(sess, p)
+		sess.reply(msg.ID, result, err)
+
+	default:
+		sess.reply(msg.ID, nil, fmt.Errorf("%w: %q", ErrUnknownMethod, msg.Method))
+	}
+}
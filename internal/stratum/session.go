@@ -0,0 +1,130 @@
+package stratum
+
+import (
+	"encoding/json"
+	"net"
+	"sync"
+	"time"
+)
+
+// assignedJob - отрезок nonce, выданный сессии для шаблона work
+type assignedJob struct {
+	work  *work
+	first uint64
+	last  uint64
+}
+
+// session - состояние одного подключённого майнера
+type session struct {
+	id           string
+	conn         net.Conn
+	writeTimeout time.Duration
+
+	writeMu sync.Mutex
+
+	mu        sync.Mutex
+	worker    string
+	active    bool
+	jobs      map[string]assignedJob
+	submitted map[uint64]struct{}
+	// submittedWork - шаблон, к которому относится submitted
+	submittedWork *work
+}
+
+func newSession(id string, conn net.Conn, writeTimeout time.Duration) *session {
+	return &session{
+		id:           id,
+		conn:         conn,
+		writeTimeout: writeTimeout,
+		jobs:         make(map[string]assignedJob),
+	}
+}
+
+func (s *session) subscribe(worker string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.worker = worker
+	s.active = true
+}
+
+func (s *session) subscribed() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.active
+}
+
+// addJob запоминает выданное задание; clean забывает задания старых шаблонов
+func (s *session) addJob(id string, job assignedJob, clean bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if clean {
+		s.jobs = make(map[string]assignedJob)
+	}
+	s.jobs[id] = job
+}
+
+func (s *session) job(id string) (assignedJob, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	job, ok := s.jobs[id]
+	return job, ok
+}
+
+// markSubmitted отмечает nonce шаблона w; false - доля уже присылалась
+func (s *session) markSubmitted(w *work, nonce uint64) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.submittedWork != w {
+		s.submittedWork = w
+		s.submitted = make(map[uint64]struct{})
+	}
+	if _, ok := s.submitted[nonce]; ok {
+		return false
+	}
+	s.submitted[nonce] = struct{}{}
+	return true
+}
+
+func (s *session) notify(job Job) {
+	params, err := json.Marshal(job)
+	if err != nil {
+		return
+	}
+	s.write(Message{Method: MethodNotify, Params: params})
+}
+
+func (s *session) reply(id *uint64, result interface{}, err error) {
+	msg := Message{ID: id}
+	if err != nil {
+		msg.Error = err.Error()
+	} else if result != nil {
+		data, mErr := json.Marshal(result)
+		if mErr != nil {
+			msg.Error = mErr.Error()
+		} else {
+			msg.Result = data
+		}
+	}
+	s.write(msg)
+}
+
+// write отправляет строку; медленный клиент отключается по WriteTimeout
+func (s *session) write(msg Message) {
+	data, err := json.Marshal(msg)
+	if err != nil {
+		return
+	}
+	data = append(data, '\n')
+
+	s.writeMu.Lock()
+	defer s.writeMu.Unlock()
+
+	s.conn.SetWriteDeadline(time.Now().Add(s.writeTimeout))
+	if _, err := s.conn.Write(data); err != nil {
+		s.conn.Close()
+	}
+}
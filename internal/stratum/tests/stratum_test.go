@@ -0,0 +1,397 @@
+package tests
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"net"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/Alex1997377/weave/internal/core/block"
+	"github.com/Alex1997377/weave/internal/crypto/powalgo"
+	"github.com/Alex1997377/weave/internal/stratum"
+	"github.com/Alex1997377/weave/internal/tests/helpers"
+)
+
+var rewardAddress = bytes.Repeat([]byte{0x42}, 32)
+
+// startServer запускает сервер на свободном локальном порту
+func startServer(t *testing.T, srv *stratum.Server) string {
+	t.Helper()
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen error: %v", err)
+	}
+
+	served := make(chan error, 1)
+	go func() { served <- srv.Serve(l) }()
+	t.Cleanup(func() {
+		srv.Close()
+		if err := <-served; !errors.Is(err, stratum.ErrServerClosed) {
+			t.Errorf("Serve returned %v, want ErrServerClosed", err)
+		}
+	})
+
+	// шаблон строится до первого Accept, поэтому ждём, пока сервер начнёт отвечать
+	helpers.WaitFor(t, 5*time.Second, func() bool { return srv.Addr() != nil })
+	return l.Addr().String()
+}
+
+func TestServer_ClientMinesBlocks(t *testing.T) {
+	bc := helpers.NewTestChain(t)
+
+	var shares, blocks atomic.Int32
+	srv, err := stratum.NewServer(bc, nil, stratum.ServerConfig{
+		RewardAddress:   rewardAddress,
+		ShareDifficulty: 4,
+		NonceRangeSize:  1 << 12,
+		TipPollInterval: 10 * time.Millisecond,
+		OnShare:         func(stratum.Share) { shares.Add(1) },
+		OnBlock:         func(*block.Block) { blocks.Add(1) },
+	})
+	if err != nil {
+		t.Fatalf("NewServer error: %v", err)
+	}
+	addr := startServer(t, srv)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	client, err := stratum.Dial(ctx, addr, "rig-1")
+	if err != nil {
+		t.Fatalf("Dial error: %v", err)
+	}
+	defer client.Close()
+
+	if got := client.Session().ShareDifficulty; got != 4 {
+		t.Errorf("share difficulty = %d, want 4", got)
+	}
+
+	mined := make(chan error, 1)
+	go func() { mined <- client.Mine(ctx, 2) }()
+
+	helpers.WaitFor(t, 10*time.Second, func() bool { return blocks.Load() >= 3 })
+	cancel()
+	if err := <-mined; !errors.Is(err, context.Canceled) {
+		t.Errorf("Mine returned %v, want context.Canceled", err)
+	}
+	// цепочку можно читать только после остановки сервера
+	srv.Close()
+
+	if err := bc.IsValid(t.Context()); err != nil {
+		t.Fatalf("chain is invalid after pool mining: %v", err)
+	}
+	if got := bc.Height(); got != int(blocks.Load()) {
+		t.Errorf("chain height = %d, want %d mined blocks", got, blocks.Load())
+	}
+	if shares.Load() < blocks.Load() {
+		t.Errorf("shares %d < blocks %d", shares.Load(), blocks.Load())
+	}
+
+	stats := client.Stats()
+	if stats.Blocks < 3 || stats.Accepted < stats.Blocks {
+		t.Errorf("unexpected client stats: %+v", stats)
+	}
+
+	balance, err := bc.GetBalance(t.Context(), rewardAddress)
+	if err != nil {
+		t.Fatalf("GetBalance error: %v", err)
+	}
+	if balance <= 0 {
+		t.Errorf("reward address balance = %v, want positive", balance)
+	}
+}
+
+// rawConn - соединение с сервером без клиента, чтобы отправлять произвольные доли
+type rawConn struct {
+	t      *testing.T
+	conn   net.Conn
+	reader *bufio.Reader
+	nextID uint64
+	// notifies - уведомления, пришедшие раньше ответа на запрос
+	notifies []stratum.Message
+}
+
+func dialRaw(t *testing.T, addr string) *rawConn {
+	t.Helper()
+
+	conn, err := net.Dial("tcp", addr)
+	if err != nil {
+		t.Fatalf("dial error: %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return &rawConn{t: t, conn: conn, reader: bufio.NewReader(conn)}
+}
+
+func (c *rawConn) send(method string, params interface{}) uint64 {
+	c.t.Helper()
+
+	c.nextID++
+	id := c.nextID
+	msg := stratum.Message{ID: &id, Method: method}
+	if params != nil {
+		data, err := json.Marshal(params)
+		if err != nil {
+			c.t.Fatalf("marshal error: %v", err)
+		}
+		msg.Params = data
+	}
+
+	data, _ := json.Marshal(msg)
+	if _, err := c.conn.Write(append(data, '\n')); err != nil {
+		c.t.Fatalf("write error: %v", err)
+	}
+	return id
+}
+
+func (c *rawConn) read() stratum.Message {
+	c.t.Helper()
+
+	c.conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	line, err := c.reader.ReadBytes('\n')
+	if err != nil {
+		c.t.Fatalf("read error: %v", err)
+	}
+	var msg stratum.Message
+	if err := json.Unmarshal(line, &msg); err != nil {
+		c.t.Fatalf("invalid message %q: %v", line, err)
+	}
+	return msg
+}
+
+// call отправляет запрос и возвращает ответ на него, пропуская уведомления
+func (c *rawConn) call(method string, params interface{}) stratum.Message {
+	c.t.Helper()
+
+	id := c.send(method, params)
+	for {
+		msg := c.read()
+		if msg.ID != nil && *msg.ID == id {
+			return msg
+		}
+		if msg.Method == stratum.MethodNotify {
+			c.notifies = append(c.notifies, msg)
+		}
+	}
+}
+
+func (c *rawConn) readJob() stratum.Job {
+	c.t.Helper()
+
+	var msg stratum.Message
+	if len(c.notifies) > 0 {
+		msg, c.notifies = c.notifies[0], c.notifies[1:]
+	} else {
+		msg = c.read()
+	}
+	if msg.Method != stratum.MethodNotify {
+		c.t.Fatalf("expected notify, got %+v", msg)
+	}
+	var job stratum.Job
+	if err := json.Unmarshal(msg.Params, &job); err != nil {
+		c.t.Fatalf("invalid job: %v", err)
+	}
+	return job
+}
+
+// findNonce ищет в отрезке задания nonce, хеш которого удовлетворяет cond
+func findNonce(t *testing.T, job stratum.Job, cond func(h interface {
+	IsValidForDifficulty(int) bool
+}) bool) uint64 {
+	t.Helper()
+
+	data, err := job.HeaderBytes()
+	if err != nil {
+		t.Fatalf("HeaderBytes error: %v", err)
+	}
+	algo, err := powalgo.Get(job.Algorithm)
+	if err != nil {
+		t.Fatalf("unknown algorithm: %v", err)
+	}
+
+	for nonce := job.NonceStart; nonce <= job.NonceEnd; nonce++ {
+		binary.LittleEndian.PutUint64(data[job.NonceOffset:], nonce)
+		if cond(algo.Hash(data)) {
+			return nonce
+		}
+	}
+	t.Fatal("no suitable nonce in job range")
+	return 0
+}
+
+func TestServer_ShareValidation(t *testing.T) {
+	stub := helpers.NewStubChain(bytes.Repeat([]byte{0xAA}, 32), 12)
+
+	var shares []stratum.Share
+	var sharesMu sync.Mutex
+	srv, err := stratum.NewServer(stub, nil, stratum.ServerConfig{
+		RewardAddress:   rewardAddress,
+		ShareDifficulty: 2,
+		NonceRangeSize:  1 << 16,
+		TipPollInterval: time.Hour,
+		OnShare: func(s stratum.Share) {
+			sharesMu.Lock()
+			shares = append(shares, s)
+			sharesMu.Unlock()
+		},
+	})
+	if err != nil {
+		t.Fatalf("NewServer error: %v", err)
+	}
+	addr := startServer(t, srv)
+	conn := dialRaw(t, addr)
+
+	if msg := conn.call(stratum.MethodSubmit, stratum.SubmitParams{JobID: "1-1"}); msg.Error != stratum.ErrNotSubscribed.Error() {
+		t.Errorf("submit before subscribe: error %q, want %q", msg.Error, stratum.ErrNotSubscribed)
+	}
+	if msg := conn.call("mining.unknown", nil); msg.Error == "" {
+		t.Error("expected error for unknown method")
+	}
+
+	if msg := conn.call(stratum.MethodSubscribe, stratum.SubscribeParams{Worker: "raw"}); msg.Error != "" {
+		t.Fatalf("subscribe error: %s", msg.Error)
+	}
+	job := conn.readJob()
+	if !job.Clean || job.ShareDifficulty != 2 || job.NonceEnd-job.NonceStart+1 != 1<<16 {
+		t.Fatalf("unexpected first job: %+v", job)
+	}
+
+	// второй запрос получает соседний отрезок того же шаблона
+	var second stratum.Job
+	msg := conn.call(stratum.MethodGetJob, nil)
+	if err := json.Unmarshal(msg.Result, &second); err != nil || msg.Error != "" {
+		t.Fatalf("get_job failed: %q %v", msg.Error, err)
+	}
+	if second.Header != job.Header || second.NonceStart != job.NonceEnd+1 {
+		t.Errorf("get_job returned overlapping or foreign range: %+v", second)
+	}
+
+	share := findNonce(t, job, func(h interface{ IsValidForDifficulty(int) bool }) bool {
+		return h.IsValidForDifficulty(2) && !h.IsValidForDifficulty(12)
+	})
+	weak := findNonce(t, job, func(h interface{ IsValidForDifficulty(int) bool }) bool {
+		return !h.IsValidForDifficulty(1)
+	})
+
+	cases := []struct {
+		name   string
+		params stratum.SubmitParams
+		err    error
+	}{
+		{"unknown job", stratum.SubmitParams{JobID: "ff-1", Nonce: share}, stratum.ErrStaleJob},
+		{"out of range", stratum.SubmitParams{JobID: job.ID, Nonce: second.NonceStart}, stratum.ErrNonceOutOfRange},
+		{"low difficulty", stratum.SubmitParams{JobID: job.ID, Nonce: weak}, stratum.ErrLowDifficulty},
+		{"share", stratum.SubmitParams{JobID: job.ID, Nonce: share}, nil},
+		{"duplicate", stratum.SubmitParams{JobID: job.ID, Nonce: share}, stratum.ErrDuplicateShare},
+	}
+	for _, tc := range cases {
+		msg := conn.call(stratum.MethodSubmit, tc.params)
+		if tc.err != nil {
+			if msg.Error != tc.err.Error() {
+				t.Errorf("%s: error %q, want %q", tc.name, msg.Error, tc.err)
+			}
+			continue
+		}
+
+		var result stratum.SubmitResult
+		if err := json.Unmarshal(msg.Result, &result); err != nil || msg.Error != "" {
+			t.Fatalf("%s: submit failed: %q %v", tc.name, msg.Error, err)
+		}
+		if !result.Accepted || result.Block {
+			t.Errorf("%s: unexpected result %+v", tc.name, result)
+		}
+	}
+
+	// решение блока уходит в цепочку, а старое задание становится устаревшим
+	solution := findNonce(t, second, func(h interface{ IsValidForDifficulty(int) bool }) bool {
+		return h.IsValidForDifficulty(12)
+	})
+	msg = conn.call(stratum.MethodSubmit, stratum.SubmitParams{JobID: second.ID, Nonce: solution})
+	var result stratum.SubmitResult
+	if err := json.Unmarshal(msg.Result, &result); err != nil || !result.Block {
+		t.Fatalf("block solution not accepted: %q %+v", msg.Error, result)
+	}
+
+	submitted := stub.Submitted()
+	if len(submitted) != 1 || submitted[0].Header.Nonce != solution {
+		t.Fatalf("unexpected submitted blocks: %d", len(submitted))
+	}
+	if err := submitted[0].Validate(); err != nil {
+		t.Errorf("submitted block is invalid: %v", err)
+	}
+
+	next := conn.readJob()
+	if !next.Clean || next.Header == job.Header {
+		t.Errorf("expected clean job for new tip, got %+v", next)
+	}
+	if msg := conn.call(stratum.MethodSubmit, stratum.SubmitParams{JobID: job.ID, Nonce: share + 1}); msg.Error != stratum.ErrStaleJob.Error() {
+		t.Errorf("old job: error %q, want %q", msg.Error, stratum.ErrStaleJob)
+	}
+
+	sharesMu.Lock()
+	defer sharesMu.Unlock()
+	if len(shares) != 2 || shares[0].Worker != "raw" || shares[0].Block || !shares[1].Block {
+		t.Errorf("unexpected shares: %+v", shares)
+	}
+}
+
+func TestServer_SolvedWorkIsNotReused(t *testing.T) {
+	stub := helpers.NewStubChain(bytes.Repeat([]byte{0xAA}, 32), 12)
+	srv, err := stratum.NewServer(stub, nil, stratum.ServerConfig{
+		RewardAddress:   rewardAddress,
+		ShareDifficulty: 2,
+		NonceRangeSize:  1 << 18,
+		TipPollInterval: time.Hour,
+	})
+	if err != nil {
+		t.Fatalf("NewServer error: %v", err)
+	}
+	conn := dialRaw(t, startServer(t, srv))
+	if msg := conn.call(stratum.MethodSubscribe, stratum.SubscribeParams{Worker: "raw"}); msg.Error != "" {
+		t.Fatalf("subscribe error: %s", msg.Error)
+	}
+	job := conn.readJob()
+
+	isBlock := func(h interface{ IsValidForDifficulty(int) bool }) bool { return h.IsValidForDifficulty(12) }
+	first := findNonce(t, job, isBlock)
+	rest := job
+	rest.NonceStart = first + 1
+	second := findNonce(t, rest, isBlock)
+
+	// после принятого блока новый шаблон собрать не удаётся
+	stub.SetTemplateErr(errors.New("template unavailable"))
+
+	msg := conn.call(stratum.MethodSubmit, stratum.SubmitParams{JobID: job.ID, Nonce: first})
+	var result stratum.SubmitResult
+	if err := json.Unmarshal(msg.Result, &result); err != nil || !result.Block {
+		t.Fatalf("block solution not accepted: %q %+v", msg.Error, result)
+	}
+	if msg := conn.call(stratum.MethodSubmit, stratum.SubmitParams{JobID: job.ID, Nonce: second}); msg.Error != stratum.ErrStaleJob.Error() {
+		t.Errorf("second solution of solved work: error %q, want %q", msg.Error, stratum.ErrStaleJob)
+	}
+
+	submitted := stub.Submitted()
+	if len(submitted) != 1 {
+		t.Fatalf("submitted %d blocks, want 1", len(submitted))
+	}
+	if b := submitted[0]; b.Header.Nonce != first || b.Validate() != nil {
+		t.Errorf("accepted block changed after submit: nonce %d, want %d", b.Header.Nonce, first)
+	}
+}
+
+func TestNewServer_Errors(t *testing.T) {
+	if _, err := stratum.NewServer(nil, nil, stratum.ServerConfig{RewardAddress: rewardAddress}); err == nil {
+		t.Error("expected error for nil chain")
+	}
+	stub := helpers.NewStubChain(make([]byte, 32), 12)
+	if _, err := stratum.NewServer(stub, nil, stratum.ServerConfig{RewardAddress: []byte{1}}); err == nil {
+		t.Error("expected error for short reward address")
+	}
+}
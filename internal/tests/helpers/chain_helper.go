@@ -0,0 +1,169 @@
+package helpers
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Alex1997377/weave/internal/clock"
+	"github.com/Alex1997377/weave/internal/core/block"
+	"github.com/Alex1997377/weave/internal/core/chain"
+	"github.com/Alex1997377/weave/internal/core/consensus"
+	"github.com/Alex1997377/weave/internal/core/header"
+	"github.com/Alex1997377/weave/internal/core/transaction"
+	"github.com/Alex1997377/weave/internal/store"
+	"github.com/dgraph-io/badger/v4"
+)
+
+// OpenTestDB открывает badger в памяти и закрывает его по завершении теста
+func OpenTestDB(t *testing.T) *badger.DB {
+	t.Helper()
+
+	db, err := badger.Open(badger.DefaultOptions("").WithInMemory(true).WithLogger(nil))
+	if err != nil {
+		t.Fatalf("failed to open badger: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+	return db
+}
+
+// NewTestRepository создаёт хранилище поверх OpenTestDB
+func NewTestRepository(t *testing.T) *store.Repository {
+	t.Helper()
+	return store.NewRepository(OpenTestDB(t))
+}
+
+// ChainOption настраивает цепочку NewTestChain
+type ChainOption func(*chainOptions)
+
+type chainOptions struct {
+	store  block.BlockStore
+	params *consensus.Params
+	clock  clock.Clock
+}
+
+// WithStore задаёт хранилище цепочки вместо NewTestRepository
+func WithStore(s block.BlockStore) ChainOption {
+	return func(o *chainOptions) { o.store = s }
+}
+
+// WithParams задаёт параметры сети вместо consensus.Regtest
+func WithParams(p *consensus.Params) ChainOption {
+	return func(o *chainOptions) { o.params = p }
+}
+
+// WithClock задаёт часы цепочки вместо системных
+func WithClock(clk clock.Clock) ChainOption {
+	return func(o *chainOptions) { o.clock = clk }
+}
+
+// NewTestChain создаёт цепочку regtest в badger в памяти и закрывает её по
+// завершении теста; opts меняют хранилище, параметры сети и часы
+func NewTestChain(t *testing.T, opts ...ChainOption) *chain.Blockchain {
+	t.Helper()
+
+	var o chainOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+	if o.store == nil {
+		o.store = NewTestRepository(t)
+	}
+	if o.params == nil {
+		o.params = consensus.Regtest()
+	}
+
+	bc, err := chain.NewBlockchainWithClock(t.Context(), o.store, o.params, o.clock)
+	if err != nil {
+		t.Fatalf("NewBlockchain error: %v", err)
+	}
+	t.Cleanup(func() { bc.Close() })
+	return bc
+}
+
+// StubChain - цепочка для тестов майнера и stratum: выдаёт шаблоны
+// сложности Difficulty поверх своей вершины, считает их и запоминает
+// отправленные блоки. Отправленный блок становится вершиной.
+type StubChain struct {
+	Difficulty int
+
+	mu          sync.Mutex
+	tip         []byte
+	templates   int
+	submitted   []*block.Block
+	templateErr error
+}
+
+// NewStubChain создаёт StubChain с вершиной tip
+func NewStubChain(tip []byte, difficulty int) *StubChain {
+	return &StubChain{Difficulty: difficulty, tip: tip}
+}
+
+func (c *StubChain) Params() *consensus.Params { return consensus.Regtest() }
+
+func (c *StubChain) TipHash() []byte {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([]byte(nil), c.tip...)
+}
+
+// SetTip переносит вершину
+func (c *StubChain) SetTip(tip []byte) {
+	c.mu.Lock()
+	c.tip = tip
+	c.mu.Unlock()
+}
+
+// SetTemplateErr задаёт ошибку, которую вернёт BlockTemplate; nil - шаблоны снова выдаются
+func (c *StubChain) SetTemplateErr(err error) {
+	c.mu.Lock()
+	c.templateErr = err
+	c.mu.Unlock()
+}
+
+// Templates возвращает число выданных шаблонов
+func (c *StubChain) Templates() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.templates
+}
+
+// Submitted возвращает отправленные блоки
+func (c *StubChain) Submitted() []*block.Block {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([]*block.Block(nil), c.submitted...)
+}
+
+func (c *StubChain) BlockTemplate(_ context.Context, recipient []byte, txs []transaction.Transaction) (*block.Block, error) {
+	c.mu.Lock()
+	if err := c.templateErr; err != nil {
+		c.mu.Unlock()
+		return nil, err
+	}
+	c.templates++
+	prev := append([]byte(nil), c.tip...)
+	c.mu.Unlock()
+
+	tpl := &block.Block{
+		Header: header.Header{
+			Version:      header.Version1,
+			Index:        1,
+			Difficulty:   c.Difficulty,
+			Timestamp:    time.Now().Unix(),
+			PreviousHash: prev,
+		},
+		Transaction: []transaction.Transaction{transaction.NewCoinbase(1, recipient, 1)},
+	}
+	return tpl, tpl.SetMerkleRoot()
+}
+
+func (c *StubChain) SubmitBlock(_ context.Context, b *block.Block) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.submitted = append(c.submitted, b)
+	c.tip = b.Hash
+	return nil
+}
@@ -0,0 +1,17 @@
+package helpers
+
+import (
+	"bytes"
+
+	"github.com/Alex1997377/weave/internal/core/transaction"
+)
+
+// BankTransaction возвращает корректную банковскую транзакцию с ID из байта seed
+func BankTransaction(seed byte) *transaction.BankTransaction {
+	return &transaction.BankTransaction{
+		ID:        bytes.Repeat([]byte{seed}, 32),
+		Sender:    bytes.Repeat([]byte{0x01}, 32),
+		Recipient: bytes.Repeat([]byte{0x02}, 32),
+		Amount:    1,
+	}
+}
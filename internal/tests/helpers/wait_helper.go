@@ -0,0 +1,19 @@
+package helpers
+
+import (
+	"testing"
+	"time"
+)
+
+// WaitFor ждёт выполнения условия не дольше timeout
+func WaitFor(t *testing.T, timeout time.Duration, cond func() bool) {
+	t.Helper()
+
+	deadline := time.Now().Add(timeout)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatal("condition not met before timeout")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
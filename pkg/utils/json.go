@@ -0,0 +1,38 @@
+package utils
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// DecodeJSONStrict декодирует ровно одно JSON-значение, запрещая неизвестные поля
+// и данные после значения
+func DecodeJSONStrict(data []byte, v interface{}) error {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+
+	if err := dec.Decode(v); err != nil {
+		return err
+	}
+
+	if _, err := dec.Token(); !errors.Is(err, io.EOF) {
+		return fmt.Errorf("unexpected data after JSON value")
+	}
+
+	return nil
+}
+
+// HexToFixedBytes декодирует hex-строку и проверяет её длину в байтах
+func HexToFixedBytes(field, s string, size int) ([]byte, error) {
+	b, err := HexToBytes(s)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", field, err)
+	}
+	if len(b) != size {
+		return nil, fmt.Errorf("%s: invalid length %d (expected %d)", field, len(b), size)
+	}
+	return b, nil
+}